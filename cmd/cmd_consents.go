@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tuanta7/hydros/internal/flow"
+	"github.com/urfave/cli/v3"
+)
+
+func NewConsentsCommand(flowUC *flow.UseCase) *cli.Command {
+	subjectFlag := &cli.StringFlag{
+		Name:     "subject",
+		Aliases:  []string{"s"},
+		Usage:    "subject that granted the consents",
+		Required: true,
+	}
+
+	cmd := &cli.Command{
+		Name:  "consents",
+		Usage: "list and revoke the consents subjects granted to clients",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list the latest consent the subject granted to each client",
+				Flags: []cli.Flag{
+					subjectFlag,
+					&cli.StringFlag{
+						Name:     "client-id",
+						Aliases:  []string{"c"},
+						Usage:    "only list the consent granted to this client",
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					consents, err := flowUC.ListGrantedConsents(ctx, command.String("subject"), command.String("client-id"))
+					if err != nil {
+						return exitErr(err)
+					}
+
+					jsonConsents, _ := json.MarshalIndent(consents, "", "\t")
+					fmt.Printf("Consents of %s: %s\n", command.String("subject"), jsonConsents)
+					return nil
+				},
+			},
+			{
+				Name:  "revoke",
+				Usage: "withdraw the consent of the subject and revoke the tokens issued under it",
+				Flags: []cli.Flag{
+					subjectFlag,
+					&cli.StringFlag{
+						Name:     "client-id",
+						Aliases:  []string{"c"},
+						Usage:    "client to withdraw the consent from",
+						Required: false,
+					},
+					&cli.BoolFlag{
+						Name:     "all",
+						Usage:    "withdraw the consent from every client",
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					subject, clientID := command.String("subject"), command.String("client-id")
+					if clientID == "" && !command.Bool("all") {
+						return cli.Exit("either --client-id or --all is required", 1)
+					}
+
+					err := flowUC.RevokeConsents(ctx, subject, clientID)
+					if err != nil {
+						return exitErr(err)
+					}
+
+					if clientID == "" {
+						fmt.Printf("Consents of %s are revoked for every client\n", subject)
+					} else {
+						fmt.Printf("Consent of %s is revoked for %s\n", subject, clientID)
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	return cmd
+}
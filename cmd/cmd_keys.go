@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/jwk"
+	"github.com/urfave/cli/v3"
+)
+
+func NewKeysCommand(jwkUC *jwk.UseCase) *cli.Command {
+	setFlag := &cli.StringFlag{
+		Name:     "set",
+		Aliases:  []string{"s"},
+		Usage:    "key set, either id-token or access-token",
+		Required: true,
+	}
+
+	cmd := &cli.Command{
+		Name:  "keys",
+		Usage: "manage the keys that sign ID tokens and JWT access tokens",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list the keys of every set, or of one set",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "set",
+						Aliases:  []string{"s"},
+						Usage:    "key set, either id-token or access-token",
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					sets := jwk.Sets
+					if command.String("set") != "" {
+						set, err := parseSet(command)
+						if err != nil {
+							return err
+						}
+						sets = []jwk.Set{set}
+					}
+
+					for _, set := range sets {
+						keys, err := jwkUC.ListKeys(ctx, set)
+						if err != nil {
+							return exitErr(err)
+						}
+
+						jsonKeys, _ := json.MarshalIndent(keys, "", "\t")
+						fmt.Printf("Keys of %s: %s\n", set, jsonKeys)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "generate",
+				Usage: "generate a next key, it is published at once and signs tokens once it is activated",
+				Flags: []cli.Flag{
+					setFlag,
+					&cli.StringFlag{
+						Name:     "alg",
+						Aliases:  []string{"a"},
+						Usage:    "signing algorithm, defaults to the algorithm configured for the set",
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					set, err := parseSet(command)
+					if err != nil {
+						return err
+					}
+
+					key, err := jwkUC.GenerateKey(ctx, set, jose.SignatureAlgorithm(command.String("alg")))
+					if err != nil {
+						return exitErr(err)
+					}
+
+					jsonKey, _ := json.MarshalIndent(key, "", "\t")
+					fmt.Println("New Key:", string(jsonKey))
+					return nil
+				},
+			},
+			newKeyStateCommand("activate", "make a next key the active key, the active key is retired",
+				setFlag, jwk.KeyStateActive, jwkUC.ActivateKey),
+			newKeyStateCommand("retire", "stop publishing a next key or end the grace period of a retired key",
+				setFlag, jwk.KeyStateRetired, jwkUC.RetireKey),
+			newKeyStateCommand("revoke", "stop trusting a key at once, every token it signed becomes invalid",
+				setFlag, jwk.KeyStateRevoked, jwkUC.RevokeKey),
+		},
+	}
+
+	return cmd
+}
+
+func newKeyStateCommand(name, usage string, setFlag cli.Flag, state jwk.KeyState,
+	fn func(ctx context.Context, set jwk.Set, kid string) error,
+) *cli.Command {
+	return &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: "<kid>",
+		Flags:     []cli.Flag{setFlag},
+		Action: func(ctx context.Context, command *cli.Command) error {
+			set, err := parseSet(command)
+			if err != nil {
+				return err
+			}
+
+			kid := command.Args().First()
+			if kid == "" {
+				return cli.Exit("the kid of the key is required", 1)
+			}
+
+			err = fn(ctx, set, kid)
+			if err != nil {
+				return exitErr(err)
+			}
+
+			fmt.Printf("Key %s of %s is now %s\n", kid, set, state)
+			return nil
+		},
+	}
+}
+
+// exitErr prefers the hint of an RFC 6749 error, which explains the failure better than its error code.
+func exitErr(err error) error {
+	var rfcErr *core.RFC6749Error
+	if errors.As(err, &rfcErr) && rfcErr.HintField != "" {
+		return cli.Exit(rfcErr.HintField, 1)
+	}
+
+	return cli.Exit(err, 1)
+}
+
+func parseSet(command *cli.Command) (jwk.Set, error) {
+	set, ok := jwk.ParseSet(command.String("set"))
+	if !ok {
+		return "", cli.Exit(fmt.Sprintf("unknown key set %q", command.String("set")), 1)
+	}
+
+	return set, nil
+}
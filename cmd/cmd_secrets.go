@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/tuanta7/hydros/core/signer/hmac"
+	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/token"
+	"github.com/urfave/cli/v3"
+)
+
+func NewSecretsCommand(cfg *config.Config, tokenStorage *token.RequestSessionStorage) *cli.Command {
+	cmd := &cli.Command{
+		Name:  "secrets",
+		Usage: "rotate the global secret that signs opaque tokens and cookies",
+		Commands: []*cli.Command{
+			{
+				Name:  "generate",
+				Usage: "generate a new global secret",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:     "bytes",
+						Aliases:  []string{"b"},
+						Usage:    "number of random bytes, at least 64",
+						Value:    64,
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					n := command.Int("bytes")
+					if n < 64 {
+						return cli.Exit("the secret must have at least 64 random bytes", 1)
+					}
+
+					secret := make([]byte, n)
+					if _, err := rand.Read(secret); err != nil {
+						return cli.Exit(err, 1)
+					}
+
+					fmt.Println("New Secret:", base64.RawURLEncoding.EncodeToString(secret))
+					fmt.Println("Set it as HYDROS.HMAC.GLOBAL_SECRET and put the current global secret first in " +
+						"HYDROS.HMAC.ROTATED_SECRETS, then restart every instance.")
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "report how many live tokens each secret still signs",
+				Action: func(ctx context.Context, command *cli.Command) error {
+					counts, err := tokenStorage.CountLiveTokensBySecret(ctx)
+					if err != nil {
+						return exitErr(err)
+					}
+
+					current := hmac.SecretID(cfg.GetGlobalSecret())
+					fmt.Printf("Global secret %s: %d live tokens\n", current, counts[current])
+					delete(counts, current)
+
+					for i, secret := range cfg.GetRotatedSecrets() {
+						id := hmac.SecretID(secret)
+						if counts[id] == 0 {
+							fmt.Printf("Rotated secret #%d %s: no live tokens, it can be removed\n", i+1, id)
+						} else {
+							fmt.Printf("Rotated secret #%d %s: %d live tokens\n", i+1, id, counts[id])
+						}
+						delete(counts, id)
+					}
+
+					// Tokens issued before secret IDs were recorded, or signed by a secret that is no longer configured.
+					for id, count := range counts {
+						if id == "" {
+							id = "unknown"
+						}
+						fmt.Printf("Unlisted secret %s: %d live tokens\n", id, count)
+					}
+
+					return nil
+				},
+			},
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tuanta7/hydros/internal/session"
+	"github.com/urfave/cli/v3"
+)
+
+func NewSessionsCommand(sessionUC session.UseCase) *cli.Command {
+	cmd := &cli.Command{
+		Name:  "sessions",
+		Usage: "list and revoke the login sessions of subjects",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list the login sessions of a subject",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "subject",
+						Aliases:  []string{"s"},
+						Usage:    "subject of the login sessions",
+						Required: true,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					sessions, err := sessionUC.ListSubjectLoginSessions(ctx, command.String("subject"))
+					if err != nil {
+						return exitErr(err)
+					}
+
+					jsonSessions, _ := json.MarshalIndent(sessions, "", "\t")
+					fmt.Printf("Login sessions of %s: %s\n", command.String("subject"), jsonSessions)
+					return nil
+				},
+			},
+			{
+				Name:  "revoke",
+				Usage: "end one login session, or every login session of a subject",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "id",
+						Usage:    "login session to end",
+						Required: false,
+					},
+					&cli.StringFlag{
+						Name:     "subject",
+						Aliases:  []string{"s"},
+						Usage:    "subject whose login sessions are all ended",
+						Required: false,
+					},
+					&cli.BoolFlag{
+						Name:     "revoke-tokens",
+						Usage:    "also revoke the tokens issued to the clients the sessions were used with",
+						Required: false,
+					},
+				},
+				Action: func(ctx context.Context, command *cli.Command) error {
+					id, subject := command.String("id"), command.String("subject")
+					revokeTokens := command.Bool("revoke-tokens")
+
+					switch {
+					case id != "" && subject != "":
+						return cli.Exit("--id and --subject can not be used together", 1)
+					case id != "":
+						if err := sessionUC.RevokeLoginSession(ctx, id, revokeTokens); err != nil {
+							return exitErr(err)
+						}
+						fmt.Printf("Login session %s is revoked\n", id)
+					case subject != "":
+						if err := sessionUC.RevokeSubjectLoginSession(ctx, subject, revokeTokens); err != nil {
+							return exitErr(err)
+						}
+						fmt.Printf("Login sessions of %s are revoked\n", subject)
+					default:
+						return cli.Exit("either --id or --subject is required", 1)
+					}
+
+					return nil
+				},
+			},
+		},
+	}
+
+	return cmd
+}
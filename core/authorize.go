@@ -16,6 +16,12 @@ const (
 	ResponseModeFormPost ResponseMode = "form_post"
 	ResponseModeQuery    ResponseMode = "query"
 	ResponseModeFragment ResponseMode = "fragment"
+	// The JWT response modes are described in JWT Secured Authorization Response Mode for OAuth 2.0 (JARM), jwt uses
+	// the default response mode of the response type.
+	ResponseModeJWT         ResponseMode = "jwt"
+	ResponseModeQueryJWT    ResponseMode = "query.jwt"
+	ResponseModeFragmentJWT ResponseMode = "fragment.jwt"
+	ResponseModeFormPostJWT ResponseMode = "form_post.jwt"
 )
 
 type AuthorizeRequest struct {
@@ -26,6 +32,8 @@ type AuthorizeRequest struct {
 	CodeChallengeMethod string    `json:"code_challenge_method" form:"code_challenge_method"`
 	RedirectURI         *url.URL  `json:"redirect_uri" form:"redirect_uri"`
 	State               string    `json:"state" form:"state"`
+	// PushedRequestURI is the request_uri handle the parameters were loaded from, if any.
+	PushedRequestURI string `json:"-" form:"-"`
 }
 
 type OIDCAuthorizeRequest struct {
@@ -76,36 +84,70 @@ func (o *OAuth2) NewAuthorizeRequest(ctx context.Context, req *http.Request) (*A
 	}
 	ar.Form = form
 
+	if err = o.authorizeRequestFromPAR(ctx, ar); err != nil {
+		return ar, err
+	}
+
+	if err = o.validateAuthorizeRequest(ctx, ar); err != nil {
+		return ar, err
+	}
+
+	if ar.PushedRequestURI == "" && o.isPushedAuthorizationRequired(ar.Client) {
+		return ar, ErrInvalidRequest.WithHint("Pushed Authorization Requests are required, use the 'request_uri' returned by the pushed authorization request endpoint.")
+	}
+
+	return ar, nil
+}
+
+// validateAuthorizeRequest parses and validates the parameters in ar.Form, it is shared by the authorization endpoint
+// and the pushed authorization request endpoint. The client is loaded from the client_id parameter unless it was
+// already set.
+func (o *OAuth2) validateAuthorizeRequest(ctx context.Context, ar *AuthorizeRequest) (err error) {
+	form := ar.Form
+
 	if len(form.Get("registration")) > 0 {
-		return ar, ErrRegistrationNotSupported
+		return ErrRegistrationNotSupported
+	}
+
+	if ar.Client == nil {
+		if ar.Client, err = o.store.GetClient(ctx, form.Get("client_id")); err != nil {
+			return ErrInvalidClient.
+				WithHint("The requested OAuth 2.0 Client does not exist.").
+				WithWrap(err).
+				WithDebug("%s", err)
+		}
+	} else if form.Get("client_id") != ar.Client.GetID() {
+		return ErrInvalidRequest.WithHint("The 'client_id' parameter does not match the authenticated client.")
+	}
+
+	// request objects override the query parameters, so every parameter below is read after they are merged
+	if err = o.authorizeRequestFromRequestObject(ctx, ar); err != nil {
+		return err
 	}
 
-	responseMode := ar.Form.Get("response_mode")
+	responseMode := form.Get("response_mode")
 	if ar.ResponseMode, err = parseResponseMode(responseMode); err != nil {
-		return ar, ErrUnsupportedResponseMode.
+		return ErrUnsupportedResponseMode.
 			WithHint("Request with unsupported response_mode \"%s\".", responseMode).
 			WithWrap(err)
 	}
 
+	if ar.ResponseMode.IsJWT() && len(o.authorizeResponseJWTHandlers) == 0 {
+		return ErrUnsupportedResponseMode.WithHint("JWT secured authorization responses are not enabled.")
+	}
+
 	if ar.ResponseMode == ResponseModeDefault {
 		// Since the /authorize endpoint is now only used for the authorization code grant type, we can safely assume
 		// that the response type is always "query". For other grant types, the default response mode is "fragment".
 		ar.DefaultResponseMode = ResponseModeQuery
 	}
 
-	if ar.Client, err = o.store.GetClient(ctx, form.Get("client_id")); err != nil {
-		return ar, ErrInvalidClient.
-			WithHint("The requested OAuth 2.0 Client does not exist.").
-			WithWrap(err).
-			WithDebug(err.Error())
-	}
-
 	if ar.RedirectURI, err = parseRedirectURI(ar, ar.Client.GetRedirectURIs()); err != nil {
-		return ar, err
+		return err
 	}
 
 	if ar.State = form.Get("state"); len(ar.State) < o.config.GetMinParameterEntropy() {
-		return ar, ErrInvalidState.
+		return ErrInvalidState.
 			WithHint("Request parameter 'state' must be at least be %d characters long.", o.config.GetMinParameterEntropy())
 	}
 
@@ -130,11 +172,11 @@ func (o *OAuth2) NewAuthorizeRequest(ctx context.Context, req *http.Request) (*A
 		// the login step, the rest of the checks are done after logging in.
 		he := th.HandleAuthorizeRequest(ctx, ar)
 		if he != nil {
-			return ar, he
+			return he
 		}
 	}
 
-	return ar, nil
+	return nil
 }
 
 func parseRedirectURI(ar *AuthorizeRequest, registeredURIs []string) (*url.URL, error) {
@@ -167,6 +209,8 @@ func parseResponseMode(rm string) (ResponseMode, error) {
 		return ResponseModeQuery, nil
 	case ResponseModeFormPost:
 		return ResponseModeFormPost, nil
+	case ResponseModeJWT, ResponseModeQueryJWT, ResponseModeFragmentJWT, ResponseModeFormPostJWT:
+		return r, nil
 	default:
 		return "", errors.New("invalid response mode")
 	}
@@ -203,28 +247,11 @@ func (o *OAuth2) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWri
 	rw.Header().Set("Cache-Control", "no-store")
 	rw.Header().Set("Pragma", "no-cache")
 
-	var redirectURIString string
-	switch req.ResponseMode {
-	case ResponseModeFormPost:
-		rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
-		o.FormPostResponse(req.RedirectURI.String(), rw)
-		return
-	case ResponseModeFragment:
-		req.RedirectURI.Fragment = ""
-		params := url.Values{}
-		params.Set("code", resp.Code)
-		params.Set("state", resp.State)
-		redirectURIString = req.RedirectURI.String() + "#" + params.Encode()
-	default:
-		params := url.Values{}
-		params.Set("code", resp.Code)
-		params.Set("state", resp.State)
-		req.RedirectURI.RawQuery = params.Encode()
-		redirectURIString = req.RedirectURI.String()
-	}
+	params := url.Values{}
+	params.Set("code", resp.Code)
+	params.Set("state", resp.State)
 
-	rw.Header().Set("Location", redirectURIString)
-	rw.WriteHeader(http.StatusSeeOther)
+	o.writeAuthorizeParameters(ctx, rw, req, params)
 }
 
 func (o *OAuth2) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, req *AuthorizeRequest, err error) {
@@ -238,18 +265,38 @@ func (o *OAuth2) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter
 	if !req.IsRedirectURIValid() {
 		return
 	}
+
+	o.writeAuthorizeParameters(ctx, rw, req, errorsForm)
+}
+
+// writeAuthorizeParameters sends the parameters back to the redirect URI as the response mode of the request
+// requires. With the JWT response modes, the parameters are replaced by a single response parameter.
+func (o *OAuth2) writeAuthorizeParameters(ctx context.Context, rw http.ResponseWriter, req *AuthorizeRequest, params url.Values) {
+	responseMode := req.ResponseMode
+	if responseMode.IsJWT() {
+		token, err := o.encodeAuthorizeResponse(ctx, req, params)
+		if err != nil {
+			// an unsigned error can not be redirected, the client would have no way to tell it apart from a forged one
+			o.writeError(ctx, rw, ErrServerError.WithWrap(err).WithDebug("%s", err))
+			return
+		}
+
+		params = url.Values{"response": {token}}
+		responseMode = responseMode.withoutJWT(req.DefaultResponseMode)
+	}
+
 	req.RedirectURI.Fragment = ""
 
 	var redirectURIString string
-	switch req.ResponseMode {
+	switch responseMode {
 	case ResponseModeFormPost:
 		rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
-		o.FormPostResponse(req.RedirectURI.String(), rw)
+		o.FormPostResponse(req.RedirectURI.String(), params, rw)
 		return
 	case ResponseModeFragment:
-		redirectURIString = req.RedirectURI.String() + "#" + errorsForm.Encode()
+		redirectURIString = req.RedirectURI.String() + "#" + params.Encode()
 	default: // ResponseModeQuery
-		req.RedirectURI.RawQuery = errorsForm.Encode()
+		req.RedirectURI.RawQuery = params.Encode()
 		redirectURIString = req.RedirectURI.String()
 	}
 
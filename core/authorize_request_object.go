@@ -0,0 +1,315 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// RequestObjectSigningAlgorithms are the algorithms accepted for signed request objects. The HMAC algorithms are
+	// only accepted for clients that can provide their plaintext secret, "none" is never accepted.
+	RequestObjectSigningAlgorithms = append(slices.Clone(privateKeyJWTAlgorithms), secretJWTAlgorithms...)
+
+	// RequestObjectEncryptionAlgorithms are the key management algorithms accepted for encrypted request objects. Only
+	// symmetric encryption with a key derived from the client secret is supported, see OpenID Connect Core 1.0
+	// section 10.2.
+	RequestObjectEncryptionAlgorithms = []string{
+		string(jose.DIRECT),
+		string(jose.A128KW), string(jose.A192KW), string(jose.A256KW),
+		string(jose.A128GCMKW), string(jose.A192GCMKW), string(jose.A256GCMKW),
+	}
+
+	// RequestObjectEncryptionEncodings are the content encryption algorithms accepted for encrypted request objects.
+	RequestObjectEncryptionEncodings = []string{
+		string(jose.A128CBC_HS256), string(jose.A192CBC_HS384), string(jose.A256CBC_HS512),
+		string(jose.A128GCM), string(jose.A192GCM), string(jose.A256GCM),
+	}
+)
+
+const maxRequestObjectSize = 64 << 10
+
+var requestObjectHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// requestObjectIgnoredClaims are JWT claims of the request object that are not authorization request parameters.
+var requestObjectIgnoredClaims = []string{"iss", "aud", "exp", "iat", "nbf", "jti", "request", "request_uri"}
+
+// authorizeRequestFromRequestObject loads the authorization request parameters from the 'request' or 'request_uri'
+// parameter as described in RFC 9101 and OpenID Connect Core 1.0 section 6. Parameters of the request object take
+// precedence over the ones in the query, 'client_id' and 'response_type' must be consistent with the query when
+// present in both. The client must already be loaded into ar.Client.
+func (o *OAuth2) authorizeRequestFromRequestObject(ctx context.Context, ar *AuthorizeRequest) error {
+	request := ar.Form.Get("request")
+	requestURI := ar.Form.Get("request_uri")
+	if request == "" && requestURI == "" {
+		return nil
+	}
+
+	if request != "" && requestURI != "" {
+		return ErrInvalidRequest.WithHint("Parameters 'request' and 'request_uri' were both given, but you can use at most one.")
+	}
+
+	oidcClient, ok := ar.Client.(OpenIDConnectClient)
+	if !ok {
+		if requestURI != "" {
+			return ErrRequestURINotSupported.WithHint("The 'request_uri' parameter was given, but the OAuth 2.0 Client does not implement advanced OpenID Connect capabilities.")
+		}
+		return ErrRequestNotSupported.WithHint("The 'request' parameter was given, but the OAuth 2.0 Client does not implement advanced OpenID Connect capabilities.")
+	}
+
+	if requestURI != "" {
+		if !slices.Contains(oidcClient.GetRequestURIs(), requestURI) {
+			return ErrInvalidRequestURI.WithHint("The 'request_uri' parameter does not match any of the OAuth 2.0 Client's pre-registered 'request_uris'.")
+		}
+
+		var err error
+		if request, err = fetchRequestObject(ctx, requestURI); err != nil {
+			return ErrInvalidRequestURI.WithHint("Unable to fetch the request object from 'request_uri'.").WithWrap(err).WithDebug("%s", err)
+		}
+	}
+
+	if strings.Count(request, ".") == 4 {
+		var err error
+		if request, err = decryptRequestObject(ar.Client, request); err != nil {
+			return err
+		}
+	}
+
+	claims, err := o.verifyRequestObject(ctx, ar.Client, oidcClient, request)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := claims["client_id"].(string); ok && id != ar.Client.GetID() {
+		return ErrInvalidRequestObject.WithHint("Claim 'client_id' from the request object must match the 'client_id' parameter.")
+	}
+
+	if rt, ok := claims["response_type"].(string); ok && ar.Form.Get("response_type") != "" && rt != ar.Form.Get("response_type") {
+		return ErrInvalidRequestObject.WithHint("Claim 'response_type' from the request object must match the 'response_type' parameter.")
+	}
+
+	for k, v := range claims {
+		if slices.Contains(requestObjectIgnoredClaims, k) {
+			continue
+		}
+
+		value, err := requestObjectClaimValue(v)
+		if err != nil {
+			return ErrInvalidRequestObject.WithHint("Unable to read claim '%s' from the request object.", k).WithWrap(err).WithDebug("%s", err)
+		}
+		ar.Form.Set(k, value)
+	}
+
+	ar.Form.Del("request")
+	ar.Form.Del("request_uri")
+	return nil
+}
+
+// verifyRequestObject verifies the signature of the request object with the client's keys and returns its claims.
+func (o *OAuth2) verifyRequestObject(ctx context.Context, client Client, oidcClient OpenIDConnectClient, request string) (gojwt.MapClaims, error) {
+	claims := gojwt.MapClaims{}
+	_, err := gojwt.NewParser(
+		gojwt.WithValidMethods(RequestObjectSigningAlgorithms),
+	).ParseWithClaims(request, claims, func(t *gojwt.Token) (any, error) {
+		alg := t.Method.Alg()
+		if slices.Contains(secretJWTAlgorithms, alg) {
+			secretClient, ok := client.(ClientSecretJWTClient)
+			if !ok || len(secretClient.GetClientSecret()) == 0 {
+				return nil, ErrInvalidRequestObject.WithHint("The client has no secret that can verify the request object.")
+			}
+			return secretClient.GetClientSecret(), nil
+		}
+
+		if oidcClient.GetJWKs() == nil && oidcClient.GetJWKsURI() == "" {
+			return nil, ErrInvalidRequestObject.WithHint("The request object is signed, but the OAuth 2.0 Client does not have any JSON Web Keys registered.")
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return o.findClientAssertionKey(ctx, oidcClient, kid, alg)
+	})
+	if err != nil {
+		return nil, ErrInvalidRequestObject.WithHint("Unable to verify the integrity of the request object.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if iss, ok := claims["iss"]; ok && iss != client.GetID() {
+		return nil, ErrInvalidRequestObject.WithHint("Claim 'iss' from the request object must match the 'client_id' of the OAuth 2.0 Client.")
+	}
+
+	if _, ok := claims["aud"]; ok {
+		aud, err := claims.GetAudience()
+		if err != nil || !slices.Contains(aud, o.config.GetIDTokenIssuer()) {
+			return nil, ErrInvalidRequestObject.WithHint("Claim 'aud' from the request object must contain the issuer '%s'.", o.config.GetIDTokenIssuer())
+		}
+	}
+
+	return claims, nil
+}
+
+// decryptRequestObject decrypts a JWE request object with a symmetric key derived from the client secret as described
+// in OpenID Connect Core 1.0 section 10.2.
+func decryptRequestObject(client Client, request string) (string, error) {
+	secretClient, ok := client.(ClientSecretJWTClient)
+	if !ok || len(secretClient.GetClientSecret()) == 0 {
+		return "", ErrInvalidRequestObject.WithHint("The request object is encrypted, but the OAuth 2.0 Client has no secret to derive the decryption key from.")
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(strings.SplitN(request, ".", 2)[0])
+	if err != nil {
+		return "", ErrInvalidRequestObject.WithHint("Unable to decode the header of the encrypted request object.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	var h struct {
+		Alg string `json:"alg"`
+		Enc string `json:"enc"`
+	}
+	if err = json.Unmarshal(header, &h); err != nil {
+		return "", ErrInvalidRequestObject.WithHint("Unable to decode the header of the encrypted request object.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if !slices.Contains(RequestObjectEncryptionAlgorithms, h.Alg) || !slices.Contains(RequestObjectEncryptionEncodings, h.Enc) {
+		return "", ErrInvalidRequestObject.WithHint("The request object is encrypted with unsupported algorithms '%s' and '%s'.", h.Alg, h.Enc)
+	}
+
+	size := symmetricKeySize(h.Alg, h.Enc)
+	if size == 0 {
+		return "", ErrInvalidRequestObject.WithHint("The request object is encrypted with unsupported algorithms '%s' and '%s'.", h.Alg, h.Enc)
+	}
+
+	keyAlgorithms := make([]jose.KeyAlgorithm, 0, len(RequestObjectEncryptionAlgorithms))
+	for _, alg := range RequestObjectEncryptionAlgorithms {
+		keyAlgorithms = append(keyAlgorithms, jose.KeyAlgorithm(alg))
+	}
+
+	contentEncryption := make([]jose.ContentEncryption, 0, len(RequestObjectEncryptionEncodings))
+	for _, enc := range RequestObjectEncryptionEncodings {
+		contentEncryption = append(contentEncryption, jose.ContentEncryption(enc))
+	}
+
+	jwe, err := jose.ParseEncryptedCompact(request, keyAlgorithms, contentEncryption)
+	if err != nil {
+		return "", ErrInvalidRequestObject.WithHint("Unable to parse the encrypted request object.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	plaintext, err := jwe.Decrypt(deriveSymmetricKey(secretClient.GetClientSecret(), size))
+	if err != nil {
+		return "", ErrInvalidRequestObject.WithHint("Unable to decrypt the request object.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// symmetricKeySize returns the key size in bytes required by the key management algorithm alg, or by the content
+// encryption enc when the content is encrypted directly.
+func symmetricKeySize(alg, enc string) int {
+	switch jose.KeyAlgorithm(alg) {
+	case jose.A128KW, jose.A128GCMKW:
+		return 16
+	case jose.A192KW, jose.A192GCMKW:
+		return 24
+	case jose.A256KW, jose.A256GCMKW:
+		return 32
+	case jose.DIRECT:
+		switch jose.ContentEncryption(enc) {
+		case jose.A128GCM:
+			return 16
+		case jose.A192GCM:
+			return 24
+		case jose.A256GCM, jose.A128CBC_HS256:
+			return 32
+		case jose.A192CBC_HS384:
+			return 48
+		case jose.A256CBC_HS512:
+			return 64
+		}
+	}
+
+	return 0
+}
+
+// deriveSymmetricKey takes the left-most size bytes of the SHA-2 hash of the secret, using the smallest SHA-2
+// function whose output is long enough.
+func deriveSymmetricKey(secret []byte, size int) []byte {
+	var sum []byte
+	switch {
+	case size <= sha256.Size:
+		h := sha256.Sum256(secret)
+		sum = h[:]
+	case size <= sha512.Size384:
+		h := sha512.Sum384(secret)
+		sum = h[:]
+	default:
+		h := sha512.Sum512(secret)
+		sum = h[:]
+	}
+
+	return sum[:size]
+}
+
+func fetchRequestObject(ctx context.Context, location string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := requestObjectHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("expected status code 200 but got %d when fetching %s", resp.StatusCode, location)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRequestObjectSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) > maxRequestObjectSize {
+		return "", fmt.Errorf("request object at %s exceeds %d bytes", location, maxRequestObjectSize)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// requestObjectClaimValue converts a request object claim to its authorization request parameter representation.
+// Arrays are joined with spaces like scope and audience, objects such as 'claims' are kept as JSON.
+func requestObjectClaimValue(v any) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("expected an array of strings but got %T", item)
+			}
+			values = append(values, s)
+		}
+		return strings.Join(values, " "), nil
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requestObjectTestSetup struct {
+	o      *OAuth2
+	key    *ecdsa.PrivateKey
+	secret []byte
+	client *testClient
+}
+
+func newRequestObjectTestSetup(t *testing.T) *requestObjectTestSetup {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &requestObjectTestSetup{
+		o:      &OAuth2{config: newTestConfig(), store: newTestStore()},
+		key:    key,
+		secret: []byte(strings.Repeat("c", 32)),
+		client: &testClient{
+			id:     "client",
+			secret: []byte(strings.Repeat("c", 32)),
+			jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: &key.PublicKey, KeyID: "key-1", Algorithm: "ES256", Use: "sig"},
+			}},
+		},
+	}
+}
+
+func newRequestObjectClaims() gojwt.MapClaims {
+	return gojwt.MapClaims{
+		"iss":           "client",
+		"aud":           "https://auth.example.com",
+		"exp":           time.Now().Add(time.Minute).Unix(),
+		"client_id":     "client",
+		"response_type": "code",
+		"scope":         []string{"openid", "profile"},
+		"state":         "request-object-state",
+	}
+}
+
+func (s *requestObjectTestSetup) sign(t *testing.T, method gojwt.SigningMethod, claims gojwt.MapClaims) string {
+	token := gojwt.NewWithClaims(method, claims)
+	token.Header["kid"] = "key-1"
+
+	var key any = s.key
+	switch {
+	case method == gojwt.SigningMethodNone:
+		key = gojwt.UnsafeAllowNoneSignatureType
+	case strings.HasPrefix(method.Alg(), "HS"):
+		key = s.secret
+	}
+
+	request, err := token.SignedString(key)
+	require.NoError(t, err)
+	return request
+}
+
+// encrypt wraps the request object in a JWE with the key derived from secret, see OpenID Connect Core 1.0 section 10.2.
+func encryptRequestObject(t *testing.T, request string, secret []byte) string {
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{
+		Algorithm: jose.DIRECT,
+		Key:       deriveSymmetricKey(secret, 32),
+	}, (&jose.EncrypterOptions{}).WithContentType("JWT"))
+	require.NoError(t, err)
+
+	jwe, err := encrypter.Encrypt([]byte(request))
+	require.NoError(t, err)
+
+	compact, err := jwe.CompactSerialize()
+	require.NoError(t, err)
+	return compact
+}
+
+func (s *requestObjectTestSetup) authorizeRequest(form url.Values) *AuthorizeRequest {
+	ar := NewAuthorizeRequest()
+	ar.Client = s.client
+	ar.Form = form
+	return ar
+}
+
+func TestAuthorizeRequestFromRequestObject(t *testing.T) {
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		request func(t *testing.T, s *requestObjectTestSetup) string
+		query   url.Values
+		wantErr error
+	}{
+		{
+			name: "signed with the client key",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				return s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+			},
+		},
+		{
+			name: "signed with the client secret",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				return s.sign(t, gojwt.SigningMethodHS256, newRequestObjectClaims())
+			},
+		},
+		{
+			name: "encrypted with the client secret",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				return encryptRequestObject(t, s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims()), s.secret)
+			},
+		},
+		{
+			name: "without aud and iss",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				claims := newRequestObjectClaims()
+				delete(claims, "aud")
+				delete(claims, "iss")
+				return s.sign(t, gojwt.SigningMethodES256, claims)
+			},
+		},
+		{
+			name: "unsigned",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				return s.sign(t, gojwt.SigningMethodNone, newRequestObjectClaims())
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "signed with another key",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				s.key = otherKey
+				return s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "signed with a secret the client does not have",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				s.client.secret = nil
+				return s.sign(t, gojwt.SigningMethodHS256, newRequestObjectClaims())
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "signed with a key the client did not register",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				s.client.jwks = nil
+				return s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "encrypted with another secret",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				request := s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+				return encryptRequestObject(t, request, []byte(strings.Repeat("o", 32)))
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "expired",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				claims := newRequestObjectClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return s.sign(t, gojwt.SigningMethodES256, claims)
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "issued by another client",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				claims := newRequestObjectClaims()
+				claims["iss"] = "other"
+				return s.sign(t, gojwt.SigningMethodES256, claims)
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "intended for another server",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				claims := newRequestObjectClaims()
+				claims["aud"] = "https://other.example.com"
+				return s.sign(t, gojwt.SigningMethodES256, claims)
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "client_id of another client",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				claims := newRequestObjectClaims()
+				claims["client_id"] = "other"
+				return s.sign(t, gojwt.SigningMethodES256, claims)
+			},
+			wantErr: ErrInvalidRequestObject,
+		},
+		{
+			name: "response_type differs from the query",
+			request: func(t *testing.T, s *requestObjectTestSetup) string {
+				return s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+			},
+			query:   url.Values{"response_type": {"token"}},
+			wantErr: ErrInvalidRequestObject,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newRequestObjectTestSetup(t)
+			form := url.Values{"client_id": {"client"}, "scope": {"email"}}
+			for k, v := range tc.query {
+				form[k] = v
+			}
+			form.Set("request", tc.request(t, s))
+
+			ar := s.authorizeRequest(form)
+			err := s.o.authorizeRequestFromRequestObject(context.Background(), ar)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			// the parameters of the request object take precedence over the query
+			assert.Equal(t, "openid profile", ar.Form.Get("scope"))
+			assert.Equal(t, "request-object-state", ar.Form.Get("state"))
+			assert.Empty(t, ar.Form.Get("request"))
+			assert.Empty(t, ar.Form.Get("iss"))
+			assert.Empty(t, ar.Form.Get("exp"))
+		})
+	}
+}
+
+func TestAuthorizeRequestFromRequestObject_RequestURI(t *testing.T) {
+	s := newRequestObjectTestSetup(t)
+	request := s.sign(t, gojwt.SigningMethodES256, newRequestObjectClaims())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/request.jwt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(request))
+	}))
+	defer server.Close()
+
+	s.client.requestURIs = []string{server.URL + "/request.jwt", server.URL + "/missing.jwt"}
+
+	cases := []struct {
+		name    string
+		form    url.Values
+		wantErr error
+	}{
+		{
+			name: "registered request_uri",
+			form: url.Values{"request_uri": {server.URL + "/request.jwt"}},
+		},
+		{
+			name:    "unregistered request_uri",
+			form:    url.Values{"request_uri": {server.URL + "/other.jwt"}},
+			wantErr: ErrInvalidRequestURI,
+		},
+		{
+			name:    "request_uri that can not be fetched",
+			form:    url.Values{"request_uri": {server.URL + "/missing.jwt"}},
+			wantErr: ErrInvalidRequestURI,
+		},
+		{
+			name:    "both request and request_uri",
+			form:    url.Values{"request": {request}, "request_uri": {server.URL + "/request.jwt"}},
+			wantErr: ErrInvalidRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ar := s.authorizeRequest(tc.form)
+			err := s.o.authorizeRequestFromRequestObject(context.Background(), ar)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "request-object-state", ar.Form.Get("state"))
+			assert.Empty(t, ar.Form.Get("request_uri"))
+		})
+	}
+}
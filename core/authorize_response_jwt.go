@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// AuthorizeResponseJWTHandler encodes the parameters of an authorization response, or of an authorization error, as
+// a signed and optionally encrypted JWT for the JWT Secured Authorization Response Mode (JARM).
+type AuthorizeResponseJWTHandler interface {
+	EncodeAuthorizeResponse(ctx context.Context, req *AuthorizeRequest, params url.Values) (string, error)
+}
+
+// AuthorizeResponseJWTClient is implemented by clients that can register how their JWT secured authorization
+// responses are signed and encrypted, see JARM section 3.
+type AuthorizeResponseJWTClient interface {
+	GetAuthorizationSignedResponseAlg() string
+	GetAuthorizationEncryptedResponseAlg() string
+	GetAuthorizationEncryptedResponseEnc() string
+}
+
+// IsJWT reports whether the response parameters are sent inside a JWT.
+func (r ResponseMode) IsJWT() bool {
+	return r == ResponseModeJWT || strings.HasSuffix(string(r), ".jwt")
+}
+
+// withoutJWT returns the response mode used to transmit the JWT, jwt resolves to the default response mode.
+func (r ResponseMode) withoutJWT(defaultMode ResponseMode) ResponseMode {
+	switch r {
+	case ResponseModeQueryJWT:
+		return ResponseModeQuery
+	case ResponseModeFragmentJWT:
+		return ResponseModeFragment
+	case ResponseModeFormPostJWT:
+		return ResponseModeFormPost
+	}
+
+	if defaultMode == ResponseModeFragment {
+		return ResponseModeFragment
+	}
+	return ResponseModeQuery
+}
+
+func (o *OAuth2) encodeAuthorizeResponse(ctx context.Context, req *AuthorizeRequest, params url.Values) (string, error) {
+	if len(o.authorizeResponseJWTHandlers) == 0 {
+		return "", errors.New("no handler can encode JWT secured authorization responses")
+	}
+
+	return o.authorizeResponseJWTHandlers[0].EncodeAuthorizeResponse(ctx, req, params)
+}
@@ -19,4 +19,25 @@ type OpenIDConnectClient interface {
 	GetJWKs() *jose.JSONWebKeySet
 	GetJWKsURI() string
 	GetTokenEndpointAuthMethod() string
+	GetTokenEndpointAuthSigningAlg() string
+	GetUserinfoSignedResponseAlg() string
+}
+
+// ClientSecretJWTClient is implemented by clients that can authenticate with the client_secret_jwt method, which
+// requires the plaintext secret to verify the HMAC signature of the client assertion.
+type ClientSecretJWTClient interface {
+	GetClientSecret() []byte
+}
+
+// IDTokenEncryptionClient is implemented by clients that can register how their ID tokens are encrypted, see OpenID
+// Connect Dynamic Client Registration 1.0 section 2.
+type IDTokenEncryptionClient interface {
+	GetIDTokenEncryptedResponseAlg() string
+	GetIDTokenEncryptedResponseEnc() string
+}
+
+// UserinfoEncryptionClient is implemented by clients that can register how their UserInfo responses are encrypted.
+type UserinfoEncryptionClient interface {
+	GetUserinfoEncryptedResponseAlg() string
+	GetUserinfoEncryptedResponseEnc() string
 }
@@ -2,16 +2,28 @@ package core
 
 import (
 	"context"
+	"crypto"
 	"net/http"
 	"net/url"
+	"slices"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core/x"
 )
 
 const (
-	ClientAuthenticationMethodNone  = "none"
-	ClientAuthenticationMethodBasic = "client_secret_basic"
-	ClientAuthenticationMethodPost  = "client_secret_post"
-	ClientAuthenticationMethodJWT   = "private_key_jwt" // client assertion
-	ClientAssertionJWTType          = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	ClientAuthenticationMethodNone      = "none"
+	ClientAuthenticationMethodBasic     = "client_secret_basic"
+	ClientAuthenticationMethodPost      = "client_secret_post"
+	ClientAuthenticationMethodJWT       = "private_key_jwt" // client assertion
+	ClientAuthenticationMethodSecretJWT = "client_secret_jwt"
+	ClientAssertionJWTType              = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	privateKeyJWTAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	secretJWTAlgorithms     = []string{"HS256", "HS384", "HS512"}
 )
 
 // AuthenticateClient check if the client needs authentication and returns the client if it does.
@@ -22,19 +34,19 @@ func (o *OAuth2) AuthenticateClient(ctx context.Context, r *http.Request, form u
 			return nil, ErrInvalidRequest.WithHint("Missing client assertion")
 		}
 
-		// TODO: implement rfc7521 client assertion
+		return o.authenticateClientAssertion(ctx, form, assertion)
 	} else if len(at) > 0 {
-		return nil, ErrInvalidRequest.WithHint("Unsupported client assertion type: " + at)
+		return nil, ErrInvalidRequest.WithHint("Unsupported client assertion type: %s", at)
 	}
 
-	clientID, clientSecret, err := clientCredentialsFromRequest(r, form)
+	clientID, clientSecret, method, err := clientCredentialsFromRequest(r, form)
 	if err != nil {
 		return nil, err
 	}
 
 	client, err := o.store.GetClient(ctx, clientID)
 	if err != nil {
-		return nil, ErrInvalidClient.WithWrap(err).WithDebug(err.Error())
+		return nil, ErrInvalidClient.WithWrap(err).WithDebug("%s", err)
 	}
 
 	// Skip authentication for public clients
@@ -42,35 +54,238 @@ func (o *OAuth2) AuthenticateClient(ctx context.Context, r *http.Request, form u
 		return client, nil
 	}
 
-	err = o.config.GetSecretsHasher().Compare(ctx, client.GetHashedSecret(), []byte(clientSecret))
+	if oidcClient, ok := client.(OpenIDConnectClient); ok {
+		registered := oidcClient.GetTokenEndpointAuthMethod()
+		switch registered {
+		case ClientAuthenticationMethodTLS, ClientAuthenticationMethodSelfSignedTLS:
+			return o.authenticateTLSClient(ctx, r, client, registered)
+		case ClientAuthenticationMethodJWT, ClientAuthenticationMethodSecretJWT:
+			return nil, ErrInvalidClient.WithHint("The client is registered with token_endpoint_auth_method '%s' and must authenticate with a client assertion.", registered)
+		case ClientAuthenticationMethodBasic, ClientAuthenticationMethodPost:
+			if registered != method {
+				return nil, ErrInvalidClient.WithHint("The client is registered with token_endpoint_auth_method '%s' but authenticated with '%s'.", registered, method)
+			}
+		}
+	}
+
+	hasher := o.config.GetSecretsHasher()
+	err = hasher.Compare(ctx, client.GetHashedSecret(), []byte(clientSecret))
 	if err != nil {
 		return nil, err
 	}
 
+	o.rehashClientSecret(ctx, hasher, client, clientSecret)
 	return client, nil
 }
 
-func clientCredentialsFromRequest(r *http.Request, form url.Values) (clientID, clientSecret string, err error) {
+// rehashClientSecret replaces a hash created with an outdated algorithm or parameters, it only runs once the secret
+// is known to be correct. A failed rehash is retried on the next authentication, as the old hash still works.
+func (o *OAuth2) rehashClientSecret(ctx context.Context, hasher Hasher, client Client, secret string) {
+	rehasher, ok := hasher.(RehashableHasher)
+	if !ok || !rehasher.NeedsRehash(client.GetHashedSecret()) {
+		return
+	}
+
+	store, ok := o.store.(ClientSecretStorage)
+	if !ok {
+		return
+	}
+
+	hash, err := rehasher.Hash(ctx, []byte(secret))
+	if err != nil {
+		return
+	}
+
+	_ = store.UpdateClientSecretHash(ctx, client.GetID(), hash)
+}
+
+// authenticateClientAssertion authenticates the client using a JWT as described in RFC 7523 section 2.2 and 3, and
+// OpenID Connect Core 1.0 section 9.
+func (o *OAuth2) authenticateClientAssertion(ctx context.Context, form url.Values, assertion string) (Client, error) {
+	unverified := &gojwt.RegisteredClaims{}
+	token, _, err := gojwt.NewParser().ParseUnverified(assertion, unverified)
+	if err != nil {
+		return nil, ErrInvalidClient.WithHint("Unable to decode the client assertion.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	clientID := unverified.Subject
+	if clientID == "" {
+		return nil, ErrInvalidClient.WithHint("Claim 'sub' from 'client_assertion' must be set to the client_id.")
+	}
+
+	if unverified.Issuer != clientID {
+		return nil, ErrInvalidClient.WithHint("Claim 'iss' from 'client_assertion' must match claim 'sub'.")
+	}
+
+	if id := form.Get("client_id"); id != "" && id != clientID {
+		return nil, ErrInvalidClient.WithHint("Claim 'sub' from 'client_assertion' must match the 'client_id' parameter.")
+	}
+
+	client, err := o.store.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, ErrInvalidClient.WithWrap(err).WithDebug("%s", err)
+	}
+
+	oidcClient, ok := client.(OpenIDConnectClient)
+	if !ok {
+		return nil, ErrInvalidClient.WithHint("The client does not support client assertions.")
+	}
+
+	method := oidcClient.GetTokenEndpointAuthMethod()
+	var algorithms []string
+	switch method {
+	case ClientAuthenticationMethodJWT:
+		algorithms = privateKeyJWTAlgorithms
+	case ClientAuthenticationMethodSecretJWT:
+		algorithms = secretJWTAlgorithms
+	default:
+		return nil, ErrInvalidClient.WithHint("The client is registered with token_endpoint_auth_method '%s' and can not authenticate with a client assertion.", method)
+	}
+
+	alg := token.Method.Alg()
+	if registered := oidcClient.GetTokenEndpointAuthSigningAlg(); registered != "" && registered != "none" {
+		algorithms = []string{registered}
+	}
+
+	if !slices.Contains(algorithms, alg) {
+		return nil, ErrInvalidClient.WithHint("The client assertion is signed with '%s' which is not allowed for this client.", alg)
+	}
+
+	claims := &gojwt.RegisteredClaims{}
+	_, err = gojwt.NewParser(
+		gojwt.WithValidMethods(algorithms),
+		gojwt.WithExpirationRequired(),
+		gojwt.WithIssuer(clientID),
+		gojwt.WithSubject(clientID),
+	).ParseWithClaims(assertion, claims, func(t *gojwt.Token) (any, error) {
+		if method == ClientAuthenticationMethodSecretJWT {
+			secretClient, ok := client.(ClientSecretJWTClient)
+			if !ok || len(secretClient.GetClientSecret()) == 0 {
+				return nil, ErrInvalidClient.WithHint("The client has no secret that can verify the client assertion.")
+			}
+			return secretClient.GetClientSecret(), nil
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return o.findClientAssertionKey(ctx, oidcClient, kid, alg)
+	})
+	if err != nil {
+		return nil, ErrInvalidClient.WithHint("Unable to verify the integrity of the 'client_assertion' value.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	tokenURL := o.config.GetTokenURL()
+	if !slices.Contains(claims.Audience, tokenURL) && !slices.Contains(claims.Audience, o.config.GetIDTokenIssuer()) {
+		return nil, ErrInvalidClient.WithHint("Claim 'aud' from 'client_assertion' must match the token endpoint '%s'.", tokenURL)
+	}
+
+	if claims.ID == "" {
+		return nil, ErrInvalidClient.WithHint("Claim 'jti' from 'client_assertion' must be set but is not.")
+	}
+
+	// the jti is stored until the assertion expires, a bounded lifetime keeps clients from pinning it forever
+	lifetime := o.config.GetClientAssertionLifetime()
+	if claims.ExpiresAt.After(x.NowUTC().Add(lifetime)) {
+		return nil, ErrInvalidClient.WithHint("Claim 'exp' from 'client_assertion' must not be more than %s in the future.", lifetime)
+	}
+
+	if claims.IssuedAt != nil && claims.ExpiresAt.Sub(claims.IssuedAt.Time) > lifetime {
+		return nil, ErrInvalidClient.WithHint("The 'client_assertion' must not be valid for more than %s.", lifetime)
+	}
+
+	if err = o.store.ClientAssertionJWTValid(ctx, claims.ID); err != nil {
+		return nil, ErrJTIKnown.WithHint("Claim 'jti' from 'client_assertion' has already been used.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = o.store.SetClientAssertionJWT(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, ErrorToRFC6749Error(err)
+	}
+
+	return client, nil
+}
+
+// findClientAssertionKey looks up the verification key in the inline JWKS of the client or, if there is none, in the
+// key set published at the client's jwks_uri. An unknown kid forces a refresh of the cached remote key set.
+func (o *OAuth2) findClientAssertionKey(ctx context.Context, client OpenIDConnectClient, kid, alg string) (any, error) {
+	if keys := client.GetJWKs(); keys != nil && len(keys.Keys) > 0 {
+		return findVerificationKey(keys, kid, alg)
+	}
+
+	location := client.GetJWKsURI()
+	if location == "" {
+		return nil, ErrInvalidClient.WithHint("The client has neither 'jwks' nor 'jwks_uri' registered.")
+	}
+
+	keys, err := o.config.GetJWKSFetcher().Resolve(ctx, location, false)
+	if err != nil {
+		return nil, ErrInvalidClient.WithHint("Unable to fetch the client's 'jwks_uri'.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	key, err := findVerificationKey(keys, kid, alg)
+	if err == nil {
+		return key, nil
+	}
+
+	keys, err = o.config.GetJWKSFetcher().Resolve(ctx, location, true)
+	if err != nil {
+		return nil, ErrInvalidClient.WithHint("Unable to fetch the client's 'jwks_uri'.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	return findVerificationKey(keys, kid, alg)
+}
+
+func findVerificationKey(keys *jose.JSONWebKeySet, kid, alg string) (any, error) {
+	candidates := keys.Keys
+	if kid != "" {
+		candidates = keys.Key(kid)
+	}
+
+	var found []jose.JSONWebKey
+	for _, key := range candidates {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+		found = append(found, key)
+	}
+
+	if len(found) == 0 {
+		return nil, ErrInvalidClient.WithHint("Unable to find a key with kid '%s' for algorithm '%s'.", kid, alg)
+	} else if kid == "" && len(found) > 1 {
+		return nil, ErrInvalidClient.WithHint("The client assertion has no 'kid' header and the client has more than one key.")
+	}
+
+	key := found[0].Key
+	if signer, ok := key.(crypto.Signer); ok {
+		// private keys registered by mistake still verify with their public part
+		key = signer.Public()
+	}
+
+	return key, nil
+}
+
+func clientCredentialsFromRequest(r *http.Request, form url.Values) (clientID, clientSecret, method string, err error) {
 	if id, secret, ok := r.BasicAuth(); ok {
 		clientID, err = url.QueryUnescape(id)
 		if err != nil {
-			return "", "", ErrInvalidRequest.WithHint("The client id in the HTTP authorization header could not be decoded from 'application/x-www-form-urlencoded'.").WithWrap(err).WithDebug(err.Error())
+			return "", "", "", ErrInvalidRequest.WithHint("The client id in the HTTP authorization header could not be decoded from 'application/x-www-form-urlencoded'.").WithWrap(err).WithDebug("%s", err)
 		}
 
 		clientSecret, err = url.QueryUnescape(secret)
 		if err != nil {
-			return "", "", ErrInvalidRequest.WithHint("The client secret in the HTTP authorization header could not be decoded from 'application/x-www-form-urlencoded'.").WithWrap(err).WithDebug(err.Error())
+			return "", "", "", ErrInvalidRequest.WithHint("The client secret in the HTTP authorization header could not be decoded from 'application/x-www-form-urlencoded'.").WithWrap(err).WithDebug("%s", err)
 		}
 
-		return clientID, clientSecret, nil
+		return clientID, clientSecret, ClientAuthenticationMethodBasic, nil
 	}
 
 	// Credentials missing in HTTP Authorization header. Try to read them from the request body.
 	clientID = form.Get("client_id")
 	if clientID == "" {
-		return "", "", ErrInvalidRequest.WithHint("Client credentials missing or malformed in both HTTP Authorization header and HTTP POST body.")
+		return "", "", "", ErrInvalidRequest.WithHint("Client credentials missing or malformed in both HTTP Authorization header and HTTP POST body.")
 	}
 
 	clientSecret = form.Get("client_secret")
-	return clientID, clientSecret, nil
+	return clientID, clientSecret, ClientAuthenticationMethodPost, nil
 }
@@ -0,0 +1,331 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type assertionTestSetup struct {
+	o      *OAuth2
+	key    *ecdsa.PrivateKey
+	secret []byte
+}
+
+func newAssertionTestSetup(t *testing.T) *assertionTestSetup {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	secret := []byte(strings.Repeat("c", 32))
+	hashedSecret, err := NewBCryptHasher(4).Hash(context.Background(), []byte("secret"))
+	require.NoError(t, err)
+
+	keyClient := &testClient{
+		id:         "key-client",
+		authMethod: ClientAuthenticationMethodJWT,
+		jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "key-1", Algorithm: "ES256", Use: "sig"},
+		}},
+	}
+
+	store := newTestStore(
+		keyClient,
+		&testClient{id: "secret-client", authMethod: ClientAuthenticationMethodSecretJWT, secret: secret},
+		&testClient{id: "pinned-client", authMethod: ClientAuthenticationMethodJWT, signingAlg: "ES384", jwks: keyClient.jwks},
+		&testClient{id: "basic-client", authMethod: ClientAuthenticationMethodBasic, hashedSecret: hashedSecret, secret: secret},
+		&testClient{id: "post-client", authMethod: ClientAuthenticationMethodPost, hashedSecret: hashedSecret},
+		&testClient{id: "plain-client", hashedSecret: hashedSecret},
+	)
+
+	return &assertionTestSetup{
+		o:      &OAuth2{config: newTestConfig(), store: store},
+		key:    key,
+		secret: secret,
+	}
+}
+
+func newAssertionClaims(clientID string) *gojwt.RegisteredClaims {
+	return &gojwt.RegisteredClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  gojwt.ClaimStrings{newTestConfig().GetTokenURL()},
+		ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+		ID:        rand.Text(),
+	}
+}
+
+func (s *assertionTestSetup) sign(t *testing.T, method gojwt.SigningMethod, claims *gojwt.RegisteredClaims, kid string) string {
+	token := gojwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	var key any = s.key
+	if strings.HasPrefix(method.Alg(), "HS") {
+		key = s.secret
+	}
+
+	assertion, err := token.SignedString(key)
+	require.NoError(t, err)
+	return assertion
+}
+
+func assertionForm(assertion string) url.Values {
+	return url.Values{
+		"client_assertion_type": {ClientAssertionJWTType},
+		"client_assertion":      {assertion},
+	}
+}
+
+func TestAuthenticateClient_Assertion(t *testing.T) {
+	cases := []struct {
+		name     string
+		clientID string
+		method   gojwt.SigningMethod
+		kid      string
+		modify   func(claims *gojwt.RegisteredClaims)
+		form     url.Values
+		wantErr  error
+	}{
+		{
+			name:     "private_key_jwt",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			kid:      "key-1",
+		},
+		{
+			name:     "private_key_jwt without kid",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+		},
+		{
+			name:     "client_secret_jwt",
+			clientID: "secret-client",
+			method:   gojwt.SigningMethodHS256,
+		},
+		{
+			name:     "issuer as audience",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify:   func(claims *gojwt.RegisteredClaims) { claims.Audience = gojwt.ClaimStrings{"https://auth.example.com"} },
+		},
+		{
+			name:     "client registered with client_secret_basic",
+			clientID: "basic-client",
+			method:   gojwt.SigningMethodHS256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "client registered without an assertion method",
+			clientID: "plain-client",
+			method:   gojwt.SigningMethodES256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "symmetric algorithm for private_key_jwt",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodHS256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "asymmetric algorithm for client_secret_jwt",
+			clientID: "secret-client",
+			method:   gojwt.SigningMethodES256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "algorithm other than the registered one",
+			clientID: "pinned-client",
+			method:   gojwt.SigningMethodES256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "unknown kid",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			kid:      "key-2",
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "unknown client",
+			clientID: "unknown",
+			method:   gojwt.SigningMethodES256,
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "issuer does not match the subject",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify:   func(claims *gojwt.RegisteredClaims) { claims.Issuer = "secret-client" },
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "client_id does not match the subject",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			form:     url.Values{"client_id": {"secret-client"}},
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "wrong audience",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify: func(claims *gojwt.RegisteredClaims) {
+				claims.Audience = gojwt.ClaimStrings{"https://other.example.com/token"}
+			},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name:     "missing exp",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify:   func(claims *gojwt.RegisteredClaims) { claims.ExpiresAt = nil },
+			wantErr:  ErrInvalidClient,
+		},
+		{
+			name:     "expired",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify: func(claims *gojwt.RegisteredClaims) {
+				claims.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(-time.Minute))
+			},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name:     "exp beyond the allowed lifetime",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify: func(claims *gojwt.RegisteredClaims) {
+				claims.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(365 * 24 * time.Hour))
+			},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name:     "valid for longer than the allowed lifetime",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify: func(claims *gojwt.RegisteredClaims) {
+				claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+			},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name:     "iat within the allowed lifetime",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify: func(claims *gojwt.RegisteredClaims) {
+				claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(-time.Minute))
+			},
+		},
+		{
+			name:     "missing jti",
+			clientID: "key-client",
+			method:   gojwt.SigningMethodES256,
+			modify:   func(claims *gojwt.RegisteredClaims) { claims.ID = "" },
+			wantErr:  ErrInvalidClient,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newAssertionTestSetup(t)
+			claims := newAssertionClaims(tc.clientID)
+			if tc.modify != nil {
+				tc.modify(claims)
+			}
+
+			form := assertionForm(s.sign(t, tc.method, claims, tc.kid))
+			for k, v := range tc.form {
+				form[k] = v
+			}
+
+			r, _ := http.NewRequest(http.MethodPost, "/oauth/token", nil)
+			client, err := s.o.AuthenticateClient(context.Background(), r, form)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.clientID, client.GetID())
+		})
+	}
+}
+
+func TestAuthenticateClient_AssertionReplay(t *testing.T) {
+	s := newAssertionTestSetup(t)
+	form := assertionForm(s.sign(t, gojwt.SigningMethodES256, newAssertionClaims("key-client"), "key-1"))
+	r, _ := http.NewRequest(http.MethodPost, "/oauth/token", nil)
+
+	_, err := s.o.AuthenticateClient(context.Background(), r, form)
+	require.NoError(t, err)
+
+	_, err = s.o.AuthenticateClient(context.Background(), r, form)
+	assert.ErrorIs(t, err, ErrJTIKnown)
+}
+
+func TestAuthenticateClient_AssertionJTIRetention(t *testing.T) {
+	s := newAssertionTestSetup(t)
+	claims := newAssertionClaims("key-client")
+	claims.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(30 * time.Minute))
+	r, _ := http.NewRequest(http.MethodPost, "/oauth/token", nil)
+
+	_, err := s.o.AuthenticateClient(context.Background(), r, assertionForm(s.sign(t, gojwt.SigningMethodES256, claims, "key-1")))
+	require.NoError(t, err)
+
+	// the jti is kept until the assertion expires, which is never later than the allowed lifetime
+	store := s.o.store.(*testStore)
+	assert.WithinDuration(t, claims.ExpiresAt.Time, store.jtis[claims.ID], time.Second)
+	assert.False(t, store.jtis[claims.ID].After(time.Now().Add(s.o.config.GetClientAssertionLifetime())))
+}
+
+func TestAuthenticateClient_RegisteredMethod(t *testing.T) {
+	cases := []struct {
+		name     string
+		clientID string
+		basic    bool
+		wantErr  error
+	}{
+		{name: "client_secret_basic", clientID: "basic-client", basic: true},
+		{name: "client_secret_post", clientID: "post-client"},
+		{name: "any secret method when none is registered", clientID: "plain-client"},
+		{name: "client_secret_basic client using the body", clientID: "basic-client", wantErr: ErrInvalidClient},
+		{name: "client_secret_post client using the header", clientID: "post-client", basic: true, wantErr: ErrInvalidClient},
+		{name: "private_key_jwt client using a secret", clientID: "key-client", basic: true, wantErr: ErrInvalidClient},
+		{name: "client_secret_jwt client using a secret", clientID: "secret-client", wantErr: ErrInvalidClient},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newAssertionTestSetup(t)
+			r, _ := http.NewRequest(http.MethodPost, "/oauth/token", nil)
+			form := url.Values{}
+			if tc.basic {
+				r.SetBasicAuth(tc.clientID, "secret")
+			} else {
+				form.Set("client_id", tc.clientID)
+				form.Set("client_secret", "secret")
+			}
+
+			client, err := s.o.AuthenticateClient(context.Background(), r, form)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.clientID, client.GetID())
+		})
+	}
+}
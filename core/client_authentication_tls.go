@@ -0,0 +1,219 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	ClientAuthenticationMethodTLS           = "tls_client_auth"
+	ClientAuthenticationMethodSelfSignedTLS = "self_signed_tls_client_auth"
+)
+
+// TLSClient is implemented by clients that can authenticate with a client certificate as described in RFC 8705
+// section 2. Only one of the subject values is registered for the tls_client_auth method.
+type TLSClient interface {
+	GetTLSClientAuthSubjectDN() string
+	GetTLSClientAuthSANDNS() string
+	GetTLSClientAuthSANURI() string
+	GetTLSClientAuthSANIP() string
+	GetTLSClientAuthSANEmail() string
+	// IsTLSClientCertificateBoundAccessTokens reports whether the access tokens of the client must be bound to its
+	// client certificate, see RFC 8705 section 3.4.
+	IsTLSClientCertificateBoundAccessTokens() bool
+}
+
+// authenticateTLSClient authenticates the client with the certificate of the TLS connection. The TLS server only
+// requests the certificate, the chain of tls_client_auth clients is verified here against the trusted client CAs.
+func (o *OAuth2) authenticateTLSClient(ctx context.Context, r *http.Request, client Client, method string) (Client, error) {
+	cert := ClientCertificateFromRequest(r)
+	if cert == nil {
+		return nil, ErrInvalidClient.WithHint("The client is registered with token_endpoint_auth_method '%s' but no client certificate was presented.", method)
+	}
+
+	tlsClient, ok := client.(TLSClient)
+	if !ok {
+		return nil, ErrInvalidClient.WithHint("The client does not support mutual TLS client authentication.")
+	}
+
+	switch method {
+	case ClientAuthenticationMethodTLS:
+		chain := r.TLS.PeerCertificates
+		intermediates := x509.NewCertPool()
+		for _, c := range chain[1:] {
+			intermediates.AddCert(c)
+		}
+
+		// a nil pool would make Verify fall back to the system roots, any public CA could then issue client certificates
+		roots := o.config.GetTLSClientCAs()
+		if roots == nil {
+			roots = x509.NewCertPool()
+		}
+
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return nil, ErrInvalidClient.WithHint("The client certificate is not issued by a trusted certificate authority.").WithWrap(err).WithDebug("%s", err)
+		}
+
+		if !matchCertificateSubject(cert, tlsClient) {
+			return nil, ErrInvalidClient.WithHint("The client certificate does not match the registered subject of the client.")
+		}
+	case ClientAuthenticationMethodSelfSignedTLS:
+		oidcClient, ok := client.(OpenIDConnectClient)
+		if !ok {
+			return nil, ErrInvalidClient.WithHint("The client does not support self-signed certificate authentication.")
+		}
+
+		keys, err := o.clientJWKS(ctx, oidcClient)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.ContainsFunc(keys.Keys, func(key jose.JSONWebKey) bool { return matchCertificateKey(cert, key) }) {
+			return nil, ErrInvalidClient.WithHint("The client certificate does not match any of the keys registered by the client.")
+		}
+	}
+
+	return client, nil
+}
+
+// ClientCertificateFromRequest returns the leaf certificate presented by the client on the TLS connection, if any.
+func ClientCertificateFromRequest(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	return r.TLS.PeerCertificates[0]
+}
+
+// CertificateThumbprint is the base64url encoded SHA-256 hash of the DER encoded certificate, used as the x5t#S256
+// confirmation method.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCertificateBinding checks that an access token bound to a client certificate is presented over a TLS
+// connection with the same certificate, as described in RFC 8705 section 3.
+func (o *OAuth2) VerifyCertificateBinding(ctx context.Context, r *http.Request, tr *TokenRequest) error {
+	cnf := confirmationFromSession(tr.Session)
+	if cnf == nil || cnf.X5TS256 == "" {
+		return nil
+	}
+
+	cert := ClientCertificateFromRequest(r)
+	if cert == nil || CertificateThumbprint(cert) != cnf.X5TS256 {
+		return ErrInvalidToken.WithHint("The access token is bound to a client certificate that was not presented.")
+	}
+
+	return nil
+}
+
+// handleTokenCertificateBinding binds the tokens of a token request to the client certificate when the client
+// authenticated with it or asked for certificate-bound access tokens. Refresh tokens of public clients stay bound to
+// the certificate they were first issued to, see RFC 8705 section 4.
+func (o *OAuth2) handleTokenCertificateBinding(r *http.Request, tr *TokenRequest) error {
+	session, ok := tr.Session.(ConfirmationSession)
+
+	bound := ""
+	if cnf := confirmationFromSession(tr.Session); cnf != nil && tr.GrantType.ExactOne(string(GrantTypeRefreshToken)) && tr.Client.IsPublic() {
+		bound = cnf.X5TS256
+	}
+
+	thumbprint := ""
+	if cert := ClientCertificateFromRequest(r); cert != nil && isCertificateBindingEnabled(tr.Client) {
+		thumbprint = CertificateThumbprint(cert)
+	} else if tlsClient, ok := tr.Client.(TLSClient); ok && tlsClient.IsTLSClientCertificateBoundAccessTokens() {
+		return ErrInvalidRequest.WithHint("The client requires certificate-bound access tokens, but no client certificate was presented.")
+	}
+
+	if bound != "" && thumbprint != bound {
+		return ErrInvalidGrant.WithHint("The refresh token is bound to a client certificate that was not presented.")
+	}
+
+	if !ok {
+		return nil
+	}
+
+	cnf := session.GetConfirmation()
+	if cnf == nil {
+		cnf = &Confirmation{}
+	}
+	cnf.X5TS256 = thumbprint
+	session.SetConfirmation(cnf)
+
+	return nil
+}
+
+func isCertificateBindingEnabled(client Client) bool {
+	if tlsClient, ok := client.(TLSClient); ok && tlsClient.IsTLSClientCertificateBoundAccessTokens() {
+		return true
+	}
+
+	if oidcClient, ok := client.(OpenIDConnectClient); ok {
+		method := oidcClient.GetTokenEndpointAuthMethod()
+		return method == ClientAuthenticationMethodTLS || method == ClientAuthenticationMethodSelfSignedTLS
+	}
+
+	return false
+}
+
+// clientJWKS returns the inline key set of the client or the one published at its jwks_uri.
+func (o *OAuth2) clientJWKS(ctx context.Context, client OpenIDConnectClient) (*jose.JSONWebKeySet, error) {
+	if keys := client.GetJWKs(); keys != nil && len(keys.Keys) > 0 {
+		return keys, nil
+	}
+
+	location := client.GetJWKsURI()
+	if location == "" {
+		return nil, ErrInvalidClient.WithHint("The client has neither 'jwks' nor 'jwks_uri' registered.")
+	}
+
+	keys, err := o.config.GetJWKSFetcher().Resolve(ctx, location, false)
+	if err != nil {
+		return nil, ErrInvalidClient.WithHint("Unable to fetch the client's 'jwks_uri'.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	return keys, nil
+}
+
+func matchCertificateSubject(cert *x509.Certificate, client TLSClient) bool {
+	switch {
+	case client.GetTLSClientAuthSubjectDN() != "":
+		return cert.Subject.String() == client.GetTLSClientAuthSubjectDN()
+	case client.GetTLSClientAuthSANDNS() != "":
+		return slices.Contains(cert.DNSNames, client.GetTLSClientAuthSANDNS())
+	case client.GetTLSClientAuthSANURI() != "":
+		return slices.ContainsFunc(cert.URIs, func(u *url.URL) bool { return u.String() == client.GetTLSClientAuthSANURI() })
+	case client.GetTLSClientAuthSANIP() != "":
+		ip := net.ParseIP(client.GetTLSClientAuthSANIP())
+		return ip != nil && slices.ContainsFunc(cert.IPAddresses, ip.Equal)
+	case client.GetTLSClientAuthSANEmail() != "":
+		return slices.Contains(cert.EmailAddresses, client.GetTLSClientAuthSANEmail())
+	default:
+		return false
+	}
+}
+
+// matchCertificateKey reports whether the key, or the first certificate of its x5c chain, is the one of cert.
+func matchCertificateKey(cert *x509.Certificate, key jose.JSONWebKey) bool {
+	if len(key.Certificates) > 0 {
+		return key.Certificates[0].Equal(cert)
+	}
+
+	pub, ok := key.Public().Key.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(cert.PublicKey)
+}
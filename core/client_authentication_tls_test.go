@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTLSClient struct {
+	testClient
+	subjectDN string
+}
+
+func (c *testTLSClient) GetTLSClientAuthSubjectDN() string             { return c.subjectDN }
+func (c *testTLSClient) GetTLSClientAuthSANDNS() string                { return "" }
+func (c *testTLSClient) GetTLSClientAuthSANURI() string                { return "" }
+func (c *testTLSClient) GetTLSClientAuthSANIP() string                 { return "" }
+func (c *testTLSClient) GetTLSClientAuthSANEmail() string              { return "" }
+func (c *testTLSClient) IsTLSClientCertificateBoundAccessTokens() bool { return false }
+
+func newTestCertificate(t *testing.T, subject string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: subject},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+// systemRootCertificate returns a certificate of the system trust store that verifies against the system roots.
+func systemRootCertificate(t *testing.T) *x509.Certificate {
+	for _, file := range []string{"/etc/ssl/certs/ca-certificates.crt", "/etc/pki/tls/certs/ca-bundle.crt", "/etc/ssl/cert.pem"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+
+			_, err = cert.Verify(x509.VerifyOptions{KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			if err == nil {
+				return cert
+			}
+		}
+	}
+
+	t.Skip("no system root certificate available")
+	return nil
+}
+
+func TestAuthenticateTLSClient_Chain(t *testing.T) {
+	ca, caKey := newTestCertificate(t, "trusted-ca", nil, nil)
+	otherCA, otherCAKey := newTestCertificate(t, "other-ca", nil, nil)
+	leaf, _ := newTestCertificate(t, "client", ca, caKey)
+	otherLeaf, _ := newTestCertificate(t, "client", otherCA, otherCAKey)
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(ca)
+
+	cases := []struct {
+		name      string
+		clientCAs *x509.CertPool
+		chain     func(t *testing.T) []*x509.Certificate
+		subjectDN string
+		wantErr   bool
+	}{
+		{
+			name:      "issued by a trusted CA",
+			clientCAs: trusted,
+			chain:     func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{leaf} },
+			subjectDN: "CN=client",
+		},
+		{
+			name:      "issued by an untrusted CA",
+			clientCAs: trusted,
+			chain:     func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{otherLeaf, otherCA} },
+			subjectDN: "CN=client",
+			wantErr:   true,
+		},
+		{
+			name:      "subject does not match",
+			clientCAs: trusted,
+			chain:     func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{leaf} },
+			subjectDN: "CN=other-client",
+			wantErr:   true,
+		},
+		{
+			name:      "no client CAs configured",
+			chain:     func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{leaf, ca} },
+			subjectDN: "CN=client",
+			wantErr:   true,
+		},
+		{
+			name:    "chains only to a system root",
+			chain:   func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{systemRootCertificate(t)} },
+			wantErr: true,
+		},
+		{
+			name:      "chains only to a system root with client CAs configured",
+			clientCAs: trusted,
+			chain:     func(t *testing.T) []*x509.Certificate { return []*x509.Certificate{systemRootCertificate(t)} },
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := newTestConfig()
+			config.clientCAs = tc.clientCAs
+			o := &OAuth2{config: config}
+
+			chain := tc.chain(t)
+			subjectDN := tc.subjectDN
+			if subjectDN == "" {
+				subjectDN = chain[0].Subject.String()
+			}
+
+			client := &testTLSClient{
+				testClient: testClient{id: "tls-client", authMethod: ClientAuthenticationMethodTLS},
+				subjectDN:  subjectDN,
+			}
+
+			r := httptest.NewRequest("POST", "https://auth.example.com/oauth/token", nil)
+			r.TLS = &tls.ConnectionState{PeerCertificates: chain}
+
+			authenticated, err := o.authenticateTLSClient(context.Background(), r, client, ClientAuthenticationMethodTLS)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidClient)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, client, authenticated)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"crypto/x509"
 	"hash"
 	"net/url"
 	"time"
@@ -60,6 +61,16 @@ type GlobalSecretProvider interface {
 	GetGlobalSecret() []byte
 }
 
+// AllowedTopLevelClaimsProvider returns the custom claims that are copied to the top level of JWT access tokens.
+type AllowedTopLevelClaimsProvider interface {
+	GetAllowedTopLevelClaims() []string
+}
+
+// RotatedSecretsProvider returns the previous global secrets, which are only used to validate what they signed.
+type RotatedSecretsProvider interface {
+	GetRotatedSecrets() [][]byte
+}
+
 type HMACHashingProvider interface {
 	GetHMACHasher() func() hash.Hash
 }
@@ -79,3 +90,54 @@ type AllowedPromptsProvider interface {
 type RedirectSecureCheckerProvider interface {
 	GetRedirectSecureChecker() func(*url.URL) bool
 }
+
+type TokenURLProvider interface {
+	GetTokenURL() string
+}
+
+type JWKSFetcherProvider interface {
+	GetJWKSFetcher() JWKSFetcher
+}
+
+type DeviceCodeLifetimeProvider interface {
+	GetDeviceCodeLifetime() time.Duration
+}
+
+type DeviceAuthorizationPollingIntervalProvider interface {
+	GetDeviceAuthorizationPollingInterval() time.Duration
+}
+
+type DeviceVerificationURLProvider interface {
+	GetDeviceVerificationURL() string
+}
+
+type PushedAuthorizeRequestLifetimeProvider interface {
+	GetPushedAuthorizeRequestLifetime() time.Duration
+}
+
+type AuthorizeResponseJWTLifetimeProvider interface {
+	GetAuthorizeResponseJWTLifetime() time.Duration
+}
+
+type PushedAuthorizationRequiredProvider interface {
+	IsPushedAuthorizationRequired() bool
+}
+
+type ClientAssertionLifetimeProvider interface {
+	// GetClientAssertionLifetime returns the longest time a client assertion may be valid for.
+	GetClientAssertionLifetime() time.Duration
+}
+
+type DPoPProofLifetimeProvider interface {
+	GetDPoPProofLifetime() time.Duration
+}
+
+type DPoPNonceProvider interface {
+	IsDPoPNonceRequired() bool
+	GetDPoPNonceLifetime() time.Duration
+}
+
+type TLSClientCAsProvider interface {
+	// GetTLSClientCAs returns the certificate authorities trusted for tls_client_auth, nil trusts no certificate.
+	GetTLSClientCAs() *x509.CertPool
+}
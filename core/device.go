@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type UserCodeState int16
+
+const (
+	UserCodeUnused   UserCodeState = 0 // the end-user has not entered the user code yet
+	UserCodeAccepted UserCodeState = 1
+	UserCodeRejected UserCodeState = 2
+)
+
+// DeviceRequest is a device authorization request as described in RFC 8628 section 3.1. The user code state and the
+// last polling time are kept alongside the request once it is stored.
+type DeviceRequest struct {
+	Request
+	UserCodeState UserCodeState `json:"-"`
+	LastPolledAt  time.Time     `json:"-"`
+}
+
+func NewDeviceRequest(session Session) *DeviceRequest {
+	r := &DeviceRequest{
+		Request: *NewRequest(),
+	}
+	r.Session = session
+	return r
+}
+
+type DeviceResponse struct {
+	DeviceCode              string        `json:"device_code"`
+	UserCode                string        `json:"user_code"`
+	VerificationURI         string        `json:"verification_uri"`
+	VerificationURIComplete string        `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               time.Duration `json:"expires_in"`
+	Interval                time.Duration `json:"interval,omitempty"`
+}
+
+// NewDeviceRequest validates a request to the device authorization endpoint. Both public and confidential clients are
+// allowed, confidential clients must authenticate as they do at the token endpoint.
+func (o *OAuth2) NewDeviceRequest(ctx context.Context, req *http.Request, session Session) (*DeviceRequest, error) {
+	if session == nil {
+		return nil, errors.New("session must not be nil")
+	}
+
+	if req.Method != http.MethodPost {
+		return nil, ErrInvalidRequest.WithHint("HTTP method is '%s', expected 'POST'.", req.Method)
+	}
+
+	form, err := x.BindPostForm(req)
+	if err != nil {
+		return nil, ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err)
+	}
+
+	client, err := o.AuthenticateClient(ctx, req, form)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.GetGrantTypes().IncludeOne(string(GrantTypeDeviceCode)) {
+		return nil, ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use the device authorization grant.")
+	}
+
+	deviceRequest := NewDeviceRequest(session)
+	deviceRequest.Form = form
+	deviceRequest.Client = client
+	deviceRequest.RequestedScope = strings.Fields(form.Get("scope"))
+	deviceRequest.RequestedAudience = form["audience"]
+
+	return deviceRequest, nil
+}
+
+func (o *OAuth2) NewDeviceResponse(ctx context.Context, req *DeviceRequest) (*DeviceResponse, error) {
+	deviceResponse := &DeviceResponse{}
+
+	handled := false
+	for _, dh := range o.deviceHandlers {
+		he := dh.HandleDeviceRequest(ctx, req, deviceResponse)
+		if he == nil {
+			handled = true
+		} else if errors.Is(he, ErrUnknownRequest) {
+			continue
+		} else {
+			return nil, he
+		}
+	}
+
+	if !handled {
+		return nil, ErrInvalidRequest.WithHint("The device authorization grant is not supported by this authorization server.")
+	}
+
+	return deviceResponse, nil
+}
+
+func (o *OAuth2) WriteDeviceError(ctx context.Context, rw http.ResponseWriter, err error) {
+	o.writeError(ctx, rw, err)
+}
+
+func (o *OAuth2) WriteDeviceResponse(ctx context.Context, rw http.ResponseWriter, resp *DeviceResponse) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	jsonPayload, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(jsonPayload)
+}
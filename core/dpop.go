@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+const (
+	DPoPHeader      = "DPoP"
+	DPoPNonceHeader = "DPoP-Nonce"
+	DPoPProofType   = "dpop+jwt"
+)
+
+// DPoPSigningAlgorithms are the algorithms accepted for DPoP proofs, symmetric algorithms and "none" are never
+// accepted as the proof must be verifiable with the public key in its header.
+var DPoPSigningAlgorithms = privateKeyJWTAlgorithms
+
+type dpopClaims struct {
+	gojwt.RegisteredClaims
+	HTM   string `json:"htm"`
+	HTU   string `json:"htu"`
+	ATH   string `json:"ath,omitempty"`
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// ValidateDPoPProof validates the DPoP proof of the request as described in RFC 9449 section 4.3 and returns the
+// thumbprint of its public key. htu is the URL of the endpoint the proof is sent to. When accessToken is not empty, the
+// proof must be bound to it with the ath claim.
+func (o *OAuth2) ValidateDPoPProof(ctx context.Context, r *http.Request, htu, accessToken string) (string, error) {
+	proofs := r.Header.Values(DPoPHeader)
+	if len(proofs) != 1 {
+		return "", ErrInvalidDPoPProof.WithHint("Exactly one DPoP header must be sent.")
+	}
+
+	var jwk jose.JSONWebKey
+	claims := &dpopClaims{}
+	_, err := gojwt.NewParser(
+		gojwt.WithValidMethods(DPoPSigningAlgorithms),
+	).ParseWithClaims(proofs[0], claims, func(t *gojwt.Token) (any, error) {
+		if typ, _ := t.Header["typ"].(string); typ != DPoPProofType {
+			return nil, ErrInvalidDPoPProof.WithHint("The 'typ' header of the DPoP proof must be '%s'.", DPoPProofType)
+		}
+
+		raw, err := json.Marshal(t.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(raw, &jwk); err != nil || !jwk.Valid() || !jwk.IsPublic() {
+			return nil, ErrInvalidDPoPProof.WithHint("The 'jwk' header of the DPoP proof must be a valid public key.")
+		}
+
+		return jwk.Key, nil
+	})
+	if err != nil {
+		return "", ErrInvalidDPoPProof.WithHint("Unable to verify the DPoP proof.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if claims.ID == "" {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'jti' from the DPoP proof must be set.")
+	}
+
+	if !strings.EqualFold(claims.HTM, r.Method) {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'htm' from the DPoP proof must be '%s'.", r.Method)
+	}
+
+	if normalizeHTU(claims.HTU) != normalizeHTU(htu) {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'htu' from the DPoP proof must be '%s'.", htu)
+	}
+
+	now := x.NowUTC()
+	lifetime := o.config.GetDPoPProofLifetime()
+	if claims.IssuedAt == nil || claims.IssuedAt.Before(now.Add(-lifetime)) || claims.IssuedAt.After(now.Add(lifetime)) {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'iat' from the DPoP proof is missing or outside of the accepted time window.")
+	}
+
+	if o.config.IsDPoPNonceRequired() && !o.validDPoPNonce(claims.Nonce) {
+		return "", ErrUseDPoPNonce.WithHint("The DPoP proof must contain the nonce from the 'DPoP-Nonce' header.")
+	}
+
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(hash[:]) {
+			return "", ErrInvalidDPoPProof.WithHint("Claim 'ath' from the DPoP proof does not match the access token.")
+		}
+	}
+
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", ErrInvalidDPoPProof.WithHint("Unable to compute the thumbprint of the DPoP proof key.").WithWrap(err).WithDebug("%s", err)
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	// proofs share the replay cache of client assertions, the key is namespaced so the two can never collide
+	replayKey := sha256.Sum256([]byte(jkt + ":" + claims.ID))
+	jti := "dpop:" + hex.EncodeToString(replayKey[:])
+	if err = o.store.ClientAssertionJWTValid(ctx, jti); err != nil {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'jti' from the DPoP proof has already been used.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	// the proof is rejected once its iat is older than the lifetime, which is at most twice the lifetime from now
+	if err = o.store.SetClientAssertionJWT(ctx, jti, claims.IssuedAt.Add(lifetime)); err != nil {
+		return "", ErrInvalidDPoPProof.WithHint("Claim 'jti' from the DPoP proof has already been used.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	return jkt, nil
+}
+
+// VerifyDPoPBinding checks that an access token bound to a DPoP key is presented with a proof of possession of that
+// key, as described in RFC 9449 section 7. Tokens that are not bound are accepted as they are.
+func (o *OAuth2) VerifyDPoPBinding(ctx context.Context, r *http.Request, htu, accessToken string, tr *TokenRequest) error {
+	cnf := confirmationFromSession(tr.Session)
+	if cnf == nil || cnf.JKT == "" {
+		return nil
+	}
+
+	scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, DPoPTokenType) {
+		return ErrInvalidDPoPProof.WithHint("The access token is bound to a DPoP key and must be sent with the DPoP authorization scheme.")
+	}
+
+	jkt, err := o.ValidateDPoPProof(ctx, r, htu, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if jkt != cnf.JKT {
+		return ErrInvalidDPoPProof.WithHint("The DPoP proof is signed with a different key than the one the access token is bound to.")
+	}
+
+	return nil
+}
+
+// handleTokenDPoPProof binds the tokens of a token request to the key of its DPoP proof. Refresh tokens of public
+// clients stay bound to the key they were first issued to, see RFC 9449 section 5.
+func (o *OAuth2) handleTokenDPoPProof(ctx context.Context, r *http.Request, tr *TokenRequest) error {
+	session, ok := tr.Session.(ConfirmationSession)
+
+	bound := ""
+	if cnf := confirmationFromSession(tr.Session); cnf != nil && tr.GrantType.ExactOne(string(GrantTypeRefreshToken)) && tr.Client.IsPublic() {
+		bound = cnf.JKT
+	}
+
+	jkt := ""
+	if len(r.Header.Values(DPoPHeader)) > 0 {
+		if !ok {
+			return ErrServerError.WithDebug("The session does not implement ConfirmationSession and can not be bound to a DPoP key.")
+		}
+
+		var err error
+		if jkt, err = o.ValidateDPoPProof(ctx, r, o.config.GetTokenURL(), ""); err != nil {
+			return err
+		}
+	}
+
+	if bound != "" && jkt != bound {
+		return ErrInvalidDPoPProof.WithHint("The refresh token is bound to a DPoP key, the request must contain a DPoP proof signed with that key.")
+	}
+
+	if !ok {
+		return nil
+	}
+
+	cnf := session.GetConfirmation()
+	if cnf == nil {
+		cnf = &Confirmation{}
+	}
+	cnf.JKT = jkt
+	session.SetConfirmation(cnf)
+
+	return nil
+}
+
+// NewDPoPNonce returns a nonce for the DPoP-Nonce header. Nonces are not stored, they carry their issue time and are
+// authenticated with the global secret.
+func (o *OAuth2) NewDPoPNonce() string {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, uint64(x.NowUTC().Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(payload, o.dpopNonceMAC(payload, o.config.GetGlobalSecret())...))
+}
+
+func (o *OAuth2) validDPoPNonce(nonce string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(raw) <= 8 {
+		return false
+	}
+
+	payload, mac := raw[:8], raw[8:]
+	secrets := append([][]byte{o.config.GetGlobalSecret()}, o.config.GetRotatedSecrets()...)
+	if !slices.ContainsFunc(secrets, func(secret []byte) bool {
+		return hmac.Equal(mac, o.dpopNonceMAC(payload, secret))
+	}) {
+		return false
+	}
+
+	issuedAt := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	return x.NowUTC().Sub(issuedAt) <= o.config.GetDPoPNonceLifetime()
+}
+
+func (o *OAuth2) dpopNonceMAC(payload, secret []byte) []byte {
+	mac := hmac.New(o.config.GetHMACHasher(), secret)
+	mac.Write([]byte("dpop-nonce"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func confirmationFromSession(session Session) *Confirmation {
+	if s, ok := session.(ConfirmationSession); ok {
+		return s.GetConfirmation()
+	}
+
+	return nil
+}
+
+// normalizeHTU drops the query and fragment and lower-cases the scheme and host as described in RFC 9449 section 4.3.
+func normalizeHTU(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.EscapedPath()
+}
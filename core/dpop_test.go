@@ -0,0 +1,479 @@
+package core
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dpopTestHTU = "https://rs.example.com/resource"
+
+func newDPoPKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func dpopThumbprint(t *testing.T, key *ecdsa.PrivateKey) string {
+	thumbprint, err := (&jose.JSONWebKey{Key: &key.PublicKey}).Thumbprint(crypto.SHA256)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(thumbprint)
+}
+
+// newDPoPProof signs a valid proof for a GET request to dpopTestHTU, modify can break it before it is signed.
+func newDPoPProof(t *testing.T, key *ecdsa.PrivateKey, modify func(token *gojwt.Token, claims *dpopClaims)) string {
+	claims := &dpopClaims{
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ID:       rand.Text(),
+			IssuedAt: gojwt.NewNumericDate(time.Now()),
+		},
+		HTM: http.MethodGet,
+		HTU: dpopTestHTU,
+	}
+
+	token := gojwt.NewWithClaims(gojwt.SigningMethodES256, claims)
+	token.Header["typ"] = DPoPProofType
+	token.Header["jwk"] = jose.JSONWebKey{Key: &key.PublicKey}
+	if modify != nil {
+		modify(token, claims)
+	}
+
+	proof, err := token.SignedString(key)
+	require.NoError(t, err)
+	return proof
+}
+
+func newDPoPRequest(method string, proofs ...string) *http.Request {
+	r, _ := http.NewRequest(method, dpopTestHTU, nil)
+	for _, proof := range proofs {
+		r.Header.Add(DPoPHeader, proof)
+	}
+	return r
+}
+
+func newDPoPTestOAuth2(cfg *testConfig) *OAuth2 {
+	return &OAuth2{config: cfg, store: newTestStore()}
+}
+
+func TestValidateDPoPProof(t *testing.T) {
+	key := newDPoPKey(t)
+	accessToken := "access-token"
+	ath := sha256.Sum256([]byte(accessToken))
+
+	cases := []struct {
+		name        string
+		method      string
+		accessToken string
+		modify      func(token *gojwt.Token, claims *dpopClaims)
+		wantErr     error
+	}{
+		{
+			name:   "valid proof",
+			method: http.MethodGet,
+		},
+		{
+			name:   "htm is compared case-insensitively",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) { claims.HTM = "get" },
+		},
+		{
+			name:   "htu ignores the query, fragment and case of the host",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.HTU = "HTTPS://RS.Example.com/resource?foo=bar#baz"
+			},
+		},
+		{
+			name:        "valid proof bound to an access token",
+			method:      http.MethodGet,
+			accessToken: accessToken,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.ATH = base64.RawURLEncoding.EncodeToString(ath[:])
+			},
+		},
+		{
+			name:    "wrong typ",
+			method:  http.MethodGet,
+			modify:  func(token *gojwt.Token, claims *dpopClaims) { token.Header["typ"] = "JWT" },
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:    "missing jwk",
+			method:  http.MethodGet,
+			modify:  func(token *gojwt.Token, claims *dpopClaims) { delete(token.Header, "jwk") },
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "private jwk",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				token.Header["jwk"] = jose.JSONWebKey{Key: key}
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "symmetric jwk",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				token.Header["jwk"] = jose.JSONWebKey{Key: []byte(strings.Repeat("k", 32))}
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "jwk of another key",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				token.Header["jwk"] = jose.JSONWebKey{Key: &newDPoPKey(t).PublicKey}
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:    "missing jti",
+			method:  http.MethodGet,
+			modify:  func(token *gojwt.Token, claims *dpopClaims) { claims.ID = "" },
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:    "htm mismatch",
+			method:  http.MethodPost,
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "htu mismatch",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.HTU = "https://rs.example.com/other"
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "htu path is case-sensitive",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.HTU = "https://rs.example.com/Resource"
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:    "missing iat",
+			method:  http.MethodGet,
+			modify:  func(token *gojwt.Token, claims *dpopClaims) { claims.IssuedAt = nil },
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "stale iat",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:   "iat in the future",
+			method: http.MethodGet,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				claims.IssuedAt = gojwt.NewNumericDate(time.Now().Add(2 * time.Minute))
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+		{
+			name:        "missing ath",
+			method:      http.MethodGet,
+			accessToken: accessToken,
+			wantErr:     ErrInvalidDPoPProof,
+		},
+		{
+			name:        "wrong ath",
+			method:      http.MethodGet,
+			accessToken: accessToken,
+			modify: func(token *gojwt.Token, claims *dpopClaims) {
+				other := sha256.Sum256([]byte("other-token"))
+				claims.ATH = base64.RawURLEncoding.EncodeToString(other[:])
+			},
+			wantErr: ErrInvalidDPoPProof,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newDPoPTestOAuth2(newTestConfig())
+			r := newDPoPRequest(tc.method, newDPoPProof(t, key, tc.modify))
+
+			jkt, err := o.ValidateDPoPProof(context.Background(), r, dpopTestHTU, tc.accessToken)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, dpopThumbprint(t, key), jkt)
+		})
+	}
+}
+
+func TestValidateDPoPProof_HeaderCount(t *testing.T) {
+	key := newDPoPKey(t)
+	o := newDPoPTestOAuth2(newTestConfig())
+
+	_, err := o.ValidateDPoPProof(context.Background(), newDPoPRequest(http.MethodGet), dpopTestHTU, "")
+	assert.ErrorIs(t, err, ErrInvalidDPoPProof)
+
+	r := newDPoPRequest(http.MethodGet, newDPoPProof(t, key, nil), newDPoPProof(t, key, nil))
+	_, err = o.ValidateDPoPProof(context.Background(), r, dpopTestHTU, "")
+	assert.ErrorIs(t, err, ErrInvalidDPoPProof)
+}
+
+func TestValidateDPoPProof_Replay(t *testing.T) {
+	ctx := context.Background()
+	key := newDPoPKey(t)
+	o := newDPoPTestOAuth2(newTestConfig())
+
+	proof := newDPoPProof(t, key, func(token *gojwt.Token, claims *dpopClaims) { claims.ID = "jti" })
+	_, err := o.ValidateDPoPProof(ctx, newDPoPRequest(http.MethodGet, proof), dpopTestHTU, "")
+	require.NoError(t, err)
+
+	_, err = o.ValidateDPoPProof(ctx, newDPoPRequest(http.MethodGet, proof), dpopTestHTU, "")
+	assert.ErrorIs(t, err, ErrInvalidDPoPProof)
+
+	// the jti is kept while the proof is within the accepted time window, not any longer
+	store := o.store.(*testStore)
+	require.Len(t, store.jtis, 1)
+	for _, exp := range store.jtis {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), exp, 2*time.Second)
+	}
+
+	// the replay cache is keyed by the proof key, another key may reuse the same jti
+	other := newDPoPProof(t, newDPoPKey(t), func(token *gojwt.Token, claims *dpopClaims) { claims.ID = "jti" })
+	_, err = o.ValidateDPoPProof(ctx, newDPoPRequest(http.MethodGet, other), dpopTestHTU, "")
+	assert.NoError(t, err)
+}
+
+func TestValidateDPoPProof_Nonce(t *testing.T) {
+	key := newDPoPKey(t)
+	oldSecret := []byte(strings.Repeat("o", 64))
+
+	// nonces carry their issue time, expired ones are built by hand
+	staleNonce := func(o *OAuth2, secret []byte) string {
+		payload := make([]byte, 8)
+		binary.BigEndian.PutUint64(payload, uint64(time.Now().Add(-2*time.Minute).Unix()))
+		return base64.RawURLEncoding.EncodeToString(append(payload, o.dpopNonceMAC(payload, secret)...))
+	}
+
+	cases := []struct {
+		name    string
+		nonce   func(o *OAuth2, cfg *testConfig) string
+		wantErr error
+	}{
+		{
+			name:  "current nonce",
+			nonce: func(o *OAuth2, cfg *testConfig) string { return o.NewDPoPNonce() },
+		},
+		{
+			name: "nonce signed with a rotated secret",
+			nonce: func(o *OAuth2, cfg *testConfig) string {
+				cfg.secret, cfg.rotatedSecrets = oldSecret, nil
+				nonce := o.NewDPoPNonce()
+				cfg.secret, cfg.rotatedSecrets = []byte(strings.Repeat("s", 64)), [][]byte{oldSecret}
+				return nonce
+			},
+		},
+		{
+			name: "nonce signed with an unknown secret",
+			nonce: func(o *OAuth2, cfg *testConfig) string {
+				return staleNonce(&OAuth2{config: &testConfig{nonceLifetime: time.Hour}}, oldSecret)
+			},
+			wantErr: ErrUseDPoPNonce,
+		},
+		{
+			name:    "expired nonce",
+			nonce:   func(o *OAuth2, cfg *testConfig) string { return staleNonce(o, cfg.secret) },
+			wantErr: ErrUseDPoPNonce,
+		},
+		{
+			name:    "malformed nonce",
+			nonce:   func(o *OAuth2, cfg *testConfig) string { return "not-a-nonce" },
+			wantErr: ErrUseDPoPNonce,
+		},
+		{
+			name:    "missing nonce",
+			nonce:   func(o *OAuth2, cfg *testConfig) string { return "" },
+			wantErr: ErrUseDPoPNonce,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestConfig()
+			cfg.nonceRequired = true
+			o := newDPoPTestOAuth2(cfg)
+
+			nonce := tc.nonce(o, cfg)
+			proof := newDPoPProof(t, key, func(token *gojwt.Token, claims *dpopClaims) { claims.Nonce = nonce })
+
+			_, err := o.ValidateDPoPProof(context.Background(), newDPoPRequest(http.MethodGet, proof), dpopTestHTU, "")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifyDPoPBinding(t *testing.T) {
+	ctx := context.Background()
+	key := newDPoPKey(t)
+	accessToken := "access-token"
+	ath := sha256.Sum256([]byte(accessToken))
+	bindProof := func(token *gojwt.Token, claims *dpopClaims) {
+		claims.ATH = base64.RawURLEncoding.EncodeToString(ath[:])
+	}
+
+	newTokenRequest := func(jkt string) *TokenRequest {
+		session := newTestSession("alice")
+		if jkt != "" {
+			session.SetConfirmation(&Confirmation{JKT: jkt})
+		}
+		return NewTokenRequest(session)
+	}
+
+	o := newDPoPTestOAuth2(newTestConfig())
+
+	// tokens that are not bound are accepted without a proof
+	r := newDPoPRequest(http.MethodGet)
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+	assert.NoError(t, o.VerifyDPoPBinding(ctx, r, dpopTestHTU, accessToken, newTokenRequest("")))
+
+	// bound tokens must use the DPoP scheme
+	r = newDPoPRequest(http.MethodGet, newDPoPProof(t, key, bindProof))
+	r.Header.Set("Authorization", "Bearer "+accessToken)
+	assert.ErrorIs(t, o.VerifyDPoPBinding(ctx, r, dpopTestHTU, accessToken, newTokenRequest(dpopThumbprint(t, key))), ErrInvalidDPoPProof)
+
+	r = newDPoPRequest(http.MethodGet, newDPoPProof(t, key, bindProof))
+	r.Header.Set("Authorization", "DPoP "+accessToken)
+	assert.NoError(t, o.VerifyDPoPBinding(ctx, r, dpopTestHTU, accessToken, newTokenRequest(dpopThumbprint(t, key))))
+
+	// and a proof signed with the key they are bound to
+	other := newDPoPKey(t)
+	r = newDPoPRequest(http.MethodGet, newDPoPProof(t, other, bindProof))
+	r.Header.Set("Authorization", "DPoP "+accessToken)
+	assert.ErrorIs(t, o.VerifyDPoPBinding(ctx, r, dpopTestHTU, accessToken, newTokenRequest(dpopThumbprint(t, key))), ErrInvalidDPoPProof)
+}
+
+func TestHandleTokenDPoPProof(t *testing.T) {
+	key, newKey := newDPoPKey(t), newDPoPKey(t)
+	tokenProof := func(t *testing.T, key *ecdsa.PrivateKey) string {
+		return newDPoPProof(t, key, func(token *gojwt.Token, claims *dpopClaims) {
+			claims.HTM = http.MethodPost
+			claims.HTU = newTestConfig().GetTokenURL()
+		})
+	}
+
+	cases := []struct {
+		name      string
+		public    bool
+		grantType GrantType
+		bound     bool
+		proofKey  *ecdsa.PrivateKey
+		wantErr   error
+		wantJKT   string
+	}{
+		{
+			name:      "binds the tokens to the proof key",
+			grantType: GrantTypeAuthorizationCode,
+			proofKey:  key,
+			wantJKT:   dpopThumbprint(t, key),
+		},
+		{
+			name:      "tokens without a proof stay unbound",
+			grantType: GrantTypeAuthorizationCode,
+		},
+		{
+			name:      "public client refresh with the bound key",
+			public:    true,
+			grantType: GrantTypeRefreshToken,
+			bound:     true,
+			proofKey:  key,
+			wantJKT:   dpopThumbprint(t, key),
+		},
+		{
+			name:      "public client refresh with a different key",
+			public:    true,
+			grantType: GrantTypeRefreshToken,
+			bound:     true,
+			proofKey:  newDPoPKey(t),
+			wantErr:   ErrInvalidDPoPProof,
+		},
+		{
+			name:      "public client refresh without a proof",
+			public:    true,
+			grantType: GrantTypeRefreshToken,
+			bound:     true,
+			wantErr:   ErrInvalidDPoPProof,
+		},
+		{
+			name:      "confidential client refresh may move to a new key",
+			grantType: GrantTypeRefreshToken,
+			bound:     true,
+			proofKey:  newKey,
+			wantJKT:   dpopThumbprint(t, newKey),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newDPoPTestOAuth2(newTestConfig())
+
+			session := newTestSession("alice")
+			if tc.bound {
+				session.SetConfirmation(&Confirmation{JKT: dpopThumbprint(t, key)})
+			}
+			tr := NewTokenRequest(session)
+			tr.Client = &testClient{id: "client", public: tc.public}
+			tr.GrantType = Arguments{string(tc.grantType)}
+
+			r := newDPoPRequest(http.MethodPost)
+			if tc.proofKey != nil {
+				r.Header.Set(DPoPHeader, tokenProof(t, tc.proofKey))
+			}
+
+			err := o.handleTokenDPoPProof(context.Background(), r, tr)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, session.GetConfirmation())
+			assert.Equal(t, tc.wantJKT, session.GetConfirmation().JKT)
+		})
+	}
+}
+
+func TestNormalizeHTU(t *testing.T) {
+	cases := map[string]string{
+		"https://rs.example.com/resource":                 "https://rs.example.com/resource",
+		"HTTPS://RS.EXAMPLE.COM/resource":                 "https://rs.example.com/resource",
+		"https://rs.example.com/resource?query=1#section": "https://rs.example.com/resource",
+		"https://rs.example.com:8443/a%20b":               "https://rs.example.com:8443/a%20b",
+		"https://rs.example.com/Resource":                 "https://rs.example.com/Resource",
+	}
+
+	for raw, want := range cases {
+		assert.Equal(t, want, normalizeHTU(raw), raw)
+	}
+}
@@ -107,6 +107,11 @@ var (
 		DescriptionField: "The OP does not support use of the request parameter.",
 		CodeField:        http.StatusBadRequest,
 	}
+	ErrRequestURINotSupported = &RFC6749Error{
+		ErrorField:       "request_uri_not_supported",
+		DescriptionField: "The OP does not support use of the request_uri parameter.",
+		CodeField:        http.StatusBadRequest,
+	}
 	ErrUnauthorizedClient = &RFC6749Error{
 		ErrorField:       "unauthorized_client",
 		DescriptionField: "The client is not authorized to request a token using this method.",
@@ -128,6 +133,11 @@ var (
 		DescriptionField: "The authorization server does not support obtaining a token using this method.",
 		CodeField:        http.StatusBadRequest,
 	}
+	ErrUnsupportedTokenType = &RFC6749Error{
+		ErrorField:       "unsupported_token_type",
+		DescriptionField: "The authorization server does not support the revocation of the presented token type.",
+		CodeField:        http.StatusBadRequest,
+	}
 	ErrInvalidTokenFormat = &RFC6749Error{
 		ErrorField:       "invalid_token",
 		DescriptionField: "Invalid token format.",
@@ -202,6 +212,61 @@ var (
 		HintField:        "You are not allowed to perform this action.",
 		CodeField:        http.StatusForbidden,
 	}
+	ErrInvalidRequestURI = &RFC6749Error{
+		ErrorField:       "invalid_request_uri",
+		DescriptionField: "The request_uri in the authorization request returns an error or contains invalid data.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidRequestObject = &RFC6749Error{
+		ErrorField:       "invalid_request_object",
+		DescriptionField: "The request parameter contains an invalid Request Object.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidToken = &RFC6749Error{
+		ErrorField:       "invalid_token",
+		DescriptionField: "The access token provided is expired, revoked, malformed, or invalid for other reasons.",
+		CodeField:        http.StatusUnauthorized,
+	}
+	ErrInvalidRedirectURI = &RFC6749Error{
+		ErrorField:       "invalid_redirect_uri",
+		DescriptionField: "The value of one or more redirection URIs is invalid.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidClientMetadata = &RFC6749Error{
+		ErrorField:       "invalid_client_metadata",
+		DescriptionField: "The value of one of the client metadata fields is invalid.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidDPoPProof = &RFC6749Error{
+		ErrorField:       "invalid_dpop_proof",
+		DescriptionField: "The DPoP proof is invalid.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrUseDPoPNonce = &RFC6749Error{
+		ErrorField:       "use_dpop_nonce",
+		DescriptionField: "The authorization server requires a nonce in the DPoP proof.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrAuthorizationPending = &RFC6749Error{
+		ErrorField:       "authorization_pending",
+		DescriptionField: "The authorization request is still pending as the end user hasn't yet completed the user-interaction steps.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrSlowDown = &RFC6749Error{
+		ErrorField:       "slow_down",
+		DescriptionField: "The authorization request is still pending and polling should continue, but the interval must be increased by 5 seconds for this and all subsequent requests.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrDeviceExpiredToken = &RFC6749Error{
+		ErrorField:       "expired_token",
+		DescriptionField: "The device_code has expired, and the device authorization session has concluded.",
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrJTIKnown = &RFC6749Error{
+		ErrorField:       "jti_known",
+		DescriptionField: "The jti was already used.",
+		CodeField:        http.StatusBadRequest,
+	}
 	ErrConsentRequired = &RFC6749Error{
 		ErrorField:       "consent_required",
 		DescriptionField: "The Authorization Server requires End-User consent.",
@@ -3,6 +3,7 @@ package core
 import (
 	"html/template"
 	"io"
+	"net/url"
 )
 
 const formPostHTML = `<html lang="en">
@@ -21,11 +22,11 @@ const formPostHTML = `<html lang="en">
 </html>
 `
 
-func (o *OAuth2) FormPostResponse(redirectTo string, rw io.Writer) {
+func (o *OAuth2) FormPostResponse(redirectTo string, params url.Values, rw io.Writer) {
 	tp := template.Must(template.New("form_post").Parse(formPostHTML))
 	_ = tp.Execute(rw, map[string]any{
 		"RedirectURL": redirectTo,
-		"Parameters":  map[string]any{},
+		"Parameters":  params,
 	},
 	)
 }
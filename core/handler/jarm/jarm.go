@@ -0,0 +1,81 @@
+package jarm
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type AuthorizeResponseConfigurator interface {
+	core.IDTokenIssuerProvider
+	core.AuthorizeResponseJWTLifetimeProvider
+	core.JWKSFetcherProvider
+}
+
+// AuthorizeResponseHandler implements the JWT Secured Authorization Response Mode for OAuth 2.0 (JARM). The response
+// parameters are signed with the provider key and encrypted to the client when it registered an encryption algorithm,
+// so the client can detect codes injected or replayed on the way back.
+type AuthorizeResponseHandler struct {
+	config AuthorizeResponseConfigurator
+	signer strategy.JWTSigner
+}
+
+func NewAuthorizeResponseHandler(config AuthorizeResponseConfigurator, signer strategy.JWTSigner) *AuthorizeResponseHandler {
+	return &AuthorizeResponseHandler{
+		config: config,
+		signer: signer,
+	}
+}
+
+func (h *AuthorizeResponseHandler) EncodeAuthorizeResponse(ctx context.Context, req *core.AuthorizeRequest, params url.Values) (string, error) {
+	if req.Client == nil {
+		return "", errors.New("the authorization response has no client")
+	}
+
+	claims := gojwt.MapClaims{}
+	for k := range params {
+		if v := params.Get(k); v != "" {
+			claims[k] = v
+		}
+	}
+	claims["iss"] = h.config.GetIDTokenIssuer()
+	claims["aud"] = req.Client.GetID()
+	claims["exp"] = x.NowUTC().Add(h.config.GetAuthorizeResponseJWTLifetime()).Unix()
+
+	token, _, err := h.signer.Generate(ctx, claims)
+	if err != nil {
+		return "", err
+	}
+
+	client, ok := req.Client.(core.AuthorizeResponseJWTClient)
+	if !ok {
+		return token, nil
+	}
+
+	// the response is signed with the provider key, a client expecting another algorithm could not verify it
+	if alg := client.GetAuthorizationSignedResponseAlg(); alg != "" {
+		parsed, _, err := gojwt.NewParser().ParseUnverified(token, gojwt.MapClaims{})
+		if err != nil {
+			return "", core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		if signedAlg := parsed.Method.Alg(); signedAlg != alg {
+			return "", core.ErrServerError.
+				WithHint("The authorization response can not be signed with '%s' as the provider key uses '%s'.", alg, signedAlg)
+		}
+	}
+
+	if client.GetAuthorizationEncryptedResponseAlg() == "" {
+		return token, nil
+	}
+
+	return core.EncryptForClient(ctx, h.config.GetJWKSFetcher(), req.Client, []byte(token), true,
+		client.GetAuthorizationEncryptedResponseAlg(),
+		client.GetAuthorizationEncryptedResponseEnc(),
+	)
+}
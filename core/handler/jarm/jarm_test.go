@@ -0,0 +1,210 @@
+package jarm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type testClient struct {
+	id      string
+	jwks    *jose.JSONWebKeySet
+	signAlg string
+	encAlg  string
+	enc     string
+}
+
+func (c *testClient) GetID() string                                { return c.id }
+func (c *testClient) GetHashedSecret() []byte                      { return nil }
+func (c *testClient) GetRedirectURIs() []string                    { return nil }
+func (c *testClient) GetGrantTypes() core.Arguments                { return nil }
+func (c *testClient) GetResponseTypes() core.Arguments             { return nil }
+func (c *testClient) GetResponseModes() []core.ResponseMode        { return nil }
+func (c *testClient) GetScopes() core.Arguments                    { return nil }
+func (c *testClient) IsPublic() bool                               { return false }
+func (c *testClient) GetAudience() core.Arguments                  { return nil }
+func (c *testClient) GetRequestURIs() []string                     { return nil }
+func (c *testClient) GetJWKs() *jose.JSONWebKeySet                 { return c.jwks }
+func (c *testClient) GetJWKsURI() string                           { return "" }
+func (c *testClient) GetTokenEndpointAuthMethod() string           { return "" }
+func (c *testClient) GetTokenEndpointAuthSigningAlg() string       { return "" }
+func (c *testClient) GetUserinfoSignedResponseAlg() string         { return "" }
+func (c *testClient) GetAuthorizationSignedResponseAlg() string    { return c.signAlg }
+func (c *testClient) GetAuthorizationEncryptedResponseAlg() string { return c.encAlg }
+func (c *testClient) GetAuthorizationEncryptedResponseEnc() string { return c.enc }
+
+type testConfig struct{}
+
+func (testConfig) GetIDTokenIssuer() string                       { return "https://auth.example.com" }
+func (testConfig) GetAccessTokenIssuer() string                   { return "https://auth.example.com" }
+func (testConfig) GetAuthorizeResponseJWTLifetime() time.Duration { return 10 * time.Minute }
+func (testConfig) GetJWKSFetcher() core.JWKSFetcher               { return nil }
+
+func newTestHandler(t *testing.T) (*AuthorizeResponseHandler, *jwt.DefaultSigner) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwk := &jose.JSONWebKey{Key: key, KeyID: "provider-key", Algorithm: "ES256", Use: "sig"}
+	signer, err := jwt.NewSigner(testConfig{}, func(ctx context.Context, kid ...string) (any, error) {
+		if len(kid) > 0 && kid[0] != jwk.KeyID {
+			return nil, errors.New("key not found")
+		}
+		return jwk, nil
+	})
+	require.NoError(t, err)
+
+	return NewAuthorizeResponseHandler(testConfig{}, signer), signer
+}
+
+func newAuthorizeRequest(client core.Client) *core.AuthorizeRequest {
+	req := core.NewAuthorizeRequest()
+	req.Client = client
+	return req
+}
+
+func TestAuthorizeResponseHandler_Signed(t *testing.T) {
+	ctx := context.Background()
+	h, signer := newTestHandler(t)
+
+	token, err := h.EncodeAuthorizeResponse(ctx, newAuthorizeRequest(&testClient{id: "client"}), url.Values{
+		"code":  {"authorization-code"},
+		"state": {"state-value"},
+		"empty": {""},
+	})
+	require.NoError(t, err)
+
+	claims := gojwt.MapClaims{}
+	require.NoError(t, signer.Decode(ctx, token, claims))
+
+	assert.Equal(t, "authorization-code", claims["code"])
+	assert.Equal(t, "state-value", claims["state"])
+	assert.NotContains(t, claims, "empty")
+	assert.Equal(t, "https://auth.example.com", claims["iss"])
+	assert.Equal(t, "client", claims["aud"])
+
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.WithinDuration(t, x.NowUTC().Add(10*time.Minute), exp.Time, 2*time.Second)
+}
+
+func TestAuthorizeResponseHandler_RegisteredSigningAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	h, signer := newTestHandler(t)
+
+	token, err := h.EncodeAuthorizeResponse(ctx, newAuthorizeRequest(&testClient{id: "client", signAlg: "ES256"}), url.Values{
+		"code": {"authorization-code"},
+	})
+	require.NoError(t, err)
+
+	claims := gojwt.MapClaims{}
+	require.NoError(t, signer.Decode(ctx, token, claims))
+	assert.Equal(t, "authorization-code", claims["code"])
+
+	_, err = h.EncodeAuthorizeResponse(ctx, newAuthorizeRequest(&testClient{id: "client", signAlg: "PS256"}), url.Values{
+		"code": {"authorization-code"},
+	})
+	assert.ErrorIs(t, err, core.ErrServerError)
+}
+
+func TestAuthorizeResponseHandler_ParamsCannotOverrideRegisteredClaims(t *testing.T) {
+	ctx := context.Background()
+	h, signer := newTestHandler(t)
+
+	token, err := h.EncodeAuthorizeResponse(ctx, newAuthorizeRequest(&testClient{id: "client"}), url.Values{
+		"code": {"authorization-code"},
+		"iss":  {"https://attacker.example.com"},
+		"aud":  {"other-client"},
+	})
+	require.NoError(t, err)
+
+	claims := gojwt.MapClaims{}
+	require.NoError(t, signer.Decode(ctx, token, claims))
+	assert.Equal(t, "https://auth.example.com", claims["iss"])
+	assert.Equal(t, "client", claims["aud"])
+}
+
+func TestAuthorizeResponseHandler_Encrypted(t *testing.T) {
+	ctx := context.Background()
+	h, signer := newTestHandler(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	client := &testClient{
+		id:     "client",
+		encAlg: string(jose.RSA_OAEP_256),
+		jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "client-enc", Use: "enc"},
+		}},
+	}
+
+	token, err := h.EncodeAuthorizeResponse(ctx, newAuthorizeRequest(client), url.Values{"code": {"authorization-code"}})
+	require.NoError(t, err)
+
+	// the response is a JWE whose payload is the signed response, it uses the default content encryption
+	jwe, err := jose.ParseEncrypted(token,
+		[]jose.KeyAlgorithm{jose.RSA_OAEP_256},
+		[]jose.ContentEncryption{jose.ContentEncryption(core.DefaultResponseEncryptionEncoding)},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "client-enc", jwe.Header.KeyID)
+	assert.Equal(t, "JWT", jwe.Header.ExtraHeaders[jose.HeaderContentType])
+
+	nested, err := jwe.Decrypt(key)
+	require.NoError(t, err)
+
+	claims := gojwt.MapClaims{}
+	require.NoError(t, signer.Decode(ctx, string(nested), claims))
+	assert.Equal(t, "authorization-code", claims["code"])
+	assert.Equal(t, "client", claims["aud"])
+}
+
+func TestAuthorizeResponseHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		client core.Client
+	}{
+		{
+			name: "no client",
+		},
+		{
+			name:   "signing algorithm not used by the provider key",
+			client: &testClient{id: "client", signAlg: "RS256"},
+		},
+		{
+			name:   "encryption algorithm without a key",
+			client: &testClient{id: "client", encAlg: string(jose.RSA_OAEP_256)},
+		},
+		{
+			name: "no key for the encryption algorithm",
+			client: &testClient{
+				id:     "client",
+				encAlg: string(jose.RSA_OAEP_256),
+				jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+					{Key: []byte("secret"), KeyID: "client-oct", Use: "enc"},
+				}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			_, err := h.EncodeAuthorizeResponse(context.Background(), newAuthorizeRequest(tc.client), url.Values{"code": {"code"}})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -126,12 +126,12 @@ func (h *AuthorizationCodeGrantHandler) HandleAuthorizeResponse(
 
 	code, signature, err := h.tokenStrategy.GenerateAuthorizeCode(ctx, &req.Request)
 	if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	err = h.tokenStorage.CreateAuthorizeCodeSession(ctx, signature, &req.Request)
 	if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	res.Code = code
@@ -139,6 +139,10 @@ func (h *AuthorizationCodeGrantHandler) HandleAuthorizeResponse(
 	return nil
 }
 
+func (h *AuthorizationCodeGrantHandler) GetGrantType() core.GrantType {
+	return core.GrantTypeAuthorizationCode
+}
+
 func (h *AuthorizationCodeGrantHandler) HandleTokenRequest(ctx context.Context, tokenRequest *core.TokenRequest) error {
 	if !tokenRequest.GrantType.ExactOne("authorization_code") {
 		return core.ErrUnknownRequest
@@ -176,15 +180,15 @@ func (h *AuthorizationCodeGrantHandler) HandleTokenRequest(ctx context.Context,
 			debug += "Revocation of refresh_token lead to error " + re.Error() + "."
 		}
 
-		return core.ErrInvalidGrant.WithHint(hint).WithDebug(debug)
+		return core.ErrInvalidGrant.WithHint("%s", hint).WithDebug("%s", debug)
 	} else if stderr.Is(err, core.ErrNotFound) {
-		return core.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error())
+		return core.ErrInvalidGrant.WithWrap(err).WithDebug("%s", err)
 	} else if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	if err = h.tokenStrategy.ValidateAuthorizeCode(ctx, authorizeRequest, code); err != nil {
-		return core.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error())
+		return core.ErrInvalidGrant.WithWrap(err).WithDebug("%s", err)
 	}
 
 	// overwrite the request
@@ -217,7 +221,7 @@ func (h *AuthorizationCodeGrantHandler) HandleTokenResponse(
 	ctx context.Context,
 	req *core.TokenRequest,
 	res *core.TokenResponse,
-) error {
+) (err error) {
 	if !req.GrantType.ExactOne("authorization_code") {
 		return core.ErrUnknownRequest
 	}
@@ -229,7 +233,7 @@ func (h *AuthorizationCodeGrantHandler) HandleTokenResponse(
 
 	ctx, err = storage.TryBeginTX(ctx, h.tokenStorage)
 	if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 	defer func() {
 		if err != nil {
@@ -240,10 +244,36 @@ func (h *AuthorizationCodeGrantHandler) HandleTokenResponse(
 		}
 	}()
 
+	// the authorization code can only be exchanged once, a replayed code is detected by HandleTokenRequest
+	codeSignature := h.tokenStrategy.AuthorizeCodeSignature(ctx, req.Code)
+	if err = h.tokenStorage.InvalidateAuthorizeCodeSession(ctx, codeSignature); stderr.Is(err, core.ErrInactiveToken) {
+		return core.ErrInvalidGrant.WithHint("The authorization code has already been used.").WithWrap(err)
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
 	if err = h.tokenStorage.CreateAccessTokenSession(ctx, signature, &req.Request); err != nil {
 		return err
 	}
 
+	if canIssueRefreshToken(req) {
+		var refreshToken, refreshSignature string
+		refreshToken, refreshSignature, err = h.tokenStrategy.GenerateRefreshToken(ctx, &req.Request)
+		if err != nil {
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		if err = h.tokenStorage.CreateRefreshTokenSession(ctx, refreshSignature, signature, &req.Request); err != nil {
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		res.RefreshToken = refreshToken
+	}
+
+	if err = storage.TryCommit(ctx, h.tokenStorage); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
 	accessTokenLifetime := h.config.GetAccessTokenLifetime()
 	if req.Session.GetExpiresAt(core.AccessToken).IsZero() {
 		res.ExpiresIn = time.Duration(accessTokenLifetime.Seconds())
@@ -252,7 +282,16 @@ func (h *AuthorizationCodeGrantHandler) HandleTokenResponse(
 	}
 
 	res.AccessToken = accessToken
-	res.TokenType = core.BearerToken
+	res.TokenType = req.AccessTokenType()
 	res.Scope = strings.Join(req.GrantedScope, " ")
 	return nil
 }
+
+// canIssueRefreshToken reports whether the user granted offline access and the client may use the refresh_token grant.
+func canIssueRefreshToken(req *core.TokenRequest) bool {
+	if !req.GrantedScope.IncludeOne(OfflineScopes...) {
+		return false
+	}
+
+	return req.Client.GetGrantTypes().IncludeOne(string(core.GrantTypeRefreshToken))
+}
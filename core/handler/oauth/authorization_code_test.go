@@ -0,0 +1,168 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type authorizationCodeTestSetup struct {
+	handler  *AuthorizationCodeGrantHandler
+	strategy *HMACStrategy
+	storage  *memoryStorage
+	client   *testClient
+}
+
+func newAuthorizationCodeTestSetup(t *testing.T) *authorizationCodeTestSetup {
+	cfg := newTestConfig()
+	tokenStrategy := newTestHMACStrategy(t, cfg)
+	storage := newMemoryStorage()
+
+	return &authorizationCodeTestSetup{
+		handler:  NewAuthorizationCodeGrantHandler(cfg, tokenStrategy, storage),
+		strategy: tokenStrategy,
+		storage:  storage,
+		client: &testClient{
+			id:         "code-client",
+			grantTypes: []string{string(core.GrantTypeAuthorizationCode), string(core.GrantTypeRefreshToken)},
+			scopes:     []string{"openid", "offline_access"},
+		},
+	}
+}
+
+// issue stores an authorization code as if the end-user granted the scope at the authorization endpoint.
+func (s *authorizationCodeTestSetup) issue(t *testing.T, scope ...string) string {
+	req := core.NewRequest()
+	req.Client = s.client
+	req.Session = newTestSession("alice")
+	req.Session.SetExpiresAt(core.AuthorizationCode, x.NowUTC().Add(time.Minute))
+	req.RequestedScope = scope
+	req.GrantedScope = scope
+
+	code, signature, err := s.strategy.GenerateAuthorizeCode(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateAuthorizeCodeSession(context.Background(), signature, req))
+	return code
+}
+
+func (s *authorizationCodeTestSetup) tokenRequest(code string) *core.TokenRequest {
+	req := core.NewTokenRequest(newTestSession(""))
+	req.Client = s.client
+	req.GrantType = core.Arguments{string(core.GrantTypeAuthorizationCode)}
+	req.Code = code
+	return req
+}
+
+func (s *authorizationCodeTestSetup) respond(t *testing.T, req *core.TokenRequest) (*core.TokenResponse, error) {
+	// the token endpoint grants the requested scope once every handler accepted the request
+	req.GrantedScope = req.RequestedScope
+	res := core.NewTokenResponse()
+	return res, s.handler.HandleTokenResponse(context.Background(), req, res)
+}
+
+func (s *authorizationCodeTestSetup) exchange(t *testing.T, code string) (*core.TokenResponse, error) {
+	req := s.tokenRequest(code)
+	if err := s.handler.HandleTokenRequest(context.Background(), req); err != nil {
+		return nil, err
+	}
+
+	return s.respond(t, req)
+}
+
+func TestAuthorizationCodeGrant_Exchanges(t *testing.T) {
+	s := newAuthorizationCodeTestSetup(t)
+	code := s.issue(t, "openid", "offline_access")
+
+	res, err := s.exchange(t, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEmpty(t, res.RefreshToken)
+
+	stored := s.storage.authorizeCodes[s.strategy.AuthorizeCodeSignature(context.Background(), code)]
+	assert.False(t, stored.active)
+}
+
+func TestAuthorizationCodeGrant_ReplayRevokesIssuedTokens(t *testing.T) {
+	s := newAuthorizationCodeTestSetup(t)
+	code := s.issue(t, "openid", "offline_access")
+
+	res, err := s.exchange(t, code)
+	require.NoError(t, err)
+
+	_, err = s.exchange(t, code)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+
+	// the tokens issued under the code are revoked, see RFC 6749 section 4.1.2
+	assert.Empty(t, s.storage.accessTokens)
+	refresh := s.storage.refreshTokens[s.strategy.RefreshTokenSignature(context.Background(), res.RefreshToken)]
+	require.NotNil(t, refresh)
+	assert.False(t, refresh.active)
+}
+
+func TestAuthorizationCodeGrant_ConcurrentExchange(t *testing.T) {
+	s := newAuthorizationCodeTestSetup(t)
+	code := s.issue(t, "openid", "offline_access")
+
+	// both requests pass the validation before either of them invalidates the code
+	ctx := context.Background()
+	first, second := s.tokenRequest(code), s.tokenRequest(code)
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, first))
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, second))
+
+	_, err := s.respond(t, first)
+	require.NoError(t, err)
+
+	res, err := s.respond(t, second)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+	assert.Empty(t, res.AccessToken)
+	assert.Len(t, s.storage.accessTokens, 1)
+	assert.Len(t, s.storage.refreshTokens, 1)
+}
+
+func TestAuthorizationCodeGrant_Validation(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    func(s *authorizationCodeTestSetup) string
+		client  *testClient
+		wantErr error
+	}{
+		{
+			name:    "unknown code",
+			code:    func(s *authorizationCodeTestSetup) string { return "unknown.code" },
+			wantErr: core.ErrInvalidGrant,
+		},
+		{
+			name:    "client without the grant type",
+			client:  &testClient{id: "code-client"},
+			wantErr: core.ErrUnauthorizedClient,
+		},
+		{
+			name:    "code of another client",
+			client:  &testClient{id: "other", grantTypes: []string{string(core.GrantTypeAuthorizationCode)}},
+			wantErr: core.ErrInvalidGrant,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newAuthorizationCodeTestSetup(t)
+			code := s.issue(t, "openid")
+			if tc.code != nil {
+				code = tc.code(s)
+			}
+
+			req := s.tokenRequest(code)
+			if tc.client != nil {
+				req.Client = tc.client
+			}
+
+			err := s.handler.HandleTokenRequest(context.Background(), req)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
@@ -37,6 +37,10 @@ func NewClientCredentialsGrantHandler(
 	}
 }
 
+func (h *ClientCredentialsGrantHandler) GetGrantType() core.GrantType {
+	return core.GrantTypeClientCredentials
+}
+
 func (h *ClientCredentialsGrantHandler) HandleTokenRequest(ctx context.Context, req *core.TokenRequest) error {
 	if !req.GrantType.ExactOne("client_credentials") {
 		return core.ErrUnknownRequest
@@ -107,7 +111,7 @@ func (h *ClientCredentialsGrantHandler) HandleTokenResponse(
 	}
 
 	res.AccessToken = token
-	res.TokenType = core.BearerToken
+	res.TokenType = req.AccessTokenType()
 	res.Scope = strings.Join(req.GrantedScope, " ")
 	return nil
 }
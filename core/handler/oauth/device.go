@@ -0,0 +1,256 @@
+package oauth
+
+import (
+	"context"
+	stderr "errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type DeviceGrantConfigurator interface {
+	strategy.ScopeStrategyProvider
+	strategy.AudienceStrategyProvider
+	core.DeviceCodeLifetimeProvider
+	core.DeviceAuthorizationPollingIntervalProvider
+	core.DeviceVerificationURLProvider
+	core.AccessTokenLifetimeProvider
+	core.RefreshTokenLifetimeProvider
+}
+
+type DeviceGrantStorage interface {
+	storage.AccessTokenStorage
+	storage.RefreshTokenStorage
+	storage.DeviceCodeStorage
+}
+
+type DeviceGrantStrategy interface {
+	strategy.DeviceCodeStrategy
+	strategy.UserCodeStrategy
+}
+
+// DeviceGrantHandler implements the RFC 8628 device authorization grant. The device authorization endpoint issues the
+// codes, the end-user approves the user code out of band and the device polls the token endpoint until then.
+type DeviceGrantHandler struct {
+	config         DeviceGrantConfigurator
+	tokenStrategy  strategy.TokenStrategy
+	deviceStrategy DeviceGrantStrategy
+	tokenStorage   DeviceGrantStorage
+}
+
+func NewDeviceGrantHandler(
+	config DeviceGrantConfigurator,
+	tokenStrategy strategy.TokenStrategy,
+	deviceStrategy DeviceGrantStrategy,
+	tokenStorage DeviceGrantStorage,
+) *DeviceGrantHandler {
+	return &DeviceGrantHandler{
+		config:         config,
+		tokenStrategy:  tokenStrategy,
+		deviceStrategy: deviceStrategy,
+		tokenStorage:   tokenStorage,
+	}
+}
+
+func (h *DeviceGrantHandler) GetGrantType() core.GrantType {
+	return core.GrantTypeDeviceCode
+}
+
+func (h *DeviceGrantHandler) HandleDeviceRequest(ctx context.Context, req *core.DeviceRequest, res *core.DeviceResponse) error {
+	client := req.Client
+	if client == nil {
+		return core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.")
+	}
+
+	scopeStrategy := h.config.GetScopeStrategy()
+	if err := scopeStrategy(client.GetScopes(), req.RequestedScope); err != nil {
+		return err
+	}
+
+	audienceStrategy := h.config.GetAudienceStrategy()
+	if err := audienceStrategy(client.GetAudience(), req.RequestedAudience); err != nil {
+		return err
+	}
+
+	if req.Session == nil {
+		return core.ErrServerError.WithHint("session cannot be nil")
+	}
+
+	req.Session.SetExpiresAt(core.DeviceCode, x.NowUTC().Add(h.config.GetDeviceCodeLifetime()))
+
+	deviceCode, deviceSignature, err := h.deviceStrategy.GenerateDeviceCode(ctx, &req.Request)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	userCode, userSignature, err := h.deviceStrategy.GenerateUserCode(ctx)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	err = h.tokenStorage.CreateDeviceCodeSession(ctx, deviceSignature, userSignature, &req.Request)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	verificationURI := h.config.GetDeviceVerificationURL()
+	completeURI, err := url.Parse(verificationURI)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	query := completeURI.Query()
+	query.Set("user_code", userCode)
+	completeURI.RawQuery = query.Encode()
+
+	res.DeviceCode = deviceCode
+	res.UserCode = userCode
+	res.VerificationURI = verificationURI
+	res.VerificationURIComplete = completeURI.String()
+	res.ExpiresIn = x.SecondsFromNow(req.Session.GetExpiresAt(core.DeviceCode))
+	res.Interval = time.Duration(h.config.GetDeviceAuthorizationPollingInterval().Seconds())
+	return nil
+}
+
+func (h *DeviceGrantHandler) HandleTokenRequest(ctx context.Context, tokenRequest *core.TokenRequest) error {
+	if !tokenRequest.GrantType.ExactOne(string(core.GrantTypeDeviceCode)) {
+		return core.ErrUnknownRequest
+	}
+
+	client := tokenRequest.Client
+	if client == nil {
+		return core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.")
+	}
+
+	if !client.GetGrantTypes().IncludeOne(string(core.GrantTypeDeviceCode)) {
+		return core.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use the device authorization grant.")
+	}
+
+	code := tokenRequest.Form.Get("device_code")
+	if code == "" {
+		return core.ErrInvalidRequest.WithHint("The 'device_code' parameter is missing.")
+	}
+
+	signature := h.deviceStrategy.DeviceCodeSignature(ctx, code)
+	deviceRequest, err := h.tokenStorage.GetDeviceCodeSession(ctx, signature, tokenRequest.Session)
+	if stderr.Is(err, core.ErrNotFound) || stderr.Is(err, core.ErrInactiveToken) {
+		return core.ErrInvalidGrant.WithHint("The device code is invalid or has already been used.").WithWrap(err).WithDebug("%s", err)
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = h.deviceStrategy.ValidateDeviceCode(ctx, &deviceRequest.Request, code); err != nil {
+		if stderr.Is(err, core.ErrDeviceExpiredToken) {
+			return err
+		}
+		return core.ErrInvalidGrant.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if deviceRequest.Client.GetID() != client.GetID() {
+		return core.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the device authorization request.")
+	}
+
+	// every poll counts towards the interval, including the ones answered with slow_down
+	now := x.NowUTC()
+	if err = h.tokenStorage.UpdateDeviceCodePolledAt(ctx, signature, now); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	interval := h.config.GetDeviceAuthorizationPollingInterval()
+	if !deviceRequest.LastPolledAt.IsZero() && deviceRequest.LastPolledAt.Add(interval).After(now) {
+		return core.ErrSlowDown
+	}
+
+	switch deviceRequest.UserCodeState {
+	case core.UserCodeAccepted:
+	case core.UserCodeRejected:
+		return core.ErrAccessDenied.WithHint("The end-user denied the authorization request.")
+	default:
+		return core.ErrAuthorizationPending
+	}
+
+	// overwrite the request with what the end-user granted during the login and consent round-trip
+	tokenRequest.ID = deviceRequest.ID
+	tokenRequest.Session = deviceRequest.Session
+	tokenRequest.RequestedScope = deviceRequest.GrantedScope
+	tokenRequest.RequestedAudience = deviceRequest.GrantedAudience
+
+	accessTokenLifetime := h.config.GetAccessTokenLifetime()
+	tokenRequest.Session.SetExpiresAt(core.AccessToken, x.NowUTC().Add(accessTokenLifetime))
+
+	refreshTokenLifetime := h.config.GetRefreshTokenLifetime()
+	if refreshTokenLifetime > -1 {
+		tokenRequest.Session.SetExpiresAt(core.RefreshToken, x.NowUTC().Add(refreshTokenLifetime))
+	}
+
+	return nil
+}
+
+func (h *DeviceGrantHandler) HandleTokenResponse(
+	ctx context.Context,
+	req *core.TokenRequest,
+	res *core.TokenResponse,
+) (err error) {
+	if !req.GrantType.ExactOne(string(core.GrantTypeDeviceCode)) {
+		return core.ErrUnknownRequest
+	}
+
+	accessToken, signature, err := h.tokenStrategy.GenerateAccessToken(ctx, &req.Request)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = storage.TryBeginTX(ctx, h.tokenStorage)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := storage.TryRollback(ctx, h.tokenStorage)
+			if rollbackErr != nil {
+				err = core.ErrServerError.WithWrap(rollbackErr).WithDebug("error: %s; rollback error: %s", err, rollbackErr)
+			}
+		}
+	}()
+
+	// the device code can only be exchanged once
+	deviceSignature := h.deviceStrategy.DeviceCodeSignature(ctx, req.Form.Get("device_code"))
+	if err = h.tokenStorage.InvalidateDeviceCodeSession(ctx, deviceSignature); stderr.Is(err, core.ErrInactiveToken) {
+		return core.ErrInvalidGrant.WithHint("The device code has already been used.").WithWrap(err)
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = h.tokenStorage.CreateAccessTokenSession(ctx, signature, &req.Request); err != nil {
+		return err
+	}
+
+	if canIssueRefreshToken(req) {
+		var refreshToken, refreshSignature string
+		refreshToken, refreshSignature, err = h.tokenStrategy.GenerateRefreshToken(ctx, &req.Request)
+		if err != nil {
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		if err = h.tokenStorage.CreateRefreshTokenSession(ctx, refreshSignature, signature, &req.Request); err != nil {
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		res.RefreshToken = refreshToken
+	}
+
+	if err = storage.TryCommit(ctx, h.tokenStorage); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	res.AccessToken = accessToken
+	res.TokenType = req.AccessTokenType()
+	res.ExpiresIn = x.SecondsFromNow(req.Session.GetExpiresAt(core.AccessToken))
+	res.Scope = strings.Join(req.GrantedScope, " ")
+	return nil
+}
@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+)
+
+type deviceTestSetup struct {
+	handler  *DeviceGrantHandler
+	storage  *memoryStorage
+	strategy *DeviceStrategy
+	client   *testClient
+}
+
+func newDeviceTestSetup(t *testing.T, cfg *testConfig) *deviceTestSetup {
+	tokenStrategy := newTestHMACStrategy(t, cfg)
+	deviceStrategy := NewDeviceStrategy(cfg, tokenStrategy.hmac)
+	storage := newMemoryStorage()
+
+	return &deviceTestSetup{
+		handler:  NewDeviceGrantHandler(cfg, tokenStrategy, deviceStrategy, storage),
+		storage:  storage,
+		strategy: deviceStrategy,
+		client: &testClient{
+			id:         "device-client",
+			public:     true,
+			grantTypes: []string{string(core.GrantTypeDeviceCode), string(core.GrantTypeRefreshToken)},
+			scopes:     []string{"openid", "offline_access"},
+		},
+	}
+}
+
+// authorize runs the device authorization request and returns the issued codes.
+func (s *deviceTestSetup) authorize(t *testing.T) *core.DeviceResponse {
+	req := core.NewDeviceRequest(newTestSession(""))
+	req.Client = s.client
+	req.RequestedScope = core.Arguments{"openid", "offline_access"}
+
+	res := &core.DeviceResponse{}
+	require.NoError(t, s.handler.HandleDeviceRequest(context.Background(), req, res))
+	return res
+}
+
+func (s *deviceTestSetup) decide(t *testing.T, userCode string, state core.UserCodeState) {
+	ctx := context.Background()
+	signature := s.strategy.UserCodeSignature(ctx, userCode)
+
+	req := core.NewRequest()
+	req.GrantedScope = core.Arguments{"openid", "offline_access"}
+	req.Session = newTestSession("alice")
+	require.NoError(t, s.storage.HandleUserCodeSession(ctx, signature, state, req))
+}
+
+func (s *deviceTestSetup) tokenRequest(deviceCode string) *core.TokenRequest {
+	req := core.NewTokenRequest(newTestSession(""))
+	req.Client = s.client
+	req.GrantType = core.Arguments{string(core.GrantTypeDeviceCode)}
+	req.Form.Set("grant_type", string(core.GrantTypeDeviceCode))
+	req.Form.Set("device_code", deviceCode)
+	return req
+}
+
+func TestDeviceGrant_IssuesTokensOnceAccepted(t *testing.T) {
+	s := newDeviceTestSetup(t, newTestConfig())
+	codes := s.authorize(t)
+	assert.Contains(t, codes.VerificationURIComplete, "user_code="+codes.UserCode)
+
+	s.decide(t, codes.UserCode, core.UserCodeAccepted)
+
+	ctx := context.Background()
+	req := s.tokenRequest(codes.DeviceCode)
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, req))
+	assert.Equal(t, "alice", req.Session.GetSubject())
+
+	req.GrantedScope = req.RequestedScope
+	res := core.NewTokenResponse()
+	require.NoError(t, s.handler.HandleTokenResponse(ctx, req, res))
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEmpty(t, res.RefreshToken)
+	assert.Len(t, s.storage.accessTokens, 1)
+
+	// the device code can not be exchanged again
+	err := s.handler.HandleTokenRequest(ctx, s.tokenRequest(codes.DeviceCode))
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+}
+
+func TestDeviceGrant_PendingAndRejected(t *testing.T) {
+	s := newDeviceTestSetup(t, newTestConfig())
+	codes := s.authorize(t)
+
+	err := s.handler.HandleTokenRequest(context.Background(), s.tokenRequest(codes.DeviceCode))
+	assert.ErrorIs(t, err, core.ErrAuthorizationPending)
+
+	s.decide(t, codes.UserCode, core.UserCodeRejected)
+
+	err = s.handler.HandleTokenRequest(context.Background(), s.tokenRequest(codes.DeviceCode))
+	assert.ErrorIs(t, err, core.ErrAccessDenied)
+}
+
+func TestDeviceGrant_SlowDown(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.pollingInterval = time.Minute
+	s := newDeviceTestSetup(t, cfg)
+	codes := s.authorize(t)
+
+	err := s.handler.HandleTokenRequest(context.Background(), s.tokenRequest(codes.DeviceCode))
+	assert.ErrorIs(t, err, core.ErrAuthorizationPending)
+
+	err = s.handler.HandleTokenRequest(context.Background(), s.tokenRequest(codes.DeviceCode))
+	assert.ErrorIs(t, err, core.ErrSlowDown)
+}
+
+func TestDeviceGrant_ConcurrentPollsExchangeOnce(t *testing.T) {
+	s := newDeviceTestSetup(t, newTestConfig())
+	codes := s.authorize(t)
+	s.decide(t, codes.UserCode, core.UserCodeAccepted)
+
+	// both polls pass the validation before either of them exchanges the device code
+	ctx := context.Background()
+	first, second := s.tokenRequest(codes.DeviceCode), s.tokenRequest(codes.DeviceCode)
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, first))
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, second))
+
+	require.NoError(t, s.handler.HandleTokenResponse(ctx, first, core.NewTokenResponse()))
+
+	res := core.NewTokenResponse()
+	err := s.handler.HandleTokenResponse(ctx, second, res)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+	assert.Empty(t, res.AccessToken)
+	assert.Len(t, s.storage.accessTokens, 1)
+}
+
+func TestDeviceStrategy_UserCodeSignatureIgnoresFormatting(t *testing.T) {
+	ds := NewDeviceStrategy(newTestConfig(), nil)
+	ctx := context.Background()
+
+	assert.Equal(t, ds.UserCodeSignature(ctx, "BCDF-GHJK"), ds.UserCodeSignature(ctx, "bcdf ghjk"))
+	assert.NotEqual(t, ds.UserCodeSignature(ctx, "BCDF-GHJK"), ds.UserCodeSignature(ctx, "BCDF-GHJL"))
+}
+
+func TestDeviceStrategy_UserCodeSignaturesAfterRotation(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig()
+	ds := NewDeviceStrategy(cfg, nil)
+	before := ds.UserCodeSignature(ctx, "BCDF-GHJK")
+
+	cfg.secret, cfg.rotatedSecrets = []byte(strings.Repeat("n", 64)), [][]byte{cfg.secret}
+	signatures := ds.UserCodeSignatures(ctx, "bcdf-ghjk")
+
+	require.Len(t, signatures, 2)
+	assert.Equal(t, ds.UserCodeSignature(ctx, "BCDF-GHJK"), signatures[0])
+	assert.NotEqual(t, before, signatures[0])
+	assert.Equal(t, before, signatures[1])
+}
@@ -0,0 +1,321 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha512"
+	"hash"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/hmac"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/strategy"
+)
+
+type testClient struct {
+	id         string
+	public     bool
+	grantTypes []string
+	scopes     []string
+	audience   []string
+}
+
+func (c *testClient) GetID() string                         { return c.id }
+func (c *testClient) GetHashedSecret() []byte               { return nil }
+func (c *testClient) GetRedirectURIs() []string             { return nil }
+func (c *testClient) GetGrantTypes() core.Arguments         { return c.grantTypes }
+func (c *testClient) GetResponseTypes() core.Arguments      { return nil }
+func (c *testClient) GetResponseModes() []core.ResponseMode { return nil }
+func (c *testClient) GetScopes() core.Arguments             { return c.scopes }
+func (c *testClient) IsPublic() bool                        { return c.public }
+func (c *testClient) GetAudience() core.Arguments           { return c.audience }
+
+type testSession struct {
+	Subject   string
+	ExpiresAt map[core.TokenType]time.Time
+	Actor     *jwt.ActorClaims
+	Extra     map[string]any
+}
+
+func newTestSession(subject string) *testSession {
+	return &testSession{Subject: subject, ExpiresAt: map[core.TokenType]time.Time{}}
+}
+
+func (s *testSession) SetExpiresAt(key core.TokenType, exp time.Time) { s.ExpiresAt[key] = exp }
+func (s *testSession) GetExpiresAt(key core.TokenType) time.Time      { return s.ExpiresAt[key] }
+func (s *testSession) GetUsername() string                            { return s.Subject }
+func (s *testSession) GetSubject() string                             { return s.Subject }
+func (s *testSession) SetSubject(subject string)                      { s.Subject = subject }
+func (s *testSession) GetActor() *jwt.ActorClaims                     { return s.Actor }
+func (s *testSession) SetActor(actor *jwt.ActorClaims)                { s.Actor = actor }
+func (s *testSession) GetExtraClaims() map[string]any                 { return s.Extra }
+
+func (s *testSession) Clone() core.Session {
+	clone := newTestSession(s.Subject)
+	clone.Actor = s.Actor
+	clone.Extra = s.Extra
+	for k, v := range s.ExpiresAt {
+		clone.ExpiresAt[k] = v
+	}
+	return clone
+}
+
+type testConfig struct {
+	secret          []byte
+	rotatedSecrets  [][]byte
+	pollingInterval time.Duration
+	refreshLifetime time.Duration
+	topLevelClaims  []string
+}
+
+func newTestConfig() *testConfig {
+	return &testConfig{
+		secret:          []byte(strings.Repeat("s", 64)),
+		refreshLifetime: time.Hour,
+	}
+}
+
+func (c *testConfig) GetScopeStrategy() strategy.ScopeStrategy { return strategy.ExactScopeStrategy }
+func (c *testConfig) GetAudienceStrategy() strategy.AudienceStrategy {
+	return strategy.ExactAudienceStrategy
+}
+func (c *testConfig) GetAccessTokenLifetime() time.Duration       { return time.Hour }
+func (c *testConfig) GetRefreshTokenLifetime() time.Duration      { return c.refreshLifetime }
+func (c *testConfig) GetAuthorizationCodeLifetime() time.Duration { return time.Minute }
+func (c *testConfig) GetDeviceCodeLifetime() time.Duration        { return 10 * time.Minute }
+func (c *testConfig) GetDeviceVerificationURL() string            { return "https://auth.example.com/device" }
+func (c *testConfig) GetTokenEntropy() int                        { return 32 }
+func (c *testConfig) GetMinParameterEntropy() int                 { return 8 }
+func (c *testConfig) GetGlobalSecret() []byte                     { return c.secret }
+func (c *testConfig) GetRotatedSecrets() [][]byte                 { return c.rotatedSecrets }
+func (c *testConfig) GetHMACHasher() func() hash.Hash             { return sha512.New512_256 }
+func (c *testConfig) IsDisableRefreshTokenValidation() bool       { return false }
+func (c *testConfig) GetAccessTokenIssuer() string                { return "https://auth.example.com" }
+func (c *testConfig) GetAllowedTopLevelClaims() []string          { return c.topLevelClaims }
+
+func (c *testConfig) GetDeviceAuthorizationPollingInterval() time.Duration {
+	return c.pollingInterval
+}
+
+func newTestHMACStrategy(t *testing.T, cfg *testConfig) *HMACStrategy {
+	signer, err := hmac.NewSigner(cfg)
+	require.NoError(t, err)
+	return NewHMACStrategy(cfg, signer)
+}
+
+type storedSession struct {
+	request       core.Request
+	active        bool
+	userSignature string
+	userCodeState core.UserCodeState
+	lastPolledAt  time.Time
+}
+
+// memoryStorage keeps the token sessions in maps keyed by signature. It follows the contract of the postgres storage,
+// including the conditional deactivation that lets only one concurrent request use a token.
+type memoryStorage struct {
+	authorizeCodes map[string]*storedSession
+	accessTokens   map[string]*storedSession
+	refreshTokens  map[string]*storedSession
+	deviceCodes    map[string]*storedSession
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		authorizeCodes: map[string]*storedSession{},
+		accessTokens:   map[string]*storedSession{},
+		refreshTokens:  map[string]*storedSession{},
+		deviceCodes:    map[string]*storedSession{},
+	}
+}
+
+func newStoredSession(req *core.Request) *storedSession {
+	stored := *req
+	stored.Form = url.Values{}
+	for k, v := range req.Form {
+		stored.Form[k] = v
+	}
+	if req.Session != nil {
+		stored.Session = req.Session.Clone()
+	}
+
+	return &storedSession{request: stored, active: true}
+}
+
+func (s *storedSession) load(session core.Session) *core.Request {
+	req := s.request
+	if session != nil && req.Session != nil {
+		req.Session = req.Session.Clone()
+	}
+	return &req
+}
+
+func (m *memoryStorage) CreateAuthorizeCodeSession(ctx context.Context, signature string, req *core.Request) error {
+	m.authorizeCodes[signature] = newStoredSession(req)
+	return nil
+}
+
+func (m *memoryStorage) GetAuthorizationCodeSession(ctx context.Context, signature string, session core.Session) (*core.Request, error) {
+	s, ok := m.authorizeCodes[signature]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	if !s.active {
+		return s.load(session), core.ErrInvalidAuthorizationCode
+	}
+	return s.load(session), nil
+}
+
+func (m *memoryStorage) InvalidateAuthorizeCodeSession(ctx context.Context, signature string) error {
+	s, ok := m.authorizeCodes[signature]
+	if !ok || !s.active {
+		return core.ErrInactiveToken
+	}
+
+	s.active = false
+	return nil
+}
+
+func (m *memoryStorage) CreateAccessTokenSession(ctx context.Context, signature string, req *core.Request) error {
+	m.accessTokens[signature] = newStoredSession(req)
+	return nil
+}
+
+func (m *memoryStorage) GetAccessTokenSession(ctx context.Context, signature string, session core.Session) (*core.Request, error) {
+	s, ok := m.accessTokens[signature]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	if !s.active {
+		return s.load(session), core.ErrInactiveToken
+	}
+	return s.load(session), nil
+}
+
+func (m *memoryStorage) DeleteAccessTokenSession(ctx context.Context, signature string) error {
+	delete(m.accessTokens, signature)
+	return nil
+}
+
+func (m *memoryStorage) RevokeAccessToken(ctx context.Context, requestID string) error {
+	for signature, s := range m.accessTokens {
+		if s.request.ID == requestID {
+			delete(m.accessTokens, signature)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) CreateRefreshTokenSession(ctx context.Context, signature string, accessSignature string, req *core.Request) error {
+	m.refreshTokens[signature] = newStoredSession(req)
+	return nil
+}
+
+func (m *memoryStorage) GetRefreshTokenSession(ctx context.Context, signature string, session core.Session) (*core.Request, error) {
+	s, ok := m.refreshTokens[signature]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	if !s.active {
+		return s.load(session), core.ErrInactiveToken
+	}
+	return s.load(session), nil
+}
+
+func (m *memoryStorage) DeleteRefreshTokenSession(ctx context.Context, signature string) error {
+	delete(m.refreshTokens, signature)
+	return nil
+}
+
+func (m *memoryStorage) RotateRefreshToken(ctx context.Context, requestID string, signature string) error {
+	s, ok := m.refreshTokens[signature]
+	if !ok || !s.active {
+		return core.ErrInactiveToken
+	}
+
+	s.active = false
+	return m.RevokeAccessToken(ctx, requestID)
+}
+
+func (m *memoryStorage) RevokeRefreshToken(ctx context.Context, requestID string) error {
+	for _, s := range m.refreshTokens {
+		if s.request.ID == requestID {
+			s.active = false
+		}
+	}
+	return nil
+}
+
+func (m *memoryStorage) CreateDeviceCodeSession(ctx context.Context, deviceCodeSignature, userCodeSignature string, req *core.Request) error {
+	s := newStoredSession(req)
+	s.userSignature = userCodeSignature
+	m.deviceCodes[deviceCodeSignature] = s
+	return nil
+}
+
+func (m *memoryStorage) deviceRequest(s *storedSession, session core.Session) *core.DeviceRequest {
+	return &core.DeviceRequest{
+		Request:       *s.load(session),
+		UserCodeState: s.userCodeState,
+		LastPolledAt:  s.lastPolledAt,
+	}
+}
+
+func (m *memoryStorage) GetDeviceCodeSession(ctx context.Context, deviceCodeSignature string, session core.Session) (*core.DeviceRequest, error) {
+	s, ok := m.deviceCodes[deviceCodeSignature]
+	if !ok {
+		return nil, core.ErrNotFound
+	}
+	if !s.active {
+		return nil, core.ErrInactiveToken
+	}
+	return m.deviceRequest(s, session), nil
+}
+
+func (m *memoryStorage) GetUserCodeSession(ctx context.Context, userCodeSignature string, session core.Session) (*core.DeviceRequest, error) {
+	for _, s := range m.deviceCodes {
+		if s.userSignature == userCodeSignature {
+			if !s.active {
+				return nil, core.ErrInactiveToken
+			}
+			return m.deviceRequest(s, session), nil
+		}
+	}
+	return nil, core.ErrNotFound
+}
+
+func (m *memoryStorage) HandleUserCodeSession(ctx context.Context, userCodeSignature string, state core.UserCodeState, req *core.Request) error {
+	for _, s := range m.deviceCodes {
+		if s.userSignature == userCodeSignature && s.active && s.userCodeState == core.UserCodeUnused {
+			s.userCodeState = state
+			if state == core.UserCodeAccepted {
+				s.request.GrantedScope = req.GrantedScope
+				s.request.GrantedAudience = req.GrantedAudience
+				s.request.Session = req.Session.Clone()
+			}
+			return nil
+		}
+	}
+	return core.ErrNotFound
+}
+
+func (m *memoryStorage) UpdateDeviceCodePolledAt(ctx context.Context, deviceCodeSignature string, polledAt time.Time) error {
+	if s, ok := m.deviceCodes[deviceCodeSignature]; ok {
+		s.lastPolledAt = polledAt
+	}
+	return nil
+}
+
+func (m *memoryStorage) InvalidateDeviceCodeSession(ctx context.Context, deviceCodeSignature string) error {
+	s, ok := m.deviceCodes[deviceCodeSignature]
+	if !ok || !s.active {
+		return core.ErrInactiveToken
+	}
+
+	s.active = false
+	return nil
+}
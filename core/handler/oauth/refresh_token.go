@@ -1,3 +1,225 @@
 package oauth
 
-type RefreshTokenGrantHandler struct{}
+import (
+	"context"
+	stderr "errors"
+	"strings"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+// OfflineScopes are the scopes that allow a client to receive a refresh token.
+var OfflineScopes = []string{"offline_access", "offline"}
+
+type RefreshTokenGrantConfigurator interface {
+	strategy.ScopeStrategyProvider
+	strategy.AudienceStrategyProvider
+	core.AccessTokenLifetimeProvider
+	core.RefreshTokenLifetimeProvider
+}
+
+type RefreshTokenGrantStorage interface {
+	storage.AccessTokenStorage
+	storage.RefreshTokenStorage
+}
+
+type RefreshTokenGrantHandler struct {
+	config        RefreshTokenGrantConfigurator
+	tokenStrategy strategy.TokenStrategy
+	tokenStorage  RefreshTokenGrantStorage
+}
+
+func NewRefreshTokenGrantHandler(
+	config RefreshTokenGrantConfigurator,
+	tokenStrategy strategy.TokenStrategy,
+	tokenStorage RefreshTokenGrantStorage,
+) *RefreshTokenGrantHandler {
+	return &RefreshTokenGrantHandler{
+		config:        config,
+		tokenStrategy: tokenStrategy,
+		tokenStorage:  tokenStorage,
+	}
+}
+
+func (h *RefreshTokenGrantHandler) GetGrantType() core.GrantType {
+	return core.GrantTypeRefreshToken
+}
+
+func (h *RefreshTokenGrantHandler) HandleTokenRequest(ctx context.Context, req *core.TokenRequest) error {
+	if !req.GrantType.ExactOne(string(core.GrantTypeRefreshToken)) {
+		return core.ErrUnknownRequest
+	}
+
+	client := req.Client
+	if client == nil {
+		// should never happen because this client must be authenticated to get here
+		return core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.")
+	}
+
+	if !client.GetGrantTypes().IncludeOne(string(core.GrantTypeRefreshToken)) {
+		return core.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant \"refresh_token\".")
+	}
+
+	refreshToken := req.Form.Get("refresh_token")
+	if refreshToken == "" {
+		return core.ErrInvalidRequest.WithHint("The 'refresh_token' parameter is required.")
+	}
+
+	signature := h.tokenStrategy.RefreshTokenSignature(ctx, refreshToken)
+	originalRequest, err := h.tokenStorage.GetRefreshTokenSession(ctx, signature, req.Session)
+	if stderr.Is(err, core.ErrInactiveToken) {
+		// The refresh token has already been rotated, which means it is being replayed. The whole token family
+		// must be revoked because we can not tell the legitimate client and the attacker apart.
+		if originalRequest == nil {
+			return core.ErrServerError.WithHint("Misconfigured code lead to an error that prohibited the OAuth 2.0 Framework from processing this request.").
+				WithDebug("GetRefreshTokenSession must return a value for when returning \"ErrInactiveToken\".")
+		}
+
+		return h.revokeTokenFamily(ctx, originalRequest.ID)
+	} else if stderr.Is(err, core.ErrNotFound) {
+		return core.ErrInvalidGrant.WithHint("The refresh token does not exist.").WithWrap(err).WithDebug("%s", err)
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = h.tokenStrategy.ValidateRefreshToken(ctx, originalRequest, refreshToken); err != nil {
+		return core.ErrInvalidGrant.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if originalRequest.Client.GetID() != client.GetID() {
+		return core.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the ID during the initial token issuance.")
+	}
+
+	if !originalRequest.GrantedScope.IncludeOne(OfflineScopes...) {
+		return core.ErrInvalidScope.WithHint("The OAuth 2.0 Client was not granted scope %s and may thus not perform the 'refresh_token' authorization grant.", strings.Join(OfflineScopes, " or "))
+	}
+
+	// A client may narrow down the scope of the new access token, but it can never widen it.
+	scopeStrategy := h.config.GetScopeStrategy()
+	grantedScope := originalRequest.GrantedScope
+	if requested := x.SplitSpace(req.Form.Get("scope")); len(requested) > 0 {
+		if err = scopeStrategy(originalRequest.GrantedScope, requested); err != nil {
+			return err
+		}
+
+		grantedScope = requested
+	}
+
+	if err = scopeStrategy(client.GetScopes(), grantedScope); err != nil {
+		return err
+	}
+
+	audienceStrategy := h.config.GetAudienceStrategy()
+	if err = audienceStrategy(client.GetAudience(), originalRequest.GrantedAudience); err != nil {
+		return err
+	}
+
+	// keep the request ID so every token issued from the same grant belongs to the same family
+	req.ID = originalRequest.ID
+	req.Session = originalRequest.Session
+	req.RequestedScope = grantedScope
+	req.GrantedScope = grantedScope
+	req.RequestedAudience = originalRequest.RequestedAudience
+	req.GrantedAudience = originalRequest.GrantedAudience
+
+	req.Session.SetExpiresAt(core.AccessToken, x.NowUTC().Add(h.config.GetAccessTokenLifetime()))
+
+	refreshTokenLifetime := h.config.GetRefreshTokenLifetime()
+	if refreshTokenLifetime > -1 {
+		req.Session.SetExpiresAt(core.RefreshToken, x.NowUTC().Add(refreshTokenLifetime))
+	}
+
+	return nil
+}
+
+func (h *RefreshTokenGrantHandler) HandleTokenResponse(
+	ctx context.Context,
+	req *core.TokenRequest,
+	res *core.TokenResponse,
+) (err error) {
+	if !req.GrantType.ExactOne(string(core.GrantTypeRefreshToken)) {
+		return core.ErrUnknownRequest
+	}
+
+	accessToken, accessSignature, err := h.tokenStrategy.GenerateAccessToken(ctx, &req.Request)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	refreshToken, refreshSignature, err := h.tokenStrategy.GenerateRefreshToken(ctx, &req.Request)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	outerCtx := ctx
+	ctx, err = storage.TryBeginTX(ctx, h.tokenStorage)
+	if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+	defer func() {
+		if err != nil {
+			rollbackErr := storage.TryRollback(ctx, h.tokenStorage)
+			if rollbackErr != nil {
+				err = core.ErrServerError.WithWrap(rollbackErr).WithDebug("error: %s; rollback error: %s", err, rollbackErr)
+			}
+		}
+	}()
+
+	oldSignature := h.tokenStrategy.RefreshTokenSignature(ctx, req.Form.Get("refresh_token"))
+	if err = h.tokenStorage.RotateRefreshToken(ctx, req.ID, oldSignature); stderr.Is(err, core.ErrInactiveToken) {
+		// A concurrent request rotated the refresh token first, so this one is a replay. The family is revoked outside
+		// of the transaction, which is rolled back when the error is returned.
+		return h.revokeTokenFamily(outerCtx, req.ID)
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	// the form is stored with the session, make sure the old refresh token does not end up in the database
+	storedRequest := req.Sanitize()
+
+	if err = h.tokenStorage.CreateAccessTokenSession(ctx, accessSignature, storedRequest); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = h.tokenStorage.CreateRefreshTokenSession(ctx, refreshSignature, accessSignature, storedRequest); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err = storage.TryCommit(ctx, h.tokenStorage); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	accessTokenLifetime := h.config.GetAccessTokenLifetime()
+	if req.Session.GetExpiresAt(core.AccessToken).IsZero() {
+		res.ExpiresIn = time.Duration(accessTokenLifetime.Seconds())
+	} else {
+		res.ExpiresIn = x.SecondsFromNow(req.Session.GetExpiresAt(core.AccessToken))
+	}
+
+	res.AccessToken = accessToken
+	res.RefreshToken = refreshToken
+	res.TokenType = req.AccessTokenType()
+	res.Scope = strings.Join(req.GrantedScope, " ")
+	return nil
+}
+
+func (h *RefreshTokenGrantHandler) revokeTokenFamily(ctx context.Context, requestID string) error {
+	hint := "The refresh token has already been used."
+	debug := ""
+
+	if re := h.tokenStorage.RevokeAccessToken(ctx, requestID); re != nil {
+		hint += " Additionally, an error occurred during processing the access token revocation."
+		debug += "Revocation of access_token lead to error " + re.Error() + "."
+	}
+
+	if re := h.tokenStorage.RevokeRefreshToken(ctx, requestID); re != nil {
+		hint += " Additionally, an error occurred during processing the refresh token revocation."
+		debug += "Revocation of refresh_token lead to error " + re.Error() + "."
+	}
+
+	return core.ErrInvalidGrant.WithHint("%s", hint).WithDebug("%s", debug)
+}
@@ -0,0 +1,247 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/hmac"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+)
+
+type refreshTestSetup struct {
+	handler  *RefreshTokenGrantHandler
+	strategy *HMACStrategy
+	storage  *memoryStorage
+	client   *testClient
+}
+
+func newRefreshTestSetup(t *testing.T) *refreshTestSetup {
+	cfg := newTestConfig()
+	tokenStrategy := newTestHMACStrategy(t, cfg)
+	storage := newMemoryStorage()
+
+	return &refreshTestSetup{
+		handler:  NewRefreshTokenGrantHandler(cfg, tokenStrategy, storage),
+		strategy: tokenStrategy,
+		storage:  storage,
+		client: &testClient{
+			id:         "refresh-client",
+			grantTypes: []string{string(core.GrantTypeAuthorizationCode), string(core.GrantTypeRefreshToken)},
+			scopes:     []string{"openid", "offline_access", "profile"},
+		},
+	}
+}
+
+// issue stores a refresh token as if it was issued by the authorization code grant.
+func (s *refreshTestSetup) issue(t *testing.T, scope ...string) (string, *core.Request) {
+	req := core.NewRequest()
+	req.Client = s.client
+	req.Session = newTestSession("alice")
+	req.GrantedScope = scope
+
+	token, signature, err := s.strategy.GenerateRefreshToken(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateRefreshTokenSession(context.Background(), signature, "", req))
+	return token, req
+}
+
+func (s *refreshTestSetup) tokenRequest(refreshToken string) *core.TokenRequest {
+	req := core.NewTokenRequest(newTestSession(""))
+	req.Client = s.client
+	req.GrantType = core.Arguments{string(core.GrantTypeRefreshToken)}
+	req.Form.Set("grant_type", string(core.GrantTypeRefreshToken))
+	req.Form.Set("refresh_token", refreshToken)
+	return req
+}
+
+func (s *refreshTestSetup) refresh(t *testing.T, refreshToken string) (*core.TokenResponse, error) {
+	ctx := context.Background()
+	req := s.tokenRequest(refreshToken)
+	if err := s.handler.HandleTokenRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	res := core.NewTokenResponse()
+	return res, s.handler.HandleTokenResponse(ctx, req, res)
+}
+
+func (s *refreshTestSetup) isActive(refreshToken string) bool {
+	stored, ok := s.storage.refreshTokens[s.strategy.RefreshTokenSignature(context.Background(), refreshToken)]
+	return ok && stored.active
+}
+
+func TestRefreshTokenGrant_Rotates(t *testing.T) {
+	s := newRefreshTestSetup(t)
+	token, original := s.issue(t, "openid", "offline_access")
+
+	res, err := s.refresh(t, token)
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEqual(t, token, res.RefreshToken)
+	assert.Equal(t, "openid offline_access", res.Scope)
+
+	assert.False(t, s.isActive(token))
+	assert.True(t, s.isActive(res.RefreshToken))
+
+	// the new tokens belong to the same family
+	stored := s.storage.refreshTokens[s.strategy.RefreshTokenSignature(context.Background(), res.RefreshToken)]
+	assert.Equal(t, original.ID, stored.request.ID)
+}
+
+func TestRefreshTokenGrant_ReplayRevokesFamily(t *testing.T) {
+	s := newRefreshTestSetup(t)
+	token, _ := s.issue(t, "openid", "offline_access")
+
+	res, err := s.refresh(t, token)
+	require.NoError(t, err)
+
+	_, err = s.refresh(t, token)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+	assert.False(t, s.isActive(res.RefreshToken))
+	assert.Empty(t, s.storage.accessTokens)
+}
+
+func TestRefreshTokenGrant_ConcurrentRefreshRevokesFamily(t *testing.T) {
+	s := newRefreshTestSetup(t)
+	token, _ := s.issue(t, "openid", "offline_access")
+
+	// both requests pass the validation before either of them rotates the refresh token
+	ctx := context.Background()
+	first, second := s.tokenRequest(token), s.tokenRequest(token)
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, first))
+	require.NoError(t, s.handler.HandleTokenRequest(ctx, second))
+
+	firstRes := core.NewTokenResponse()
+	require.NoError(t, s.handler.HandleTokenResponse(ctx, first, firstRes))
+
+	secondRes := core.NewTokenResponse()
+	err := s.handler.HandleTokenResponse(ctx, second, secondRes)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+	assert.Empty(t, secondRes.RefreshToken)
+
+	// the refresh token issued to the first request belongs to the replayed family
+	assert.False(t, s.isActive(firstRes.RefreshToken))
+	assert.Empty(t, s.storage.accessTokens)
+}
+
+func TestRefreshTokenGrant_Scope(t *testing.T) {
+	cases := []struct {
+		name    string
+		granted []string
+		scope   string
+		want    string
+		wantErr error
+	}{
+		{name: "keeps the granted scope", granted: []string{"openid", "offline_access"}, want: "openid offline_access"},
+		{name: "narrows the scope", granted: []string{"openid", "offline_access", "profile"}, scope: "offline_access", want: "offline_access"},
+		{name: "can not widen the scope", granted: []string{"openid", "offline_access"}, scope: "offline_access profile", wantErr: core.ErrInvalidScope},
+		{name: "requires the offline scope", granted: []string{"openid"}, wantErr: core.ErrInvalidScope},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newRefreshTestSetup(t)
+			token, _ := s.issue(t, tc.granted...)
+
+			req := s.tokenRequest(token)
+			req.Form.Set("scope", tc.scope)
+			err := s.handler.HandleTokenRequest(context.Background(), req)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, strings.Join(req.GrantedScope, " "))
+		})
+	}
+}
+
+func TestRefreshTokenGrant_RejectsOtherClient(t *testing.T) {
+	s := newRefreshTestSetup(t)
+	token, _ := s.issue(t, "openid", "offline_access")
+
+	req := s.tokenRequest(token)
+	req.Client = &testClient{id: "other", grantTypes: s.client.grantTypes, scopes: s.client.scopes}
+	err := s.handler.HandleTokenRequest(context.Background(), req)
+	assert.ErrorIs(t, err, core.ErrInvalidGrant)
+}
+
+func TestRefreshTokenGrant_CustomClaims(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig()
+	cfg.topLevelClaims = []string{"tenant", "sub", "scope", "client_id", "cnf", "ext"}
+
+	hmacSigner, err := hmac.NewSigner(cfg)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwtSigner, err := jwt.NewSigner(cfg, func(ctx context.Context, kid ...string) (any, error) {
+		return &jose.JSONWebKey{Key: key, KeyID: "access-token", Algorithm: "ES256", Use: "sig"}, nil
+	})
+	require.NoError(t, err)
+
+	tokenStrategy := NewJWTStrategy(cfg, hmacSigner, jwtSigner)
+	storage := newMemoryStorage()
+	handler := NewRefreshTokenGrantHandler(cfg, tokenStrategy, storage)
+	client := &testClient{
+		id:         "refresh-client",
+		grantTypes: []string{string(core.GrantTypeRefreshToken)},
+		scopes:     []string{"openid", "offline_access"},
+	}
+
+	// the custom claims were set when the consent was accepted, the reserved ones must never reach the token
+	session := newTestSession("alice")
+	session.Extra = map[string]any{
+		"tenant":    "acme",
+		"sub":       "mallory",
+		"scope":     "admin",
+		"client_id": "other-client",
+		"cnf":       map[string]any{"jkt": "attacker"},
+		"ext":       "overridden",
+	}
+
+	original := core.NewRequest()
+	original.Client = client
+	original.Session = session
+	original.GrantedScope = []string{"openid", "offline_access"}
+	refreshToken, signature, err := tokenStrategy.GenerateRefreshToken(ctx, original)
+	require.NoError(t, err)
+	require.NoError(t, storage.CreateRefreshTokenSession(ctx, signature, "", original))
+
+	// the claims are checked on the token issued by the refresh and on the one issued by refreshing it again
+	for range 2 {
+		req := core.NewTokenRequest(newTestSession(""))
+		req.Client = client
+		req.GrantType = core.Arguments{string(core.GrantTypeRefreshToken)}
+		req.Form.Set("refresh_token", refreshToken)
+		require.NoError(t, handler.HandleTokenRequest(ctx, req))
+
+		res := core.NewTokenResponse()
+		require.NoError(t, handler.HandleTokenResponse(ctx, req, res))
+		refreshToken = res.RefreshToken
+
+		claims := gojwt.MapClaims{}
+		require.NoError(t, jwtSigner.Decode(ctx, res.AccessToken, claims))
+
+		assert.Equal(t, "acme", claims["tenant"])
+		assert.Equal(t, "alice", claims["sub"])
+		assert.Equal(t, "openid offline_access", claims["scope"])
+		assert.Equal(t, "refresh-client", claims["client_id"])
+		assert.NotContains(t, claims, "cnf")
+
+		ext, ok := claims["ext"].(map[string]any)
+		require.True(t, ok, "ext must keep the custom claims, got %v", claims["ext"])
+		assert.Equal(t, "acme", ext["tenant"])
+	}
+}
@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"context"
+	stderr "errors"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/strategy"
+)
+
+type TokenRevocationStorage interface {
+	storage.AccessTokenStorage
+	storage.RefreshTokenStorage
+}
+
+// TokenRevocationHandler revokes access and refresh tokens as described in RFC 7009. Revoking any token of a grant
+// revokes every access and refresh token that was issued under the same request ID.
+type TokenRevocationHandler struct {
+	tokenStrategy strategy.TokenStrategy
+	tokenStorage  TokenRevocationStorage
+}
+
+func NewTokenRevocationHandler(
+	tokenStrategy strategy.TokenStrategy,
+	tokenStorage TokenRevocationStorage,
+) *TokenRevocationHandler {
+	return &TokenRevocationHandler{
+		tokenStrategy: tokenStrategy,
+		tokenStorage:  tokenStorage,
+	}
+}
+
+func (h *TokenRevocationHandler) RevokeToken(ctx context.Context, rr *core.RevocationRequest) error {
+	lookups := []func(context.Context, string) (*core.Request, error){h.findAccessToken, h.findRefreshToken}
+	if rr.TokenTypeHint == core.RefreshToken {
+		lookups = []func(context.Context, string) (*core.Request, error){h.findRefreshToken, h.findAccessToken}
+	}
+
+	var found *core.Request
+	for _, lookup := range lookups {
+		req, err := lookup(ctx, rr.Token)
+		if stderr.Is(err, core.ErrNotFound) || stderr.Is(err, core.ErrInactiveToken) {
+			continue
+		} else if err != nil {
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+		}
+
+		found = req
+		break
+	}
+
+	if found == nil {
+		// invalid or unknown tokens do not cause an error response, see RFC 7009 section 2.2
+		return nil
+	}
+
+	if found.Client.GetID() != rr.Client.GetID() {
+		return core.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to revoke a token that was issued to another client.")
+	}
+
+	if err := h.tokenStorage.RevokeRefreshToken(ctx, found.ID); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if err := h.tokenStorage.RevokeAccessToken(ctx, found.ID); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	return nil
+}
+
+func (h *TokenRevocationHandler) findAccessToken(ctx context.Context, token string) (*core.Request, error) {
+	signature := h.tokenStrategy.AccessTokenSignature(ctx, token)
+	if signature == "" {
+		return nil, core.ErrNotFound
+	}
+
+	return h.tokenStorage.GetAccessTokenSession(ctx, signature, nil)
+}
+
+func (h *TokenRevocationHandler) findRefreshToken(ctx context.Context, token string) (*core.Request, error) {
+	signature := h.tokenStrategy.RefreshTokenSignature(ctx, token)
+	if signature == "" {
+		return nil, core.ErrNotFound
+	}
+
+	return h.tokenStorage.GetRefreshTokenSession(ctx, signature, nil)
+}
@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+)
+
+type revocationTestSetup struct {
+	handler  *TokenRevocationHandler
+	strategy *HMACStrategy
+	storage  *memoryStorage
+	client   *testClient
+}
+
+func newRevocationTestSetup(t *testing.T) *revocationTestSetup {
+	cfg := newTestConfig()
+	tokenStrategy := newTestHMACStrategy(t, cfg)
+	storage := newMemoryStorage()
+
+	return &revocationTestSetup{
+		handler:  NewTokenRevocationHandler(tokenStrategy, storage),
+		strategy: tokenStrategy,
+		storage:  storage,
+		client:   &testClient{id: "revoke-client"},
+	}
+}
+
+// issue stores an access and refresh token pair of the same grant.
+func (s *revocationTestSetup) issue(t *testing.T) (accessToken, refreshToken string) {
+	ctx := context.Background()
+	req := core.NewRequest()
+	req.Client = s.client
+	req.Session = newTestSession("alice")
+
+	accessToken, accessSignature, err := s.strategy.GenerateAccessToken(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateAccessTokenSession(ctx, accessSignature, req))
+
+	refreshToken, refreshSignature, err := s.strategy.GenerateRefreshToken(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateRefreshTokenSession(ctx, refreshSignature, accessSignature, req))
+	return accessToken, refreshToken
+}
+
+func (s *revocationTestSetup) revoke(token string, hint core.TokenType, client core.Client) error {
+	return s.handler.RevokeToken(context.Background(), &core.RevocationRequest{
+		Token:         token,
+		TokenTypeHint: hint,
+		Client:        client,
+	})
+}
+
+func (s *revocationTestSetup) isRevoked(t *testing.T, accessToken, refreshToken string) bool {
+	ctx := context.Background()
+	_, accessFound := s.storage.accessTokens[s.strategy.AccessTokenSignature(ctx, accessToken)]
+	refresh, ok := s.storage.refreshTokens[s.strategy.RefreshTokenSignature(ctx, refreshToken)]
+	require.True(t, ok)
+	return !accessFound && !refresh.active
+}
+
+func TestTokenRevocation_RevokesGrant(t *testing.T) {
+	cases := []struct {
+		name    string
+		refresh bool
+		hint    core.TokenType
+	}{
+		{name: "access token without hint"},
+		{name: "access token with access_token hint", hint: core.AccessToken},
+		{name: "access token with refresh_token hint", hint: core.RefreshToken},
+		{name: "refresh token without hint", refresh: true},
+		{name: "refresh token with refresh_token hint", refresh: true, hint: core.RefreshToken},
+		{name: "refresh token with access_token hint", refresh: true, hint: core.AccessToken},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newRevocationTestSetup(t)
+			accessToken, refreshToken := s.issue(t)
+
+			// a wrong hint only changes the lookup order
+			token := accessToken
+			if tc.refresh {
+				token = refreshToken
+			}
+
+			require.NoError(t, s.revoke(token, tc.hint, s.client))
+			assert.True(t, s.isRevoked(t, accessToken, refreshToken))
+		})
+	}
+}
+
+func TestTokenRevocation_KeepsOtherGrants(t *testing.T) {
+	s := newRevocationTestSetup(t)
+	_, refreshToken := s.issue(t)
+	otherAccess, otherRefresh := s.issue(t)
+
+	require.NoError(t, s.revoke(refreshToken, core.RefreshToken, s.client))
+	assert.False(t, s.isRevoked(t, otherAccess, otherRefresh))
+}
+
+func TestTokenRevocation_UnknownToken(t *testing.T) {
+	cases := []struct {
+		name  string
+		token func(t *testing.T, s *revocationTestSetup) string
+	}{
+		{
+			name:  "malformed token",
+			token: func(t *testing.T, s *revocationTestSetup) string { return "not-a-token" },
+		},
+		{
+			name: "token issued by another server",
+			token: func(t *testing.T, s *revocationTestSetup) string {
+				other := newTestConfig()
+				other.secret = []byte(strings.Repeat("o", 64))
+				token, _, _ := newTestHMACStrategy(t, other).GenerateAccessToken(context.Background(), core.NewRequest())
+				return token
+			},
+		},
+		{
+			name: "revoked refresh token",
+			token: func(t *testing.T, s *revocationTestSetup) string {
+				_, refreshToken := s.issue(t)
+				require.NoError(t, s.revoke(refreshToken, core.RefreshToken, s.client))
+				return refreshToken
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newRevocationTestSetup(t)
+			accessToken, refreshToken := s.issue(t)
+
+			// unknown tokens get the same response as revoked ones, see RFC 7009 section 2.2
+			assert.NoError(t, s.revoke(tc.token(t, s), "", s.client))
+			assert.False(t, s.isRevoked(t, accessToken, refreshToken))
+		})
+	}
+}
+
+func TestTokenRevocation_RejectsOtherClient(t *testing.T) {
+	for _, hint := range []core.TokenType{"", core.AccessToken, core.RefreshToken} {
+		t.Run(string(hint), func(t *testing.T) {
+			s := newRevocationTestSetup(t)
+			accessToken, refreshToken := s.issue(t)
+
+			err := s.revoke(accessToken, hint, &testClient{id: "other"})
+			assert.ErrorIs(t, err, core.ErrUnauthorizedClient)
+			err = s.revoke(refreshToken, hint, &testClient{id: "other"})
+			assert.ErrorIs(t, err, core.ErrUnauthorizedClient)
+
+			assert.False(t, s.isRevoked(t, accessToken, refreshToken))
+		})
+	}
+}
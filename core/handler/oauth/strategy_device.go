@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"strings"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+// userCodeCharset excludes vowels to avoid forming words, see RFC 8628 section 6.1.
+const userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+type DeviceStrategyConfigurator interface {
+	core.DeviceCodeLifetimeProvider
+	core.GlobalSecretProvider
+	core.RotatedSecretsProvider
+	core.HMACHashingProvider
+}
+
+// DeviceStrategy issues opaque device codes signed like the other HMAC tokens and short user codes that are meant to
+// be typed by the end-user. The user code is too short to carry a signature, so its HMAC is stored instead.
+type DeviceStrategy struct {
+	config DeviceStrategyConfigurator
+	hmac   strategy.OpaqueSigner
+}
+
+func NewDeviceStrategy(config DeviceStrategyConfigurator, hmac strategy.OpaqueSigner) *DeviceStrategy {
+	return &DeviceStrategy{
+		config: config,
+		hmac:   hmac,
+	}
+}
+
+func (ds *DeviceStrategy) DeviceCodeSignature(ctx context.Context, code string) string {
+	return ds.hmac.GetSignature(code)
+}
+
+func (ds *DeviceStrategy) GenerateDeviceCode(ctx context.Context, request *core.Request) (code string, signature string, err error) {
+	return ds.hmac.Generate(ctx, request)
+}
+
+func (ds *DeviceStrategy) ValidateDeviceCode(ctx context.Context, request *core.Request, code string) (err error) {
+	exp := request.Session.GetExpiresAt(core.DeviceCode)
+	if expiredAt := request.RequestedAt.Add(ds.config.GetDeviceCodeLifetime()); exp.IsZero() && expiredAt.Before(x.NowUTC()) {
+		return core.ErrDeviceExpiredToken.WithHint("Device code expired at '%s'.", expiredAt)
+	}
+
+	if !exp.IsZero() && exp.Before(x.NowUTC()) {
+		return core.ErrDeviceExpiredToken.WithHint("Device code expired at '%s'.", exp)
+	}
+
+	return ds.hmac.Validate(ctx, code)
+}
+
+// UserCodeSignature normalizes the code the end-user typed, so that case and separators do not matter.
+func (ds *DeviceStrategy) UserCodeSignature(ctx context.Context, code string) string {
+	return ds.userCodeSignature(code, ds.config.GetGlobalSecret())
+}
+
+// UserCodeSignatures returns the signatures of the user code under the global secret and then each rotated secret, as a
+// user code issued before a rotation is stored under the signature of the secret that was global at that time.
+func (ds *DeviceStrategy) UserCodeSignatures(ctx context.Context, code string) []string {
+	signatures := []string{ds.UserCodeSignature(ctx, code)}
+	for _, secret := range ds.config.GetRotatedSecrets() {
+		signatures = append(signatures, ds.userCodeSignature(code, secret))
+	}
+	return signatures
+}
+
+func (ds *DeviceStrategy) userCodeSignature(code string, secret []byte) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+
+	mac := hmac.New(ds.config.GetHMACHasher(), secret)
+	mac.Write([]byte(normalized))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (ds *DeviceStrategy) GenerateUserCode(ctx context.Context) (code string, signature string, err error) {
+	max := big.NewInt(int64(len(userCodeCharset)))
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", "", err
+		}
+		b[i] = userCodeCharset[n.Int64()]
+	}
+
+	code = string(b[:userCodeLength/2]) + "-" + string(b[userCodeLength/2:])
+	return code, ds.UserCodeSignature(ctx, code), nil
+}
@@ -11,13 +11,18 @@ import (
 	"github.com/tuanta7/hydros/core/x"
 )
 
+type JWTStrategyConfigurator interface {
+	core.AccessTokenIssuerProvider
+	core.AllowedTopLevelClaimsProvider
+}
+
 type JWTStrategy struct {
-	cfg  core.AccessTokenIssuerProvider
+	cfg  JWTStrategyConfigurator
 	hmac strategy.OpaqueSigner
 	jwt  strategy.JWTSigner
 }
 
-func NewJWTStrategy(cfg core.AccessTokenIssuerProvider, hmac strategy.OpaqueSigner, jwt strategy.JWTSigner) *JWTStrategy {
+func NewJWTStrategy(cfg JWTStrategyConfigurator, hmac strategy.OpaqueSigner, jwt strategy.JWTSigner) *JWTStrategy {
 	return &JWTStrategy{
 		cfg:  cfg,
 		hmac: hmac,
@@ -43,6 +48,26 @@ func (js *JWTStrategy) GenerateAccessToken(ctx context.Context, request *core.Re
 		Scope:    strings.Join(request.GrantedScope, " "),
 	}
 
+	if session, ok := request.Session.(ActorSession); ok {
+		claims.Actor = session.GetActor()
+	}
+
+	if session, ok := request.Session.(core.ConfirmationSession); ok {
+		if cnf := session.GetConfirmation(); cnf != nil && *cnf != (core.Confirmation{}) {
+			claims.Confirmation = cnf
+		}
+	}
+
+	if session, ok := request.Session.(core.ExtraClaimsSession); ok && len(session.GetExtraClaims()) > 0 {
+		claims.Extra = session.GetExtraClaims()
+		mapClaims, err := claims.ToMapClaims(js.cfg.GetAllowedTopLevelClaims())
+		if err != nil {
+			return "", "", err
+		}
+
+		return js.jwt.Generate(ctx, mapClaims)
+	}
+
 	return js.jwt.Generate(ctx, claims)
 }
 
@@ -59,6 +84,11 @@ func (js *JWTStrategy) GenerateRefreshToken(ctx context.Context, request *core.R
 }
 
 func (js *JWTStrategy) ValidateRefreshToken(ctx context.Context, request *core.Request, token string) (err error) {
+	exp := request.Session.GetExpiresAt(core.RefreshToken)
+	if !exp.IsZero() && exp.Before(x.NowUTC()) {
+		return core.ErrTokenExpired.WithHint("Refresh token expired at '%s'.", exp)
+	}
+
 	return js.hmac.Validate(ctx, token)
 }
 
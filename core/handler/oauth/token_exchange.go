@@ -0,0 +1,254 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// ActorSession is implemented by sessions that can carry the actor of a token obtained through token exchange.
+type ActorSession interface {
+	GetActor() *jwt.ActorClaims
+	SetActor(actor *jwt.ActorClaims)
+}
+
+type TokenExchangeGrantConfigurator interface {
+	core.AccessTokenLifetimeProvider
+	strategy.ScopeStrategyProvider
+	strategy.AudienceStrategyProvider
+}
+
+// TokenExchangeGrantHandler implements the RFC 8693 token exchange grant. The subject and actor tokens are validated
+// with the same introspection handlers that serve the introspection endpoint.
+type TokenExchangeGrantHandler struct {
+	config                TokenExchangeGrantConfigurator
+	accessTokenStrategy   strategy.AccessTokenStrategy
+	jwtStrategy           strategy.AccessTokenStrategy
+	accessTokenStorage    storage.AccessTokenStorage
+	introspectionHandlers []core.IntrospectionHandler
+}
+
+// NewTokenExchangeGrantHandler returns a new handler. The jwtStrategy issues the tokens requested with the
+// urn:ietf:params:oauth:token-type:jwt type, all other tokens are issued with the accessTokenStrategy. The jwt type is
+// rejected when jwtStrategy is nil.
+func NewTokenExchangeGrantHandler(
+	config TokenExchangeGrantConfigurator,
+	accessTokenStrategy strategy.AccessTokenStrategy,
+	jwtStrategy strategy.AccessTokenStrategy,
+	storage storage.AccessTokenStorage,
+	introspectionHandlers ...core.IntrospectionHandler,
+) *TokenExchangeGrantHandler {
+	return &TokenExchangeGrantHandler{
+		config:                config,
+		accessTokenStrategy:   accessTokenStrategy,
+		jwtStrategy:           jwtStrategy,
+		accessTokenStorage:    storage,
+		introspectionHandlers: introspectionHandlers,
+	}
+}
+
+func (h *TokenExchangeGrantHandler) GetGrantType() core.GrantType {
+	return core.GrantTypeTokenExchange
+}
+
+func (h *TokenExchangeGrantHandler) HandleTokenRequest(ctx context.Context, req *core.TokenRequest) error {
+	if !req.GrantType.ExactOne(string(core.GrantTypeTokenExchange)) {
+		return core.ErrUnknownRequest
+	}
+
+	client := req.Client
+	if client == nil {
+		// should never happen because this client must be authenticated to get here
+		return core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.")
+	}
+
+	if !client.GetGrantTypes().IncludeOne(string(core.GrantTypeTokenExchange)) {
+		return core.ErrUnauthorizedClient
+	}
+
+	if client.IsPublic() {
+		return core.ErrInvalidGrant.WithHint("Public clients are not allowed to exchange tokens.")
+	}
+
+	if req.Session == nil {
+		return errors.New("session cannot be nil")
+	}
+
+	switch req.Form.Get("requested_token_type") {
+	case "", TokenTypeAccessToken:
+	case TokenTypeJWT:
+		if h.jwtStrategy == nil {
+			return core.ErrInvalidRequest.WithHint("The requested token type '%s' is not supported.", TokenTypeJWT)
+		}
+	default:
+		return core.ErrInvalidRequest.WithHint("The requested token type '%s' is not supported.", req.Form.Get("requested_token_type"))
+	}
+
+	subjectToken := req.Form.Get("subject_token")
+	if subjectToken == "" {
+		return core.ErrInvalidRequest.WithHint("The 'subject_token' parameter is missing.")
+	}
+
+	subject, err := h.introspect(ctx, req.Session.Clone(), subjectToken, req.Form.Get("subject_token_type"), "subject_token")
+	if err != nil {
+		return err
+	}
+
+	// the subject token must have been issued to the client or be intended for it
+	if subject.Client.GetID() != client.GetID() && !subject.GrantedAudience.Include(client.GetID()) {
+		return core.ErrInvalidGrant.WithHint("The 'subject_token' was not issued to or intended for the client.")
+	}
+
+	requestedScope := core.Arguments(strings.Fields(req.Form.Get("scope")))
+	if len(requestedScope) == 0 {
+		requestedScope = subject.GrantedScope
+	}
+
+	scopeStrategy := h.config.GetScopeStrategy()
+	if err = scopeStrategy(subject.GrantedScope, requestedScope); err != nil {
+		return err
+	}
+
+	if err = scopeStrategy(client.GetScopes(), requestedScope); err != nil {
+		return err
+	}
+
+	audienceStrategy := h.config.GetAudienceStrategy()
+	if err = audienceStrategy(client.GetAudience(), req.RequestedAudience); err != nil {
+		return err
+	}
+
+	req.RequestedScope = requestedScope
+
+	if s, ok := req.Session.(core.JWTProfileSession); ok {
+		s.SetSubject(subject.Session.GetSubject())
+	}
+
+	if actorToken := req.Form.Get("actor_token"); actorToken != "" {
+		actor, err := h.introspect(ctx, req.Session.Clone(), actorToken, req.Form.Get("actor_token_type"), "actor_token")
+		if err != nil {
+			return err
+		}
+
+		// like the subject token, otherwise any token the client got hold of could name the actor
+		if actor.Client.GetID() != client.GetID() && !actor.GrantedAudience.Include(client.GetID()) {
+			return core.ErrInvalidGrant.WithHint("The 'actor_token' was not issued to or intended for the client.")
+		}
+
+		act := &jwt.ActorClaims{
+			Subject:  actor.Session.GetSubject(),
+			ClientID: actor.Client.GetID(),
+		}
+		if act.Subject == "" {
+			act.Subject = act.ClientID
+		}
+
+		if s, ok := subject.Session.(ActorSession); ok {
+			act.Actor = s.GetActor() // the subject token was obtained through token exchange too
+		}
+
+		s, ok := req.Session.(ActorSession)
+		if !ok {
+			return core.ErrServerError.WithDebug("The session does not support the actor claim.")
+		}
+		s.SetActor(act)
+	} else if req.Form.Get("actor_token_type") != "" {
+		return core.ErrInvalidRequest.WithHint("The 'actor_token_type' parameter must not be set without 'actor_token'.")
+	}
+
+	// the exchanged token never outlives the subject token
+	expiresAt := x.NowUTC().Add(h.config.GetAccessTokenLifetime())
+	if exp := subject.Session.GetExpiresAt(core.AccessToken); !exp.IsZero() && exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+
+	req.Session.SetExpiresAt(core.AccessToken, expiresAt)
+	return nil
+}
+
+func (h *TokenExchangeGrantHandler) HandleTokenResponse(
+	ctx context.Context,
+	req *core.TokenRequest,
+	res *core.TokenResponse,
+) error {
+	if !req.GrantType.ExactOne(string(core.GrantTypeTokenExchange)) {
+		return core.ErrUnknownRequest
+	}
+
+	tokenStrategy, issuedTokenType := h.accessTokenStrategy, TokenTypeAccessToken
+	if req.Form.Get("requested_token_type") == TokenTypeJWT {
+		if h.jwtStrategy == nil {
+			return core.ErrInvalidRequest.WithHint("The requested token type '%s' is not supported.", TokenTypeJWT)
+		}
+		tokenStrategy, issuedTokenType = h.jwtStrategy, TokenTypeJWT
+	}
+
+	token, signature, err := tokenStrategy.GenerateAccessToken(ctx, &req.Request)
+	if err != nil {
+		return err
+	}
+
+	err = h.accessTokenStorage.CreateAccessTokenSession(ctx, signature, &req.Request)
+	if err != nil {
+		return err
+	}
+
+	res.AccessToken = token
+	res.TokenType = req.AccessTokenType()
+	res.IssuedTokenType = issuedTokenType
+	res.ExpiresIn = x.SecondsFromNow(req.Session.GetExpiresAt(core.AccessToken))
+	res.Scope = strings.Join(req.GrantedScope, " ")
+	return nil
+}
+
+// introspect validates a subject or actor token with the introspection handlers. Only access tokens can be exchanged.
+func (h *TokenExchangeGrantHandler) introspect(
+	ctx context.Context,
+	session core.Session,
+	token, tokenType, parameter string,
+) (*core.TokenRequest, error) {
+	switch tokenType {
+	case TokenTypeAccessToken, TokenTypeJWT:
+	case "":
+		return nil, core.ErrInvalidRequest.WithHint("The '%s_type' parameter is missing.", parameter)
+	default:
+		return nil, core.ErrInvalidRequest.WithHint("The '%s_type' '%s' is not supported.", parameter, tokenType)
+	}
+
+	tr := core.NewTokenRequest(session)
+	ir := &core.IntrospectionRequest{
+		Token:         token,
+		TokenTypeHint: core.AccessToken,
+	}
+
+	handled := false
+	for _, ih := range h.introspectionHandlers {
+		tt, err := ih.IntrospectToken(ctx, ir, tr)
+		if errors.Is(err, core.ErrUnknownRequest) {
+			continue
+		} else if err != nil {
+			return nil, core.ErrInvalidRequest.WithHint("The '%s' is invalid, expired or revoked.", parameter).WithWrap(err).WithDebug("%s", err)
+		} else if tt != core.AccessToken {
+			return nil, core.ErrInvalidRequest.WithHint("The '%s' must be an access token.", parameter)
+		}
+
+		handled = true
+	}
+
+	if !handled || tr.Client == nil {
+		return nil, core.ErrInvalidRequest.WithHint("The '%s' is invalid, expired or revoked.", parameter)
+	}
+
+	return tr, nil
+}
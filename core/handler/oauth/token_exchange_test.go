@@ -0,0 +1,374 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type tokenExchangeTestSetup struct {
+	handler      *TokenExchangeGrantHandler
+	strategy     *HMACStrategy
+	storage      *memoryStorage
+	introspector *TokenIntrospectionHandler
+	client       *testClient
+}
+
+func newTokenExchangeTestSetup(t *testing.T) *tokenExchangeTestSetup {
+	cfg := newTestConfig()
+	tokenStrategy := newTestHMACStrategy(t, cfg)
+	storage := newMemoryStorage()
+	introspector := NewTokenIntrospectionHandler(cfg, tokenStrategy, storage)
+
+	return &tokenExchangeTestSetup{
+		handler:      NewTokenExchangeGrantHandler(cfg, tokenStrategy, nil, storage, introspector),
+		strategy:     tokenStrategy,
+		storage:      storage,
+		introspector: introspector,
+		client: &testClient{
+			id:         "exchange-client",
+			grantTypes: []string{string(core.GrantTypeTokenExchange)},
+			scopes:     []string{"read", "write"},
+			audience:   []string{"https://api.example.com"},
+		},
+	}
+}
+
+// issue stores an access token of subject issued to client, as if it was issued by another grant. The token is granted
+// the audience of the client.
+func (s *tokenExchangeTestSetup) issue(t *testing.T, client core.Client, subject string, exp time.Time, scope ...string) string {
+	req := core.NewRequest()
+	req.Client = client
+	req.Session = newTestSession(subject)
+	req.Session.SetExpiresAt(core.AccessToken, exp)
+	req.GrantedScope = scope
+	req.GrantedAudience = client.GetAudience()
+
+	token, signature, err := s.strategy.GenerateAccessToken(context.Background(), req)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateAccessTokenSession(context.Background(), signature, req))
+	return token
+}
+
+func (s *tokenExchangeTestSetup) tokenRequest(form map[string]string) *core.TokenRequest {
+	req := core.NewTokenRequest(newTestSession(""))
+	req.Client = s.client
+	req.GrantType = core.Arguments{string(core.GrantTypeTokenExchange)}
+	req.Form.Set("grant_type", string(core.GrantTypeTokenExchange))
+	for k, v := range form {
+		req.Form.Set(k, v)
+	}
+	return req
+}
+
+func (s *tokenExchangeTestSetup) exchange(t *testing.T, req *core.TokenRequest) (*core.TokenResponse, error) {
+	ctx := context.Background()
+	if err := s.handler.HandleTokenRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// the token endpoint grants the requested scope once every handler accepted the request
+	req.GrantedScope = req.RequestedScope
+	res := core.NewTokenResponse()
+	return res, s.handler.HandleTokenResponse(ctx, req, res)
+}
+
+// stubJWTStrategy issues tokens shaped like a JWT, it stands in for the JWT strategy of the jwt access token format.
+type stubJWTStrategy struct {
+	*HMACStrategy
+}
+
+func (s *stubJWTStrategy) GenerateAccessToken(ctx context.Context, request *core.Request) (string, string, error) {
+	token, signature, err := s.HMACStrategy.GenerateAccessToken(ctx, request)
+	return "header." + token, signature, err
+}
+
+func TestTokenExchangeGrant_Exchanges(t *testing.T) {
+	s := newTokenExchangeTestSetup(t)
+	subjectToken := s.issue(t, s.client, "alice", x.NowUTC().Add(time.Hour), "read", "write")
+
+	req := s.tokenRequest(map[string]string{
+		"subject_token":      subjectToken,
+		"subject_token_type": TokenTypeAccessToken,
+		"scope":              "read",
+	})
+	res, err := s.exchange(t, req)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEqual(t, subjectToken, res.AccessToken)
+	assert.Equal(t, TokenTypeAccessToken, res.IssuedTokenType)
+	assert.Equal(t, "read", res.Scope)
+	assert.Equal(t, "alice", req.Session.GetSubject())
+
+	// the exchanged token can be introspected like any other access token
+	tr := core.NewTokenRequest(newTestSession(""))
+	tt, err := s.introspector.IntrospectToken(context.Background(), &core.IntrospectionRequest{Token: res.AccessToken}, tr)
+	require.NoError(t, err)
+	assert.Equal(t, core.AccessToken, tt)
+	assert.Equal(t, "alice", tr.Session.GetSubject())
+}
+
+func TestTokenExchangeGrant_Validation(t *testing.T) {
+	other := &testClient{id: "other-client", grantTypes: []string{string(core.GrantTypeAuthorizationCode)}}
+	intended := &testClient{id: "api-client", audience: []string{"exchange-client"}}
+	exp := x.NowUTC().Add(time.Hour)
+
+	cases := []struct {
+		name    string
+		client  *testClient
+		issuer  *testClient
+		expired bool
+		form    map[string]string
+		wantErr error
+	}{
+		{
+			name:    "client without the grant type",
+			client:  &testClient{id: "exchange-client"},
+			wantErr: core.ErrUnauthorizedClient,
+		},
+		{
+			name:    "public client",
+			client:  &testClient{id: "exchange-client", public: true, grantTypes: []string{string(core.GrantTypeTokenExchange)}},
+			wantErr: core.ErrInvalidGrant,
+		},
+		{
+			name:    "unsupported requested token type",
+			form:    map[string]string{"requested_token_type": "urn:ietf:params:oauth:token-type:id_token"},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "jwt requested without a JWT strategy",
+			form:    map[string]string{"requested_token_type": TokenTypeJWT},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "missing subject token",
+			form:    map[string]string{"subject_token": ""},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "missing subject token type",
+			form:    map[string]string{"subject_token_type": ""},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "unsupported subject token type",
+			form:    map[string]string{"subject_token_type": "urn:ietf:params:oauth:token-type:refresh_token"},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "unknown subject token",
+			form:    map[string]string{"subject_token": "unknown.token"},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "expired subject token",
+			expired: true,
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "subject token of another client",
+			issuer:  other,
+			wantErr: core.ErrInvalidGrant,
+		},
+		{
+			name:   "subject token intended for the client",
+			issuer: intended,
+		},
+		{
+			name:    "scope wider than the subject token",
+			form:    map[string]string{"scope": "read write"},
+			wantErr: core.ErrInvalidScope,
+		},
+		{
+			name:    "audience not allowed for the client",
+			form:    map[string]string{"audience": "https://admin.example.com"},
+			wantErr: core.ErrInvalidRequest,
+		},
+		{
+			name:    "actor token type without actor token",
+			form:    map[string]string{"actor_token_type": TokenTypeAccessToken},
+			wantErr: core.ErrInvalidRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTokenExchangeTestSetup(t)
+			if tc.client != nil {
+				s.client = tc.client
+			}
+
+			issuer, tokenExp := s.client, exp
+			if tc.issuer != nil {
+				issuer = tc.issuer
+			}
+			if tc.expired {
+				tokenExp = x.NowUTC().Add(-time.Minute)
+			}
+
+			form := map[string]string{
+				"subject_token":      s.issue(t, issuer, "alice", tokenExp, "read"),
+				"subject_token_type": TokenTypeAccessToken,
+			}
+			for k, v := range tc.form {
+				form[k] = v
+			}
+
+			req := s.tokenRequest(form)
+			if audience := form["audience"]; audience != "" {
+				req.RequestedAudience = core.Arguments{audience}
+			}
+
+			_, err := s.exchange(t, req)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTokenExchangeGrant_NeverOutlivesSubjectToken(t *testing.T) {
+	s := newTokenExchangeTestSetup(t)
+	exp := x.NowUTC().Add(5 * time.Minute).Truncate(time.Second)
+	subjectToken := s.issue(t, s.client, "alice", exp, "read")
+
+	req := s.tokenRequest(map[string]string{"subject_token": subjectToken, "subject_token_type": TokenTypeAccessToken})
+	res, err := s.exchange(t, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, exp, req.Session.GetExpiresAt(core.AccessToken))
+	assert.LessOrEqual(t, res.ExpiresIn, int64(5*time.Minute/time.Second))
+}
+
+func TestTokenExchangeGrant_Actor(t *testing.T) {
+	s := newTokenExchangeTestSetup(t)
+	actorClient := &testClient{id: "actor-client", audience: []string{"exchange-client"}}
+
+	subjectToken := s.issue(t, s.client, "alice", x.NowUTC().Add(time.Hour), "read")
+	actorToken := s.issue(t, actorClient, "", x.NowUTC().Add(time.Hour))
+
+	req := s.tokenRequest(map[string]string{
+		"subject_token":      subjectToken,
+		"subject_token_type": TokenTypeAccessToken,
+		"actor_token":        actorToken,
+		"actor_token_type":   TokenTypeAccessToken,
+	})
+	_, err := s.exchange(t, req)
+	require.NoError(t, err)
+
+	// the actor has no end-user, it is identified by its client
+	actor := req.Session.(*testSession).Actor
+	require.NotNil(t, actor)
+	assert.Equal(t, "actor-client", actor.Subject)
+	assert.Equal(t, "actor-client", actor.ClientID)
+	assert.Nil(t, actor.Actor)
+}
+
+func TestTokenExchangeGrant_ActorTokenBinding(t *testing.T) {
+	cases := []struct {
+		name    string
+		issuer  *testClient
+		wantErr error
+	}{
+		{
+			name:   "actor token issued to the client",
+			issuer: &testClient{id: "exchange-client"},
+		},
+		{
+			name:   "actor token intended for the client",
+			issuer: &testClient{id: "actor-client", audience: []string{"exchange-client"}},
+		},
+		{
+			name:    "actor token of another client",
+			issuer:  &testClient{id: "other-client"},
+			wantErr: core.ErrInvalidGrant,
+		},
+		{
+			name:    "actor token intended for another client",
+			issuer:  &testClient{id: "other-client", audience: []string{"https://api.example.com"}},
+			wantErr: core.ErrInvalidGrant,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTokenExchangeTestSetup(t)
+			req := s.tokenRequest(map[string]string{
+				"subject_token":      s.issue(t, s.client, "alice", x.NowUTC().Add(time.Hour), "read"),
+				"subject_token_type": TokenTypeAccessToken,
+				"actor_token":        s.issue(t, tc.issuer, "mallory", x.NowUTC().Add(time.Hour)),
+				"actor_token_type":   TokenTypeAccessToken,
+			})
+
+			_, err := s.exchange(t, req)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Nil(t, req.Session.(*testSession).Actor)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "mallory", req.Session.(*testSession).Actor.Subject)
+		})
+	}
+}
+
+func TestTokenExchangeGrant_NestsPriorActor(t *testing.T) {
+	s := newTokenExchangeTestSetup(t)
+	actorClient := &testClient{id: "actor-client", audience: []string{"exchange-client"}}
+
+	// the subject token was itself obtained through token exchange by a prior actor
+	subject := core.NewRequest()
+	subject.Client = s.client
+	session := newTestSession("alice")
+	session.SetActor(&jwt.ActorClaims{Subject: "prior-client", ClientID: "prior-client"})
+	subject.Session = session
+	subject.GrantedScope = core.Arguments{"read"}
+	subjectToken, signature, err := s.strategy.GenerateAccessToken(context.Background(), subject)
+	require.NoError(t, err)
+	require.NoError(t, s.storage.CreateAccessTokenSession(context.Background(), signature, subject))
+
+	req := s.tokenRequest(map[string]string{
+		"subject_token":      subjectToken,
+		"subject_token_type": TokenTypeAccessToken,
+		"actor_token":        s.issue(t, actorClient, "bob", x.NowUTC().Add(time.Hour)),
+		"actor_token_type":   TokenTypeAccessToken,
+	})
+	_, err = s.exchange(t, req)
+	require.NoError(t, err)
+
+	actor := req.Session.(*testSession).Actor
+	require.NotNil(t, actor)
+	assert.Equal(t, "bob", actor.Subject)
+	assert.Equal(t, "actor-client", actor.ClientID)
+	require.NotNil(t, actor.Actor)
+	assert.Equal(t, "prior-client", actor.Actor.Subject)
+}
+
+func TestTokenExchangeGrant_RequestedJWT(t *testing.T) {
+	s := newTokenExchangeTestSetup(t)
+	s.handler.jwtStrategy = &stubJWTStrategy{HMACStrategy: s.strategy}
+	subjectToken := s.issue(t, s.client, "alice", x.NowUTC().Add(time.Hour), "read")
+
+	req := s.tokenRequest(map[string]string{
+		"subject_token":        subjectToken,
+		"subject_token_type":   TokenTypeAccessToken,
+		"requested_token_type": TokenTypeJWT,
+	})
+	res, err := s.exchange(t, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, TokenTypeJWT, res.IssuedTokenType)
+	assert.True(t, strings.HasPrefix(res.AccessToken, "header."))
+}
@@ -56,29 +56,6 @@ func (h *OpenIDConnectAuthorizationCodeFlowHandler) HandleAuthorizeRequest(ctx c
 		req.MaxAge = maxAge
 	}
 
-	requestURI := req.Form.Get("request_uri")
-	request := req.Form.Get("request")
-	if request == "" && requestURI == "" {
-		return nil
-	}
-
-	if request != "" && requestURI != "" {
-		return core.ErrInvalidRequest.WithHint("OpenID Connect parameters 'request' and 'request_uri' were both given, but you can use at most one.")
-	}
-
-	// TODO: support JWT Request Object
-	oidcClient, ok := req.Client.(core.OpenIDConnectClient)
-	if !ok {
-		if requestURI != "" {
-			return core.ErrRequestNotSupported.WithHint("OpenID Connect 'request_uri' context was given, but the  OAuth 2.0 Client does not implement advanced OpenID Connect capabilities.")
-		}
-		return core.ErrRequestNotSupported.WithHint("OpenID Connect 'request' context was given, but the  OAuth 2.0 Client does not implement advanced OpenID Connect capabilities.")
-	}
-
-	if oidcClient.GetJWKs() == nil || oidcClient.GetJWKsURI() == "" {
-		return core.ErrInvalidRequest.WithHint("OpenID Connect 'request' or 'request_uri' context was given, but the OAuth 2.0 Client does not have any JSON Web Keys registered.")
-	}
-
 	return nil
 }
 
@@ -115,7 +92,7 @@ func (h *OpenIDConnectAuthorizationCodeFlowHandler) HandleAuthorizeResponse(
 		"id_token_hint",
 		"nonce",
 	)); err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	return nil
@@ -128,9 +105,9 @@ func (h *OpenIDConnectAuthorizationCodeFlowHandler) HandleTokenRequest(ctx conte
 
 	authorizeRequest, err := h.storage.GetOpenIDConnectSession(ctx, req.Code, req.Session)
 	if stderr.Is(err, core.ErrNotFound) {
-		return core.ErrUnknownRequest.WithWrap(err).WithDebug(err.Error())
+		return core.ErrUnknownRequest.WithWrap(err).WithDebug("%s", err)
 	} else if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	if !authorizeRequest.GrantedScope.IncludeAll("openid") {
@@ -147,7 +124,7 @@ func (h *OpenIDConnectAuthorizationCodeFlowHandler) HandleTokenRequest(ctx conte
 	}
 
 	if err = h.storage.DeleteOpenIDConnectSession(ctx, req.Code); err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	claims := sess.IDTokenClaims()
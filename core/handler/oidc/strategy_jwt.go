@@ -14,6 +14,7 @@ type IDTokenStrategyConfigurator interface {
 	core.IDTokenIssuerProvider
 	core.IDTokenLifetimeProvider
 	core.MinParameterEntropyProvider
+	core.JWKSFetcherProvider
 }
 
 type IDTokenStrategy struct {
@@ -65,6 +66,29 @@ func (i *IDTokenStrategy) GenerateIDToken(ctx context.Context, lifetime time.Dur
 	claims.Audience = append(claims.Audience, tr.Client.GetID())
 	claims.IssuedAt = gojwt.NewNumericDate(x.NowUTC())
 
-	token, _, err := i.JWTSigner.Generate(ctx, claims)
-	return token, err
+	// custom claims are set at the top level of ID tokens, see OpenID Connect Core 1.0 section 5.1.2
+	var jwtClaims gojwt.Claims = claims
+	if len(claims.Extra) > 0 {
+		mapClaims, err := claims.ToMapClaims()
+		if err != nil {
+			return "", err
+		}
+		jwtClaims = mapClaims
+	}
+
+	token, _, err := i.JWTSigner.Generate(ctx, jwtClaims)
+	if err != nil {
+		return "", err
+	}
+
+	// the ID token is signed first and then encrypted, see OpenID Connect Core 1.0 section 10.2
+	client, ok := tr.Client.(core.IDTokenEncryptionClient)
+	if !ok || client.GetIDTokenEncryptedResponseAlg() == "" {
+		return token, nil
+	}
+
+	return core.EncryptForClient(ctx, i.cfg.GetJWKSFetcher(), tr.Client, []byte(token), true,
+		client.GetIDTokenEncryptedResponseAlg(),
+		client.GetIDTokenEncryptedResponseEnc(),
+	)
 }
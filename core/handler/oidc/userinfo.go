@@ -0,0 +1,39 @@
+package oidc
+
+import (
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+)
+
+// ScopeClaims maps the scopes defined in OpenID Connect Core 1.0 section 5.4 to the standard claims they release.
+var ScopeClaims = map[string][]string{
+	"profile": {
+		"name", "family_name", "given_name", "middle_name", "nickname", "preferred_username",
+		"profile", "picture", "website", "gender", "birthdate", "zoneinfo", "locale", "updated_at",
+	},
+	"email":   {"email", "email_verified"},
+	"address": {"address"},
+	"phone":   {"phone_number", "phone_number_verified"},
+}
+
+// UserinfoClaims returns the subject and the standard claims of the ID token claims that are released by the granted
+// scopes. Claims that were not granted are never part of the result, even if they are set on the session.
+func UserinfoClaims(grantedScope core.Arguments, claims *jwt.IDTokenClaims) map[string]any {
+	released := map[string]any{
+		"sub": claims.Subject,
+	}
+
+	for scope, names := range ScopeClaims {
+		if !grantedScope.IncludeOne(scope) {
+			continue
+		}
+
+		for _, name := range names {
+			if value, ok := claims.Extra[name]; ok {
+				released[name] = value
+			}
+		}
+	}
+
+	return released
+}
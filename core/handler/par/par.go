@@ -0,0 +1,113 @@
+package par
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderr "errors"
+	"net/url"
+	"strings"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type PushedAuthorizeConfigurator interface {
+	core.PushedAuthorizeRequestLifetimeProvider
+}
+
+// PushedAuthorizeHandler implements RFC 9126. It stores the validated parameters under a request_uri handle and
+// consumes the handle once the authorization response is issued, so the login and consent round-trip can still use
+// the handle while each handle yields at most one authorization code.
+type PushedAuthorizeHandler struct {
+	config  PushedAuthorizeConfigurator
+	storage storage.PARStorage
+}
+
+func NewPushedAuthorizeHandler(config PushedAuthorizeConfigurator, storage storage.PARStorage) *PushedAuthorizeHandler {
+	return &PushedAuthorizeHandler{
+		config:  config,
+		storage: storage,
+	}
+}
+
+func (h *PushedAuthorizeHandler) HandlePushedAuthorizeRequest(
+	ctx context.Context,
+	req *core.AuthorizeRequest,
+	res *core.PushedAuthorizeResponse,
+) error {
+	if req.Client == nil {
+		return core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.")
+	}
+
+	handle := make([]byte, 32)
+	if _, err := rand.Read(handle); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	requestURI := core.PushedAuthorizeRequestURIPrefix + base64.RawURLEncoding.EncodeToString(handle)
+	expiresAt := x.NowUTC().Add(h.config.GetPushedAuthorizeRequestLifetime())
+
+	// the client credentials are not authorization parameters and must not be stored
+	pushed := *req
+	pushed.Form = url.Values{}
+	for k, v := range req.Form {
+		switch k {
+		case "client_secret", "client_assertion", "client_assertion_type":
+		default:
+			pushed.Form[k] = v
+		}
+	}
+
+	if err := h.storage.CreatePARSession(ctx, requestURI, &pushed, expiresAt); err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	res.RequestURI = requestURI
+	res.ExpiresIn = x.SecondsFromNow(expiresAt)
+	return nil
+}
+
+func (h *PushedAuthorizeHandler) GetPushedAuthorizeRequest(ctx context.Context, requestURI string, client core.Client) (url.Values, error) {
+	if !strings.HasPrefix(requestURI, core.PushedAuthorizeRequestURIPrefix) {
+		return nil, core.ErrUnknownRequest
+	}
+
+	req, err := h.storage.GetPARSession(ctx, requestURI)
+	if stderr.Is(err, core.ErrNotFound) {
+		return nil, core.ErrInvalidRequestURI.WithHint("The 'request_uri' is invalid, expired or has already been used.")
+	} else if err != nil {
+		return nil, core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if req.Client.GetID() != client.GetID() {
+		return nil, core.ErrInvalidRequestURI.WithHint("The 'request_uri' was not issued to the client.")
+	}
+
+	return req.Form, nil
+}
+
+func (h *PushedAuthorizeHandler) HandleAuthorizeRequest(ctx context.Context, req *core.AuthorizeRequest) error {
+	return nil
+}
+
+func (h *PushedAuthorizeHandler) HandleAuthorizeResponse(
+	ctx context.Context,
+	req *core.AuthorizeRequest,
+	res *core.AuthorizeResponse,
+) error {
+	if req.PushedRequestURI == "" {
+		return nil
+	}
+
+	err := h.storage.DeletePARSession(ctx, req.PushedRequestURI)
+	if stderr.Is(err, core.ErrNotFound) {
+		// another authorization response consumed the handle in the meantime
+		return core.ErrInvalidRequestURI.WithHint("The 'request_uri' has already been used.")
+	} else if err != nil {
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	return nil
+}
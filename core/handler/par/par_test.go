@@ -0,0 +1,209 @@
+package par
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type testClient struct {
+	id string
+}
+
+func (c *testClient) GetID() string                         { return c.id }
+func (c *testClient) GetHashedSecret() []byte               { return nil }
+func (c *testClient) GetRedirectURIs() []string             { return nil }
+func (c *testClient) GetGrantTypes() core.Arguments         { return nil }
+func (c *testClient) GetResponseTypes() core.Arguments      { return nil }
+func (c *testClient) GetResponseModes() []core.ResponseMode { return nil }
+func (c *testClient) GetScopes() core.Arguments             { return nil }
+func (c *testClient) IsPublic() bool                        { return false }
+func (c *testClient) GetAudience() core.Arguments           { return nil }
+
+type testConfig struct {
+	lifetime time.Duration
+}
+
+func (c *testConfig) GetPushedAuthorizeRequestLifetime() time.Duration { return c.lifetime }
+
+type pushedRequest struct {
+	request   core.Request
+	expiresAt time.Time
+}
+
+// memoryStorage follows the contract of the postgres storage, expired handles are reported as not found.
+type memoryStorage struct {
+	requests map[string]*pushedRequest
+}
+
+func (m *memoryStorage) CreatePARSession(ctx context.Context, requestURI string, req *core.AuthorizeRequest, expiresAt time.Time) error {
+	m.requests[requestURI] = &pushedRequest{request: req.Request, expiresAt: expiresAt}
+	return nil
+}
+
+func (m *memoryStorage) GetPARSession(ctx context.Context, requestURI string) (*core.Request, error) {
+	pushed, ok := m.requests[requestURI]
+	if !ok || pushed.expiresAt.Before(x.NowUTC()) {
+		return nil, core.ErrNotFound
+	}
+
+	req := pushed.request
+	return &req, nil
+}
+
+func (m *memoryStorage) DeletePARSession(ctx context.Context, requestURI string) error {
+	if _, ok := m.requests[requestURI]; !ok {
+		return core.ErrNotFound
+	}
+
+	delete(m.requests, requestURI)
+	return nil
+}
+
+func newTestHandler() (*PushedAuthorizeHandler, *memoryStorage, *testConfig) {
+	cfg := &testConfig{lifetime: time.Minute}
+	storage := &memoryStorage{requests: map[string]*pushedRequest{}}
+	return NewPushedAuthorizeHandler(cfg, storage), storage, cfg
+}
+
+func push(t *testing.T, h *PushedAuthorizeHandler, client core.Client, form url.Values) *core.PushedAuthorizeResponse {
+	req := core.NewAuthorizeRequest()
+	req.Client = client
+	req.Form = form
+
+	res := &core.PushedAuthorizeResponse{}
+	require.NoError(t, h.HandlePushedAuthorizeRequest(context.Background(), req, res))
+	return res
+}
+
+func TestPushedAuthorizeHandler_Push(t *testing.T) {
+	h, storage, _ := newTestHandler()
+	client := &testClient{id: "client"}
+
+	res := push(t, h, client, url.Values{
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"client_secret":         {"secret"},
+		"client_assertion":      {"assertion"},
+		"client_assertion_type": {core.ClientAssertionJWTType},
+	})
+
+	assert.True(t, strings.HasPrefix(res.RequestURI, core.PushedAuthorizeRequestURIPrefix))
+	// expires_in is serialized as a number of seconds
+	assert.InDelta(t, 60, int64(res.ExpiresIn), 1)
+
+	// the client credentials are not stored with the authorization parameters
+	form, err := h.GetPushedAuthorizeRequest(context.Background(), res.RequestURI, client)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"response_type": {"code"}, "scope": {"openid"}}, form)
+	assert.Len(t, storage.requests, 1)
+
+	// every push gets its own handle
+	other := push(t, h, client, url.Values{"response_type": {"code"}})
+	assert.NotEqual(t, res.RequestURI, other.RequestURI)
+}
+
+func TestPushedAuthorizeHandler_PushWithoutClient(t *testing.T) {
+	h, _, _ := newTestHandler()
+
+	err := h.HandlePushedAuthorizeRequest(context.Background(), core.NewAuthorizeRequest(), &core.PushedAuthorizeResponse{})
+	assert.ErrorIs(t, err, core.ErrInvalidClient)
+}
+
+func TestPushedAuthorizeHandler_GetPushedAuthorizeRequest(t *testing.T) {
+	client := &testClient{id: "client"}
+
+	cases := []struct {
+		name       string
+		requestURI func(pushed string) string
+		client     core.Client
+		expire     bool
+		wantErr    error
+	}{
+		{
+			name:       "request_uri of the client",
+			requestURI: func(pushed string) string { return pushed },
+			client:     client,
+		},
+		{
+			name:       "request_uri not issued by this handler",
+			requestURI: func(pushed string) string { return "https://client.example.com/request.jwt" },
+			client:     client,
+			wantErr:    core.ErrUnknownRequest,
+		},
+		{
+			name:       "unknown request_uri",
+			requestURI: func(pushed string) string { return core.PushedAuthorizeRequestURIPrefix + "unknown" },
+			client:     client,
+			wantErr:    core.ErrInvalidRequestURI,
+		},
+		{
+			name:       "request_uri of another client",
+			requestURI: func(pushed string) string { return pushed },
+			client:     &testClient{id: "other"},
+			wantErr:    core.ErrInvalidRequestURI,
+		},
+		{
+			name:       "expired request_uri",
+			requestURI: func(pushed string) string { return pushed },
+			client:     client,
+			expire:     true,
+			wantErr:    core.ErrInvalidRequestURI,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _, cfg := newTestHandler()
+			if tc.expire {
+				cfg.lifetime = -time.Second
+			}
+
+			res := push(t, h, client, url.Values{"response_type": {"code"}})
+			form, err := h.GetPushedAuthorizeRequest(context.Background(), tc.requestURI(res.RequestURI), tc.client)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "code", form.Get("response_type"))
+		})
+	}
+}
+
+func TestPushedAuthorizeHandler_ConsumesHandleOnce(t *testing.T) {
+	ctx := context.Background()
+	h, _, _ := newTestHandler()
+	client := &testClient{id: "client"}
+	res := push(t, h, client, url.Values{"response_type": {"code"}})
+
+	// the handle stays usable during the login and consent round-trip
+	for range 2 {
+		_, err := h.GetPushedAuthorizeRequest(ctx, res.RequestURI, client)
+		require.NoError(t, err)
+		require.NoError(t, h.HandleAuthorizeRequest(ctx, core.NewAuthorizeRequest()))
+	}
+
+	req := core.NewAuthorizeRequest()
+	req.Client = client
+	req.PushedRequestURI = res.RequestURI
+	require.NoError(t, h.HandleAuthorizeResponse(ctx, req, core.NewAuthorizeResponse()))
+
+	// it yields at most one authorization response
+	assert.ErrorIs(t, h.HandleAuthorizeResponse(ctx, req, core.NewAuthorizeResponse()), core.ErrInvalidRequestURI)
+	_, err := h.GetPushedAuthorizeRequest(ctx, res.RequestURI, client)
+	assert.ErrorIs(t, err, core.ErrInvalidRequestURI)
+}
+
+func TestPushedAuthorizeHandler_IgnoresRequestsWithoutHandle(t *testing.T) {
+	h, _, _ := newTestHandler()
+	assert.NoError(t, h.HandleAuthorizeResponse(context.Background(), core.NewAuthorizeRequest(), core.NewAuthorizeResponse()))
+}
@@ -78,7 +78,7 @@ func (h *ProofKeyForCodeExchangeHandler) HandleAuthorizeResponse(
 		"code_challenge",
 		"code_challenge_method",
 	)); err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	return nil
@@ -94,13 +94,13 @@ func (h *ProofKeyForCodeExchangeHandler) HandleTokenRequest(ctx context.Context,
 	codeSignature := h.codeStrategy.AuthorizeCodeSignature(ctx, req.Code)
 	pkceRequest, err := h.storage.GetPKCERequestSession(ctx, codeSignature, req.Session)
 	if stderr.Is(err, core.ErrNotFound) {
-		return core.ErrInvalidGrant.WithHint("Unable to find initial PKCE data tied to this request").WithWrap(err).WithDebug(err.Error())
+		return core.ErrInvalidGrant.WithHint("Unable to find initial PKCE data tied to this request").WithWrap(err).WithDebug("%s", err)
 	} else if err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	if err = h.storage.DeletePKCERequestSession(ctx, codeSignature); err != nil {
-		return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+		return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 	}
 
 	challenge := pkceRequest.Form.Get("code_challenge")
@@ -125,7 +125,7 @@ func (h *ProofKeyForCodeExchangeHandler) HandleTokenRequest(ctx context.Context,
 	case "S256":
 		hash := sha256.New()
 		if _, err = hash.Write([]byte(verifier)); err != nil {
-			return core.ErrServerError.WithWrap(err).WithDebug(err.Error())
+			return core.ErrServerError.WithWrap(err).WithDebug("%s", err)
 		}
 
 		if base64.RawURLEncoding.EncodeToString(hash.Sum([]byte{})) != challenge {
@@ -1,16 +1,33 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	HashAlgorithmBCrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmPBKDF2   = "pbkdf2-sha256"
+)
+
+var ErrHashMismatch = errors.New("hash does not match the data")
+
 type Hasher interface {
 	Compare(ctx context.Context, hash, data []byte) error
 	Hash(ctx context.Context, data []byte) ([]byte, error)
 }
 
+// RehashableHasher is implemented by hashers that can tell a hash created with outdated parameters, e.g. a lower
+// cost than the one configured now.
+type RehashableHasher interface {
+	Hasher
+	NeedsRehash(hash []byte) bool
+}
+
 type BCrypt struct {
 	cost int
 }
@@ -24,12 +41,7 @@ func NewBCryptHasher(cost int) *BCrypt {
 const DefaultBCryptWorkFactor = 12
 
 func (b *BCrypt) Hash(ctx context.Context, data []byte) ([]byte, error) {
-	wf := b.cost
-	if wf == 0 {
-		wf = DefaultBCryptWorkFactor
-	}
-
-	s, err := bcrypt.GenerateFromPassword(data, wf)
+	s, err := bcrypt.GenerateFromPassword(data, b.workFactor())
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +50,77 @@ func (b *BCrypt) Hash(ctx context.Context, data []byte) ([]byte, error) {
 }
 
 func (b *BCrypt) Compare(ctx context.Context, hash, data []byte) error {
-	if err := bcrypt.CompareHashAndPassword(hash, data); err != nil {
+	if err := bcrypt.CompareHashAndPassword(hash, data); errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrHashMismatch
+	} else if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (b *BCrypt) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost < b.workFactor()
+}
+
+func (b *BCrypt) workFactor() int {
+	if b.cost == 0 {
+		return DefaultBCryptWorkFactor
+	}
+	return b.cost
+}
+
+// MultiHasher hashes with the hasher of the current algorithm and compares with the hasher of the algorithm the hash
+// was created with, so secrets keep working after the algorithm is changed. Such hashes, and hashes with outdated
+// parameters, need a rehash.
+type MultiHasher struct {
+	current string
+	hashers map[string]RehashableHasher
+}
+
+func NewMultiHasher(current string, hashers map[string]RehashableHasher) *MultiHasher {
+	return &MultiHasher{
+		current: current,
+		hashers: hashers,
+	}
+}
+
+func (m *MultiHasher) Hash(ctx context.Context, data []byte) ([]byte, error) {
+	hasher, ok := m.hashers[m.current]
+	if !ok {
+		return nil, errors.New("unsupported hash algorithm: " + m.current)
+	}
+
+	return hasher.Hash(ctx, data)
+}
+
+func (m *MultiHasher) Compare(ctx context.Context, hash, data []byte) error {
+	hasher, ok := m.hashers[HashAlgorithm(hash)]
+	if !ok {
+		return errors.New("unsupported hash algorithm: " + HashAlgorithm(hash))
+	}
+
+	return hasher.Compare(ctx, hash, data)
+}
+
+func (m *MultiHasher) NeedsRehash(hash []byte) bool {
+	if HashAlgorithm(hash) != m.current {
+		return true
+	}
+
+	return m.hashers[m.current].NeedsRehash(hash)
+}
+
+// HashAlgorithm detects the algorithm of a bcrypt hash or of a hash in PHC string format.
+func HashAlgorithm(hash []byte) string {
+	switch {
+	case bytes.HasPrefix(hash, []byte("$2a$")), bytes.HasPrefix(hash, []byte("$2b$")), bytes.HasPrefix(hash, []byte("$2y$")):
+		return HashAlgorithmBCrypt
+	case bytes.HasPrefix(hash, []byte("$"+HashAlgorithmArgon2id+"$")):
+		return HashAlgorithmArgon2id
+	case bytes.HasPrefix(hash, []byte("$"+HashAlgorithmPBKDF2+"$")):
+		return HashAlgorithmPBKDF2
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// The defaults are the second recommended option of RFC 9106 section 4.
+const (
+	DefaultArgon2idMemory      = 64 * 1024 // KiB
+	DefaultArgon2idIterations  = 3
+	DefaultArgon2idParallelism = 4
+
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2id hashes in the PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+type Argon2id struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2id {
+	if memory == 0 {
+		memory = DefaultArgon2idMemory
+	}
+	if iterations == 0 {
+		iterations = DefaultArgon2idIterations
+	}
+	if parallelism == 0 {
+		parallelism = DefaultArgon2idParallelism
+	}
+
+	return &Argon2id{
+		memory:      memory,
+		iterations:  iterations,
+		parallelism: parallelism,
+	}
+}
+
+func (a *Argon2id) Hash(ctx context.Context, data []byte) ([]byte, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(data, salt, a.iterations, a.memory, a.parallelism, argon2idKeyLength)
+	return fmt.Appendf(nil, "$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", HashAlgorithmArgon2id, argon2.Version,
+		a.memory, a.iterations, a.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) Compare(ctx context.Context, hash, data []byte) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey(data, salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrHashMismatch
+	}
+
+	return nil
+}
+
+func (a *Argon2id) NeedsRehash(hash []byte) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	return err != nil || params.memory < a.memory || params.iterations < a.iterations || params.parallelism < a.parallelism
+}
+
+func decodeArgon2id(hash []byte) (params *Argon2id, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return nil, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2id version: %s", parts[2])
+	}
+
+	params = &Argon2id{}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	// argon2.IDKey panics on zero iterations or parallelism
+	if params.memory == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id parameters: %s", parts[3])
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return nil, nil, nil, errors.New("invalid argon2id key")
+	}
+
+	return params, salt, key, nil
+}
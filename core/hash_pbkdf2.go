@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultPBKDF2Iterations follows the OWASP password storage recommendation for PBKDF2-HMAC-SHA256.
+const (
+	DefaultPBKDF2Iterations = 600000
+
+	pbkdf2SaltLength = 16
+	pbkdf2KeyLength  = 32
+)
+
+// PBKDF2 hashes with PBKDF2-HMAC-SHA256 in the PHC string format, e.g. $pbkdf2-sha256$i=600000$<salt>$<hash>. It only
+// uses FIPS 140 approved primitives.
+type PBKDF2 struct {
+	iterations int
+}
+
+func NewPBKDF2Hasher(iterations int) *PBKDF2 {
+	if iterations == 0 {
+		iterations = DefaultPBKDF2Iterations
+	}
+
+	return &PBKDF2{
+		iterations: iterations,
+	}
+}
+
+func (p *PBKDF2) Hash(ctx context.Context, data []byte) ([]byte, error) {
+	salt := make([]byte, pbkdf2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, string(data), salt, p.iterations, pbkdf2KeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return fmt.Appendf(nil, "$%s$i=%d$%s$%s", HashAlgorithmPBKDF2, p.iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (p *PBKDF2) Compare(ctx context.Context, hash, data []byte) error {
+	iterations, salt, key, err := decodePBKDF2(hash)
+	if err != nil {
+		return err
+	}
+
+	actual, err := pbkdf2.Key(sha256.New, string(data), salt, iterations, len(key))
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrHashMismatch
+	}
+
+	return nil
+}
+
+func (p *PBKDF2) NeedsRehash(hash []byte) bool {
+	iterations, _, _, err := decodePBKDF2(hash)
+	return err != nil || iterations < p.iterations
+}
+
+func decodePBKDF2(hash []byte) (iterations int, salt, key []byte, err error) {
+	// "", "pbkdf2-sha256", "i=600000", salt, key
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 5 || parts[1] != HashAlgorithmPBKDF2 {
+		return 0, nil, nil, errors.New("invalid pbkdf2-sha256 hash")
+	}
+
+	if _, err = fmt.Sscanf(parts[2], "i=%d", &iterations); err != nil || iterations <= 0 {
+		return 0, nil, nil, fmt.Errorf("invalid pbkdf2-sha256 iterations: %s", parts[2])
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+		return 0, nil, nil, fmt.Errorf("invalid pbkdf2-sha256 salt: %w", err)
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(key) == 0 {
+		return 0, nil, nil, errors.New("invalid pbkdf2-sha256 key")
+	}
+
+	return iterations, salt, key, nil
+}
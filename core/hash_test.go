@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the costs are kept low so the tests run quickly
+func testHashers() map[string]RehashableHasher {
+	return map[string]RehashableHasher{
+		HashAlgorithmBCrypt:   NewBCryptHasher(4),
+		HashAlgorithmArgon2id: NewArgon2idHasher(1024, 1, 1),
+		HashAlgorithmPBKDF2:   NewPBKDF2Hasher(1000),
+	}
+}
+
+func TestHasher_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for algorithm, hasher := range testHashers() {
+		t.Run(algorithm, func(t *testing.T) {
+			hash, err := hasher.Hash(ctx, []byte("secret"))
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, HashAlgorithm(hash))
+
+			assert.NoError(t, hasher.Compare(ctx, hash, []byte("secret")))
+			assert.ErrorIs(t, hasher.Compare(ctx, hash, []byte("other")), ErrHashMismatch)
+			assert.False(t, hasher.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestHasher_NeedsRehashOnLowerCost(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name     string
+		old, new RehashableHasher
+	}{
+		{"bcrypt", NewBCryptHasher(4), NewBCryptHasher(5)},
+		{"argon2id memory", NewArgon2idHasher(1024, 1, 1), NewArgon2idHasher(2048, 1, 1)},
+		{"argon2id iterations", NewArgon2idHasher(1024, 1, 1), NewArgon2idHasher(1024, 2, 1)},
+		{"argon2id parallelism", NewArgon2idHasher(1024, 1, 1), NewArgon2idHasher(1024, 1, 2)},
+		{"pbkdf2", NewPBKDF2Hasher(1000), NewPBKDF2Hasher(2000)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := tc.old.Hash(ctx, []byte("secret"))
+			require.NoError(t, err)
+
+			assert.True(t, tc.new.NeedsRehash(hash))
+			assert.NoError(t, tc.new.Compare(ctx, hash, []byte("secret")))
+		})
+	}
+}
+
+func TestMultiHasher(t *testing.T) {
+	ctx := context.Background()
+	hashers := testHashers()
+
+	bcryptHash, err := hashers[HashAlgorithmBCrypt].Hash(ctx, []byte("secret"))
+	require.NoError(t, err)
+
+	m := NewMultiHasher(HashAlgorithmArgon2id, hashers)
+
+	// hashes of the previous algorithm still verify, but need a rehash
+	assert.NoError(t, m.Compare(ctx, bcryptHash, []byte("secret")))
+	assert.ErrorIs(t, m.Compare(ctx, bcryptHash, []byte("other")), ErrHashMismatch)
+	assert.True(t, m.NeedsRehash(bcryptHash))
+
+	hash, err := m.Hash(ctx, []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, HashAlgorithmArgon2id, HashAlgorithm(hash))
+	assert.False(t, m.NeedsRehash(hash))
+
+	assert.Error(t, m.Compare(ctx, []byte("$unknown$hash"), []byte("secret")))
+	_, err = NewMultiHasher("unknown", hashers).Hash(ctx, []byte("secret"))
+	assert.Error(t, err)
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	cases := map[string]string{
+		"$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW": HashAlgorithmBCrypt,
+		"$2b$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW": HashAlgorithmBCrypt,
+		"$2y$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW": HashAlgorithmBCrypt,
+		"$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$a2V5":                   HashAlgorithmArgon2id,
+		"$pbkdf2-sha256$i=600000$c2FsdA$a2V5":                          HashAlgorithmPBKDF2,
+		"$argon2i$v=19$m=65536,t=3,p=4$c2FsdA$a2V5":                    "",
+		"plaintext": "",
+	}
+
+	for hash, want := range cases {
+		assert.Equal(t, want, HashAlgorithm([]byte(hash)), hash)
+	}
+}
+
+func TestHasher_MalformedHash(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		hasher Hasher
+		hash   string
+	}{
+		{"argon2id zero parallelism", NewArgon2idHasher(0, 0, 0), "$argon2id$v=19$m=65536,t=3,p=0$c2FsdA$a2V5"},
+		{"argon2id zero iterations", NewArgon2idHasher(0, 0, 0), "$argon2id$v=19$m=65536,t=0,p=4$c2FsdA$a2V5"},
+		{"argon2id zero memory", NewArgon2idHasher(0, 0, 0), "$argon2id$v=19$m=0,t=3,p=4$c2FsdA$a2V5"},
+		{"argon2id wrong version", NewArgon2idHasher(0, 0, 0), "$argon2id$v=16$m=65536,t=3,p=4$c2FsdA$a2V5"},
+		{"argon2id missing key", NewArgon2idHasher(0, 0, 0), "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$"},
+		{"argon2id missing parts", NewArgon2idHasher(0, 0, 0), "$argon2id$v=19$m=65536,t=3,p=4"},
+		{"pbkdf2 zero iterations", NewPBKDF2Hasher(0), "$pbkdf2-sha256$i=0$c2FsdA$a2V5"},
+		{"pbkdf2 bad salt", NewPBKDF2Hasher(0), "$pbkdf2-sha256$i=1000$!!$a2V5"},
+		{"bcrypt truncated", NewBCryptHasher(4), "$2a$04$short"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				err := tc.hasher.Compare(ctx, []byte(tc.hash), []byte("secret"))
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrHashMismatch)
+			})
+		})
+	}
+}
+
+func TestRehashClientSecret(t *testing.T) {
+	ctx := context.Background()
+	hashers := testHashers()
+
+	bcryptHash, err := hashers[HashAlgorithmBCrypt].Hash(ctx, []byte("secret"))
+	require.NoError(t, err)
+	argon2Hash, err := hashers[HashAlgorithmArgon2id].Hash(ctx, []byte("secret"))
+	require.NoError(t, err)
+
+	outdated := &testClient{id: "outdated", hashedSecret: bcryptHash}
+	current := &testClient{id: "current", hashedSecret: argon2Hash}
+	store := newTestStore(outdated, current)
+	o := &OAuth2{store: store}
+	m := NewMultiHasher(HashAlgorithmArgon2id, hashers)
+
+	o.rehashClientSecret(ctx, m, outdated, "secret")
+	o.rehashClientSecret(ctx, m, current, "secret")
+
+	require.Contains(t, store.updatedHashes, "outdated")
+	assert.Equal(t, HashAlgorithmArgon2id, HashAlgorithm(store.updatedHashes["outdated"]))
+	assert.NoError(t, m.Compare(ctx, store.updatedHashes["outdated"], []byte("secret")))
+	assert.NotContains(t, store.updatedHashes, "current")
+
+	// hashers that can not tell outdated hashes are never rehashed
+	o.rehashClientSecret(ctx, &plainHasher{}, &testClient{id: "plain"}, "secret")
+	assert.NotContains(t, store.updatedHashes, "plain")
+}
+
+type plainHasher struct{}
+
+func (plainHasher) Hash(ctx context.Context, data []byte) ([]byte, error) { return data, nil }
+func (plainHasher) Compare(ctx context.Context, hash, data []byte) error  { return nil }
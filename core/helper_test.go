@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"crypto/sha512"
+	"crypto/x509"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+type testClient struct {
+	id           string
+	hashedSecret []byte
+	secret       []byte
+	public       bool
+	grantTypes   []string
+	authMethod   string
+	signingAlg   string
+	jwks         *jose.JSONWebKeySet
+	jwksURI      string
+	requestURIs  []string
+}
+
+func (c *testClient) GetID() string                          { return c.id }
+func (c *testClient) GetHashedSecret() []byte                { return c.hashedSecret }
+func (c *testClient) GetRedirectURIs() []string              { return nil }
+func (c *testClient) GetGrantTypes() Arguments               { return c.grantTypes }
+func (c *testClient) GetResponseTypes() Arguments            { return nil }
+func (c *testClient) GetResponseModes() []ResponseMode       { return nil }
+func (c *testClient) GetScopes() Arguments                   { return nil }
+func (c *testClient) IsPublic() bool                         { return c.public }
+func (c *testClient) GetAudience() Arguments                 { return nil }
+func (c *testClient) GetRequestURIs() []string               { return c.requestURIs }
+func (c *testClient) GetJWKs() *jose.JSONWebKeySet           { return c.jwks }
+func (c *testClient) GetJWKsURI() string                     { return c.jwksURI }
+func (c *testClient) GetTokenEndpointAuthMethod() string     { return c.authMethod }
+func (c *testClient) GetTokenEndpointAuthSigningAlg() string { return c.signingAlg }
+func (c *testClient) GetUserinfoSignedResponseAlg() string   { return "" }
+func (c *testClient) GetClientSecret() []byte                { return c.secret }
+
+// testStore keeps clients and used client assertion IDs in memory.
+type testStore struct {
+	clients       map[string]Client
+	jtis          map[string]time.Time
+	updatedHashes map[string][]byte
+}
+
+func newTestStore(clients ...Client) *testStore {
+	s := &testStore{
+		clients:       map[string]Client{},
+		jtis:          map[string]time.Time{},
+		updatedHashes: map[string][]byte{},
+	}
+	for _, c := range clients {
+		s.clients[c.GetID()] = c
+	}
+	return s
+}
+
+func (s *testStore) GetClient(ctx context.Context, id string) (Client, error) {
+	c, ok := s.clients[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *testStore) ClientAssertionJWTValid(ctx context.Context, jti string) error {
+	if exp, ok := s.jtis[jti]; ok && exp.After(time.Now()) {
+		return ErrJTIKnown
+	}
+	return nil
+}
+
+func (s *testStore) SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error {
+	s.jtis[jti] = exp
+	return nil
+}
+
+func (s *testStore) UpdateClientSecretHash(ctx context.Context, id string, hash []byte) error {
+	s.updatedHashes[id] = hash
+	return nil
+}
+
+type testConfig struct {
+	secret         []byte
+	rotatedSecrets [][]byte
+	nonceRequired  bool
+	nonceLifetime  time.Duration
+	hasher         Hasher
+	jwksFetcher    JWKSFetcher
+	clientCAs      *x509.CertPool
+}
+
+func newTestConfig() *testConfig {
+	return &testConfig{
+		secret:        []byte(strings.Repeat("s", 64)),
+		nonceLifetime: time.Minute,
+		hasher:        NewBCryptHasher(4),
+	}
+}
+
+func (c *testConfig) IsDebugging() bool                             { return false }
+func (c *testConfig) GetMinParameterEntropy() int                   { return 8 }
+func (c *testConfig) GetSecretsHasher() Hasher                      { return c.hasher }
+func (c *testConfig) GetIDTokenIssuer() string                      { return "https://auth.example.com" }
+func (c *testConfig) GetTokenURL() string                           { return "https://auth.example.com/oauth/token" }
+func (c *testConfig) GetJWKSFetcher() JWKSFetcher                   { return c.jwksFetcher }
+func (c *testConfig) IsPushedAuthorizationRequired() bool           { return false }
+func (c *testConfig) GetGlobalSecret() []byte                       { return c.secret }
+func (c *testConfig) GetRotatedSecrets() [][]byte                   { return c.rotatedSecrets }
+func (c *testConfig) GetHMACHasher() func() hash.Hash               { return sha512.New512_256 }
+func (c *testConfig) GetClientAssertionLifetime() time.Duration     { return time.Hour }
+func (c *testConfig) GetDPoPProofLifetime() time.Duration           { return time.Minute }
+func (c *testConfig) IsDPoPNonceRequired() bool                     { return c.nonceRequired }
+func (c *testConfig) GetDPoPNonceLifetime() time.Duration           { return c.nonceLifetime }
+func (c *testConfig) GetTLSClientCAs() *x509.CertPool               { return c.clientCAs }
+func (c *testConfig) GetRedirectSecureChecker() func(*url.URL) bool { return nil }
+
+type testSession struct {
+	subject      string
+	expiresAt    map[TokenType]time.Time
+	confirmation *Confirmation
+}
+
+func newTestSession(subject string) *testSession {
+	return &testSession{subject: subject, expiresAt: map[TokenType]time.Time{}}
+}
+
+func (s *testSession) SetExpiresAt(key TokenType, exp time.Time) { s.expiresAt[key] = exp }
+func (s *testSession) GetExpiresAt(key TokenType) time.Time      { return s.expiresAt[key] }
+func (s *testSession) GetUsername() string                       { return s.subject }
+func (s *testSession) GetSubject() string                        { return s.subject }
+func (s *testSession) GetConfirmation() *Confirmation            { return s.confirmation }
+func (s *testSession) SetConfirmation(cnf *Confirmation)         { s.confirmation = cnf }
+
+func (s *testSession) Clone() Session {
+	clone := *s
+	return &clone
+}
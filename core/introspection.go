@@ -29,6 +29,11 @@ type IntrospectionResponse struct {
 	Audience  string `json:"aud,omitempty"`
 	Issuer    string `json:"iss,omitempty"`
 	JTI       string `json:"jti,omitempty"`
+	// Confirmation lets resource servers enforce sender-constrained tokens, see RFC 9449 section 6.2 and RFC 8705
+	// section 3.2.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	// Extra holds the custom claims of the session the token was issued for.
+	Extra map[string]any `json:"ext,omitempty"`
 }
 
 func (o *OAuth2) IntrospectToken(ctx context.Context, req *http.Request, session Session) (*IntrospectionResponse, error) {
@@ -67,11 +72,71 @@ func (o *OAuth2) IntrospectToken(ctx context.Context, req *http.Request, session
 		}
 	}
 
+	tokenType, tr, err := o.introspect(ctx, request, session)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenType := ""
+	if tokenType == AccessToken {
+		accessTokenType = tr.AccessTokenType()
+	}
+
+	cnf := confirmationFromSession(tr.Session)
+	if cnf != nil && *cnf == (Confirmation{}) {
+		cnf = nil
+	}
+
+	var extra map[string]any
+	if s, ok := tr.Session.(ExtraClaimsSession); ok {
+		extra = s.GetExtraClaims()
+	}
+
+	return &IntrospectionResponse{
+		Active:       true,
+		Scope:        strings.Join(tr.RequestedScope, " "),
+		ClientID:     tr.Client.GetID(),
+		TokenType:    accessTokenType,
+		Subject:      tr.Session.GetSubject(),
+		Audience:     strings.Join(tr.RequestedAudience, " "),
+		Confirmation: cnf,
+		Extra:        extra,
+	}, nil
+}
+
+// IntrospectBearerToken validates an access token that was presented by a client to a protected resource, e.g. the
+// UserInfo endpoint. Unlike IntrospectToken, the caller is not authenticated and refresh tokens are never accepted.
+func (o *OAuth2) IntrospectBearerToken(ctx context.Context, token string, session Session, scopes ...string) (*TokenRequest, error) {
+	if session == nil {
+		return nil, errors.New("session must not be nil")
+	}
+
+	if token == "" {
+		return nil, ErrRequestUnauthorized.WithHint("The request is missing a bearer token.")
+	}
+
+	tokenType, tr, err := o.introspect(ctx, &IntrospectionRequest{
+		Token:         token,
+		TokenTypeHint: AccessToken,
+		Scope:         scopes,
+	}, session)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType != AccessToken {
+		return nil, ErrRequestUnauthorized.WithHint("Only access tokens are allowed in the authorization header.")
+	}
+
+	return tr, nil
+}
+
+func (o *OAuth2) introspect(ctx context.Context, ir *IntrospectionRequest, session Session) (TokenType, *TokenRequest, error) {
 	handled := false
 	tokenType := TokenType("")
 	tr := NewTokenRequest(session)
 	for _, ih := range o.introspectionHandlers {
-		tt, ie := ih.IntrospectToken(ctx, request, tr)
+		tt, ie := ih.IntrospectToken(ctx, ir, tr)
 		if ie == nil {
 			handled = true
 			tokenType = tt
@@ -79,27 +144,15 @@ func (o *OAuth2) IntrospectToken(ctx context.Context, req *http.Request, session
 			// this handler does not handle this token type, try the next one
 			continue
 		} else if ie != nil {
-			return nil, ie
+			return "", nil, ie
 		}
 	}
 
 	if !handled {
-		return nil, ErrRequestUnauthorized.WithHint("Unable to find a suitable introspection strategy for the token, thus it is invalid.")
-	}
-
-	accessTokenType := ""
-	if tokenType == AccessToken {
-		accessTokenType = BearerToken
+		return "", nil, ErrRequestUnauthorized.WithHint("Unable to find a suitable introspection strategy for the token, thus it is invalid.")
 	}
 
-	return &IntrospectionResponse{
-		Active:    true,
-		Scope:     strings.Join(tr.RequestedScope, " "),
-		ClientID:  tr.Client.GetID(),
-		TokenType: accessTokenType,
-		Subject:   tr.Session.GetSubject(),
-		Audience:  strings.Join(tr.RequestedAudience, " "),
-	}, nil
+	return tokenType, tr, nil
 }
 
 func (o *OAuth2) WriteIntrospectionError(ctx context.Context, rw http.ResponseWriter, err error) {
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// DefaultResponseEncryptionEncoding is used when a client registers a key management algorithm without a content
+// encryption, see OpenID Connect Dynamic Client Registration 1.0 section 2 and JARM section 3.
+const DefaultResponseEncryptionEncoding = "A128CBC-HS256"
+
+var (
+	// ResponseEncryptionAlgorithms are the key management algorithms ID tokens, UserInfo and authorization responses
+	// can be encrypted to a client with. The key is one of the asymmetric keys registered by the client.
+	ResponseEncryptionAlgorithms = []string{
+		string(jose.RSA_OAEP), string(jose.RSA_OAEP_256),
+		string(jose.ECDH_ES), string(jose.ECDH_ES_A128KW), string(jose.ECDH_ES_A192KW), string(jose.ECDH_ES_A256KW),
+	}
+
+	// ResponseEncryptionEncodings are the content encryption algorithms of encrypted responses.
+	ResponseEncryptionEncodings = []string{
+		string(jose.A128CBC_HS256), string(jose.A192CBC_HS384), string(jose.A256CBC_HS512),
+		string(jose.A128GCM), string(jose.A192GCM), string(jose.A256GCM),
+	}
+)
+
+// EncryptForClient encrypts the payload to a key of the client that matches the key management algorithm alg. A signed
+// JWT payload becomes a nested JWT as described in RFC 7519 section 5.2, any other payload is encrypted as it is.
+func EncryptForClient(ctx context.Context, fetcher JWKSFetcher, c Client, payload []byte, nested bool, alg, enc string) (string, error) {
+	if enc == "" {
+		enc = DefaultResponseEncryptionEncoding
+	}
+
+	key, err := findEncryptionKey(ctx, fetcher, c, jose.KeyAlgorithm(alg))
+	if err != nil {
+		return "", err
+	}
+
+	opts := (&jose.EncrypterOptions{}).WithType("JWT")
+	if nested {
+		opts = opts.WithContentType("JWT")
+	}
+
+	encrypter, err := jose.NewEncrypter(jose.ContentEncryption(enc), jose.Recipient{
+		Algorithm: jose.KeyAlgorithm(alg),
+		Key:       key.Key,
+		KeyID:     key.KeyID,
+	}, opts)
+	if err != nil {
+		return "", err
+	}
+
+	jwe, err := encrypter.Encrypt(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return jwe.CompactSerialize()
+}
+
+// findEncryptionKey returns the first key of the client with use either empty or enc, whose alg matches the key
+// management algorithm, or whose type fits it when alg is not set.
+func findEncryptionKey(ctx context.Context, fetcher JWKSFetcher, c Client, alg jose.KeyAlgorithm) (*jose.JSONWebKey, error) {
+	client, ok := c.(OpenIDConnectClient)
+	if !ok {
+		return nil, errors.New("the client can not register encryption keys")
+	}
+
+	keys := client.GetJWKs()
+	if (keys == nil || len(keys.Keys) == 0) && client.GetJWKsURI() != "" {
+		var err error
+		if keys, err = fetcher.Resolve(ctx, client.GetJWKsURI(), false); err != nil {
+			return nil, err
+		}
+	}
+
+	if keys == nil {
+		return nil, errors.New("the client has no keys registered")
+	}
+
+	i := slices.IndexFunc(keys.Keys, func(key jose.JSONWebKey) bool {
+		if key.Use != "" && key.Use != "enc" {
+			return false
+		}
+
+		if key.Algorithm != "" {
+			return key.Algorithm == string(alg)
+		}
+
+		switch key.Public().Key.(type) {
+		case *rsa.PublicKey:
+			return strings.HasPrefix(string(alg), "RSA")
+		case *ecdsa.PublicKey:
+			return strings.HasPrefix(string(alg), "ECDH-ES")
+		default:
+			return false
+		}
+	})
+	if i < 0 {
+		return nil, errors.New("the client has no key registered for the encryption algorithm " + string(alg))
+	}
+
+	key := keys.Keys[i].Public()
+	return &key, nil
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindEncryptionKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaEnc := jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "rsa-enc", Use: "enc"}
+	rsaSig := jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "rsa-sig", Use: "sig"}
+	rsaNoUse := jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "rsa-no-use"}
+	rsaOAEP := jose.JSONWebKey{Key: &rsaKey.PublicKey, KeyID: "rsa-oaep", Use: "enc", Algorithm: string(jose.RSA_OAEP)}
+	ecEnc := jose.JSONWebKey{Key: &ecKey.PublicKey, KeyID: "ec-enc", Use: "enc"}
+	octEnc := jose.JSONWebKey{Key: []byte("secret"), KeyID: "oct-enc", Use: "enc"}
+
+	server, _ := newJWKSServer(t, func() any { return &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{ecEnc}} })
+	missing := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(missing.Close)
+	fetcher := NewDefaultJWKSFetcher(server.Client(), time.Hour)
+
+	cases := []struct {
+		name    string
+		client  Client
+		alg     jose.KeyAlgorithm
+		wantKID string
+		wantErr string
+	}{
+		{
+			name:    "RSA key without alg",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaEnc}}},
+			alg:     jose.RSA_OAEP_256,
+			wantKID: "rsa-enc",
+		},
+		{
+			name:    "EC key without alg",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaEnc, ecEnc}}},
+			alg:     jose.ECDH_ES_A128KW,
+			wantKID: "ec-enc",
+		},
+		{
+			name:    "key without use",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaNoUse}}},
+			alg:     jose.RSA_OAEP,
+			wantKID: "rsa-no-use",
+		},
+		{
+			name:    "signing key is skipped",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaSig, rsaEnc}}},
+			alg:     jose.RSA_OAEP_256,
+			wantKID: "rsa-enc",
+		},
+		{
+			name:    "key with matching alg",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaOAEP}}},
+			alg:     jose.RSA_OAEP,
+			wantKID: "rsa-oaep",
+		},
+		{
+			name:    "key from jwks_uri",
+			client:  &testClient{id: "client", jwksURI: server.URL},
+			alg:     jose.ECDH_ES,
+			wantKID: "ec-enc",
+		},
+		{
+			name:    "client without key registration",
+			client:  struct{ Client }{&testClient{id: "client"}},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "the client can not register encryption keys",
+		},
+		{
+			name:    "no keys registered",
+			client:  &testClient{id: "client"},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "the client has no keys registered",
+		},
+		{
+			name:    "jwks_uri can not be fetched",
+			client:  &testClient{id: "client", jwksURI: missing.URL},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "expected status code 200 but got 404",
+		},
+		{
+			name:    "only a signing key",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaSig}}},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "the client has no key registered for the encryption algorithm RSA-OAEP-256",
+		},
+		{
+			name:    "key with another alg",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaOAEP}}},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "the client has no key registered for the encryption algorithm RSA-OAEP-256",
+		},
+		{
+			name:    "key type does not fit the algorithm",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{rsaEnc}}},
+			alg:     jose.ECDH_ES,
+			wantErr: "the client has no key registered for the encryption algorithm ECDH-ES",
+		},
+		{
+			name:    "symmetric key",
+			client:  &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{octEnc}}},
+			alg:     jose.RSA_OAEP_256,
+			wantErr: "the client has no key registered for the encryption algorithm RSA-OAEP-256",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := findEncryptionKey(context.Background(), fetcher, tc.client, tc.alg)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantKID, key.KeyID)
+			assert.True(t, key.IsPublic())
+		})
+	}
+}
+
+func TestEncryptForClient(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	client := &testClient{id: "client", jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &key.PublicKey, KeyID: "client-enc", Use: "enc"},
+	}}}
+
+	token, err := EncryptForClient(context.Background(), nil, client, []byte("payload"), true, string(jose.RSA_OAEP_256), "")
+	require.NoError(t, err)
+
+	jwe, err := jose.ParseEncrypted(token,
+		[]jose.KeyAlgorithm{jose.RSA_OAEP_256},
+		[]jose.ContentEncryption{jose.ContentEncryption(DefaultResponseEncryptionEncoding)},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "client-enc", jwe.Header.KeyID)
+	assert.Equal(t, "JWT", jwe.Header.ExtraHeaders[jose.HeaderContentType])
+
+	payload, err := jwe.Decrypt(key)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(payload))
+
+	_, err = EncryptForClient(context.Background(), nil, client, []byte("payload"), false, string(jose.RSA_OAEP_256), "A0GCM")
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// JWKSFetcher resolves a JSON Web Key Set from a remote location such as a client's jwks_uri.
+type JWKSFetcher interface {
+	// Resolve returns the key set published at location. When forceRefresh is true the cached key set is ignored,
+	// this is used when a token refers to a kid that is unknown to the cached key set.
+	Resolve(ctx context.Context, location string, forceRefresh bool) (*jose.JSONWebKeySet, error)
+}
+
+const (
+	maxJWKSSize = 1 << 20
+
+	// defaultJWKSRefreshInterval is the minimum time between two forced refreshes of the same key set, so tokens
+	// with random kids can not turn the server into a proxy flooding the jwks_uri.
+	defaultJWKSRefreshInterval = 30 * time.Second
+)
+
+type cachedJWKS struct {
+	keys      *jose.JSONWebKeySet
+	expiresAt time.Time
+}
+
+// DefaultJWKSFetcher caches every fetched key set in memory for a fixed TTL. Forced refreshes of a location are
+// limited to one per refresh interval, the cached key set is used in between.
+type DefaultJWKSFetcher struct {
+	client          *http.Client
+	ttl             time.Duration
+	refreshInterval time.Duration
+	mu              sync.RWMutex
+	cache           map[string]cachedJWKS
+	refreshedAt     map[string]time.Time
+}
+
+func NewDefaultJWKSFetcher(client *http.Client, ttl time.Duration) *DefaultJWKSFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &DefaultJWKSFetcher{
+		client:          client,
+		ttl:             ttl,
+		refreshInterval: defaultJWKSRefreshInterval,
+		cache:           make(map[string]cachedJWKS),
+		refreshedAt:     make(map[string]time.Time),
+	}
+}
+
+func (f *DefaultJWKSFetcher) Resolve(ctx context.Context, location string, forceRefresh bool) (*jose.JSONWebKeySet, error) {
+	if forceRefresh && !f.allowRefresh(location) {
+		forceRefresh = false
+	}
+
+	if !forceRefresh {
+		f.mu.RLock()
+		item, ok := f.cache[location]
+		f.mu.RUnlock()
+
+		if ok && time.Now().Before(item.expiresAt) {
+			return item.keys, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected status code 200 but got %d when fetching %s", resp.StatusCode, location)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > maxJWKSSize {
+		return nil, fmt.Errorf("key set at %s exceeds %d bytes", location, maxJWKSSize)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err = json.Unmarshal(body, &keys); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.cache[location] = cachedJWKS{keys: &keys, expiresAt: time.Now().Add(f.ttl)}
+	f.mu.Unlock()
+
+	return &keys, nil
+}
+
+// allowRefresh records a forced refresh of location, unless the previous one happened within the refresh interval.
+func (f *DefaultJWKSFetcher) allowRefresh(location string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := f.refreshedAt[location]; ok && now.Sub(last) < f.refreshInterval {
+		return false
+	}
+
+	f.refreshedAt[location] = now
+	return true
+}
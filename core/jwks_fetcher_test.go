@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJWKSServer serves the key set returned by keys and counts the requests it receives.
+func newJWKSServer(t *testing.T, keys func() any) (*httptest.Server, *atomic.Int32) {
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode(keys())
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func newTestJWKS(t *testing.T, kid string) *jose.JSONWebKeySet {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: kid, Algorithm: "ES256", Use: "sig"}}}
+}
+
+func TestDefaultJWKSFetcher_Cache(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestJWKS(t, "key-1")
+	server, hits := newJWKSServer(t, func() any { return keys })
+	f := NewDefaultJWKSFetcher(server.Client(), time.Hour)
+
+	resolved, err := f.Resolve(ctx, server.URL, false)
+	require.NoError(t, err)
+	assert.Len(t, resolved.Key("key-1"), 1)
+
+	_, err = f.Resolve(ctx, server.URL, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, hits.Load())
+
+	keys = newTestJWKS(t, "key-2")
+	resolved, err = f.Resolve(ctx, server.URL, true)
+	require.NoError(t, err)
+	assert.Len(t, resolved.Key("key-2"), 1)
+	assert.EqualValues(t, 2, hits.Load())
+}
+
+func TestDefaultJWKSFetcher_RateLimitsForcedRefresh(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestJWKS(t, "key-1")
+	server, hits := newJWKSServer(t, func() any { return keys })
+	other, otherHits := newJWKSServer(t, func() any { return keys })
+	f := NewDefaultJWKSFetcher(server.Client(), time.Hour)
+
+	_, err := f.Resolve(ctx, server.URL, true)
+	require.NoError(t, err)
+
+	// unknown kids can not make the server fetch the key set on every request
+	for range 5 {
+		resolved, err := f.Resolve(ctx, server.URL, true)
+		require.NoError(t, err)
+		assert.Len(t, resolved.Key("key-1"), 1)
+	}
+	assert.EqualValues(t, 1, hits.Load())
+
+	// the limit is kept per location
+	_, err = f.Resolve(ctx, other.URL, true)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, otherHits.Load())
+
+	f.refreshInterval = 0
+	_, err = f.Resolve(ctx, server.URL, true)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, hits.Load())
+}
+
+func TestDefaultJWKSFetcher_RejectsOversizedKeySet(t *testing.T) {
+	server, _ := newJWKSServer(t, func() any {
+		return map[string]any{"keys": []any{}, "padding": strings.Repeat("a", maxJWKSSize)}
+	})
+	f := NewDefaultJWKSFetcher(server.Client(), time.Hour)
+
+	_, err := f.Resolve(context.Background(), server.URL, false)
+	assert.Error(t, err)
+}
+
+func TestDefaultJWKSFetcher_RejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	f := NewDefaultJWKSFetcher(server.Client(), time.Hour)
+
+	_, err := f.Resolve(context.Background(), server.URL, false)
+	assert.Error(t, err)
+}
+
+func TestFindClientAssertionKey_RefreshesOnUnknownKid(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestJWKS(t, "key-1")
+	server, hits := newJWKSServer(t, func() any { return keys })
+
+	cfg := newTestConfig()
+	cfg.jwksFetcher = NewDefaultJWKSFetcher(server.Client(), time.Hour)
+	o := &OAuth2{config: cfg, store: newTestStore()}
+	client := &testClient{id: "client", jwksURI: server.URL}
+
+	_, err := o.findClientAssertionKey(ctx, client, "key-1", "ES256")
+	require.NoError(t, err)
+
+	// the client rotated its key, the cached key set is refreshed once
+	keys = newTestJWKS(t, "key-2")
+	_, err = o.findClientAssertionKey(ctx, client, "key-2", "ES256")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, hits.Load())
+
+	_, err = o.findClientAssertionKey(ctx, client, "key-3", "ES256")
+	assert.ErrorIs(t, err, ErrInvalidClient)
+	_, err = o.findClientAssertionKey(ctx, client, "key-4", "ES256")
+	assert.ErrorIs(t, err, ErrInvalidClient)
+	assert.EqualValues(t, 2, hits.Load())
+}
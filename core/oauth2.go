@@ -4,22 +4,27 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 type TokenType string
 type GrantType string
 
 const (
-	BearerToken = "Bearer"
+	BearerToken   = "Bearer"
+	DPoPTokenType = "DPoP"
 
 	AccessToken       TokenType = "access_token"
 	RefreshToken      TokenType = "refresh_token"
 	AuthorizationCode TokenType = "authorize_code"
 	IDToken           TokenType = "id_token"
+	DeviceCode        TokenType = "device_code"
 
 	GrantTypeRefreshToken      GrantType = "refresh_token"
 	GrantTypeAuthorizationCode GrantType = "authorization_code"
 	GrantTypeClientCredentials GrantType = "client_credentials"
+	GrantTypeTokenExchange     GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	GrantTypeDeviceCode        GrantType = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
 type OAuth2Provider interface {
@@ -28,6 +33,11 @@ type OAuth2Provider interface {
 	WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, req *AuthorizeRequest, err error)
 	WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, req *AuthorizeRequest, resp *AuthorizeResponse)
 
+	NewPushedAuthorizeRequest(ctx context.Context, req *http.Request) (*AuthorizeRequest, error)
+	NewPushedAuthorizeResponse(ctx context.Context, req *AuthorizeRequest, session Session) (*PushedAuthorizeResponse, error)
+	WritePushedAuthorizeError(ctx context.Context, rw http.ResponseWriter, err error)
+	WritePushedAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, resp *PushedAuthorizeResponse)
+
 	AuthenticateClient(ctx context.Context, r *http.Request, form url.Values) (Client, error)
 	NewTokenRequest(ctx context.Context, req *http.Request, session Session) (*TokenRequest, error)
 	NewTokenResponse(ctx context.Context, req *TokenRequest) (*TokenResponse, error)
@@ -37,15 +47,35 @@ type OAuth2Provider interface {
 	IntrospectToken(ctx context.Context, req *http.Request, session Session) (*IntrospectionResponse, error)
 	WriteIntrospectionError(ctx context.Context, rw http.ResponseWriter, err error)
 	WriteIntrospectionResponse(ctx context.Context, rw http.ResponseWriter, r *IntrospectionResponse)
+	IntrospectBearerToken(ctx context.Context, token string, session Session, scopes ...string) (*TokenRequest, error)
+	VerifyDPoPBinding(ctx context.Context, r *http.Request, htu, accessToken string, tr *TokenRequest) error
+	NewDPoPNonce() string
+	VerifyCertificateBinding(ctx context.Context, r *http.Request, tr *TokenRequest) error
+
+	NewRevocationRequest(ctx context.Context, req *http.Request) error
+	WriteRevocationResponse(ctx context.Context, rw http.ResponseWriter, err error)
+
+	NewDeviceRequest(ctx context.Context, req *http.Request, session Session) (*DeviceRequest, error)
+	NewDeviceResponse(ctx context.Context, req *DeviceRequest) (*DeviceResponse, error)
+	WriteDeviceError(ctx context.Context, rw http.ResponseWriter, err error)
+	WriteDeviceResponse(ctx context.Context, rw http.ResponseWriter, resp *DeviceResponse)
+
+	GetSupportedGrantTypes() []GrantType
 }
 
 // OAuth2 implements the OAuth2Provider interface.
 type OAuth2 struct {
-	config                Configurator
-	store                 Storage
-	authorizeHandlers     []AuthorizeHandler
-	tokenHandlers         []TokenHandler
-	introspectionHandlers []IntrospectionHandler
+	config                  Configurator
+	store                   Storage
+	authorizeHandlers       []AuthorizeHandler
+	pushedAuthorizeHandlers []PushedAuthorizeHandler
+	tokenHandlers           []TokenHandler
+	introspectionHandlers   []IntrospectionHandler
+	revocationHandlers      []RevocationHandler
+	deviceHandlers          []DeviceHandler
+	grantTypes              []GrantType
+
+	authorizeResponseJWTHandlers []AuthorizeResponseJWTHandler
 }
 
 func NewOAuth2(
@@ -54,14 +84,23 @@ func NewOAuth2(
 	handlers ...any,
 ) *OAuth2 {
 	authorizeHandlers := make([]AuthorizeHandler, 0)
+	pushedAuthorizeHandlers := make([]PushedAuthorizeHandler, 0)
 	tokenHandlers := make([]TokenHandler, 0)
 	introspectionHandlers := make([]IntrospectionHandler, 0)
+	revocationHandlers := make([]RevocationHandler, 0)
+	deviceHandlers := make([]DeviceHandler, 0)
+	grantTypes := make([]GrantType, 0)
+	authorizeResponseJWTHandlers := make([]AuthorizeResponseJWTHandler, 0)
 
 	for _, handler := range handlers {
 		if h, ok := handler.(AuthorizeHandler); ok {
 			authorizeHandlers = append(authorizeHandlers, h)
 		}
 
+		if h, ok := handler.(PushedAuthorizeHandler); ok {
+			pushedAuthorizeHandlers = append(pushedAuthorizeHandlers, h)
+		}
+
 		if h, ok := handler.(TokenHandler); ok {
 			tokenHandlers = append(tokenHandlers, h)
 		}
@@ -69,25 +108,73 @@ func NewOAuth2(
 		if h, ok := handler.(IntrospectionHandler); ok {
 			introspectionHandlers = append(introspectionHandlers, h)
 		}
+
+		if h, ok := handler.(RevocationHandler); ok {
+			revocationHandlers = append(revocationHandlers, h)
+		}
+
+		if h, ok := handler.(DeviceHandler); ok {
+			deviceHandlers = append(deviceHandlers, h)
+		}
+
+		if h, ok := handler.(GrantTypeHandler); ok {
+			grantTypes = append(grantTypes, h.GetGrantType())
+		}
+
+		if h, ok := handler.(AuthorizeResponseJWTHandler); ok {
+			authorizeResponseJWTHandlers = append(authorizeResponseJWTHandlers, h)
+		}
 	}
 
 	return &OAuth2{
-		config:                config,
-		store:                 store,
-		authorizeHandlers:     authorizeHandlers,
-		tokenHandlers:         tokenHandlers,
-		introspectionHandlers: introspectionHandlers,
+		config:                  config,
+		store:                   store,
+		authorizeHandlers:       authorizeHandlers,
+		pushedAuthorizeHandlers: pushedAuthorizeHandlers,
+		tokenHandlers:           tokenHandlers,
+		introspectionHandlers:   introspectionHandlers,
+		revocationHandlers:      revocationHandlers,
+		deviceHandlers:          deviceHandlers,
+		grantTypes:              grantTypes,
+
+		authorizeResponseJWTHandlers: authorizeResponseJWTHandlers,
 	}
 }
 
+// GetSupportedGrantTypes returns the grant types of the token handlers this provider was created with.
+func (o *OAuth2) GetSupportedGrantTypes() []GrantType {
+	return o.grantTypes
+}
+
 type Configurator interface {
 	DebugModeProvider
 	MinParameterEntropyProvider
 	SecretsHasherProvider
+	IDTokenIssuerProvider
+	TokenURLProvider
+	JWKSFetcherProvider
+	PushedAuthorizationRequiredProvider
+	GlobalSecretProvider
+	RotatedSecretsProvider
+	HMACHashingProvider
+	ClientAssertionLifetimeProvider
+	DPoPProofLifetimeProvider
+	DPoPNonceProvider
+	TLSClientCAsProvider
 }
 
 type Storage interface {
 	GetClient(ctx context.Context, id string) (Client, error)
+	// ClientAssertionJWTValid returns ErrJTIKnown if the jti of a client assertion has already been used.
+	ClientAssertionJWTValid(ctx context.Context, jti string) error
+	// SetClientAssertionJWT remembers the jti of a client assertion until it expires.
+	SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error
+}
+
+// ClientSecretStorage is implemented by storages that can replace the hash of a client secret, which lets
+// AuthenticateClient rehash secrets hashed with an outdated algorithm or parameters.
+type ClientSecretStorage interface {
+	UpdateClientSecretHash(ctx context.Context, id string, hash []byte) error
 }
 
 type AuthorizeHandler interface {
@@ -103,3 +190,17 @@ type TokenHandler interface {
 type IntrospectionHandler interface {
 	IntrospectToken(context.Context, *IntrospectionRequest, *TokenRequest) (TokenType, error)
 }
+
+type RevocationHandler interface {
+	RevokeToken(ctx context.Context, req *RevocationRequest) error
+}
+
+type DeviceHandler interface {
+	HandleDeviceRequest(ctx context.Context, req *DeviceRequest, res *DeviceResponse) error
+}
+
+// GrantTypeHandler is implemented by token handlers that introduce a grant type, as opposed to handlers that only
+// extend an existing one (e.g. PKCE or OpenID Connect).
+type GrantTypeHandler interface {
+	GetGrantType() GrantType
+}
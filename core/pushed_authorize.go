@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tuanta7/hydros/core/x"
+)
+
+// PushedAuthorizeRequestURIPrefix is the prefix of the request_uri handles issued by the pushed authorization request
+// endpoint, see RFC 9126 section 2.2.
+const PushedAuthorizeRequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+type PushedAuthorizeResponse struct {
+	RequestURI string        `json:"request_uri"`
+	ExpiresIn  time.Duration `json:"expires_in"`
+}
+
+// PushedAuthorizeHandler handles requests to the pushed authorization request endpoint and resolves the request_uri
+// handles it issued when they come back to the authorization endpoint.
+type PushedAuthorizeHandler interface {
+	HandlePushedAuthorizeRequest(ctx context.Context, req *AuthorizeRequest, res *PushedAuthorizeResponse) error
+	// GetPushedAuthorizeRequest returns the parameters pushed for requestURI, or ErrUnknownRequest if the handler did
+	// not issue it.
+	GetPushedAuthorizeRequest(ctx context.Context, requestURI string, client Client) (url.Values, error)
+}
+
+// PushedAuthorizeClient is implemented by clients that can be required to push their authorization requests.
+type PushedAuthorizeClient interface {
+	IsPushedAuthorizationRequired() bool
+}
+
+// NewPushedAuthorizeRequest authenticates the client and validates the pushed parameters exactly like the
+// authorization endpoint would, so that errors are reported to the client instead of the end-user.
+func (o *OAuth2) NewPushedAuthorizeRequest(ctx context.Context, req *http.Request) (*AuthorizeRequest, error) {
+	ar := NewAuthorizeRequest()
+
+	if req.Method != http.MethodPost {
+		return ar, ErrInvalidRequest.WithHint("HTTP method is '%s', expected 'POST'.", req.Method)
+	}
+
+	form, err := x.BindPostForm(req)
+	if err != nil {
+		return ar, ErrInvalidRequest.
+			WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").
+			WithWrap(err)
+	}
+	ar.Form = form
+
+	if form.Has("request_uri") {
+		return ar, ErrInvalidRequest.WithHint("The 'request_uri' parameter must not be used with pushed authorization requests.")
+	}
+
+	client, err := o.AuthenticateClient(ctx, req, form)
+	if err != nil {
+		return ar, err
+	}
+
+	if form.Get("client_id") == "" {
+		form.Set("client_id", client.GetID())
+	}
+	ar.Client = client
+
+	if err = o.validateAuthorizeRequest(ctx, ar); err != nil {
+		return ar, err
+	}
+
+	return ar, nil
+}
+
+func (o *OAuth2) NewPushedAuthorizeResponse(ctx context.Context, req *AuthorizeRequest, session Session) (*PushedAuthorizeResponse, error) {
+	response := &PushedAuthorizeResponse{}
+
+	req.Session = session
+	handled := false
+	for _, ph := range o.pushedAuthorizeHandlers {
+		he := ph.HandlePushedAuthorizeRequest(ctx, req, response)
+		if he == nil {
+			handled = true
+		} else if errors.Is(he, ErrUnknownRequest) {
+			continue
+		} else {
+			return nil, he
+		}
+	}
+
+	if !handled {
+		return nil, ErrInvalidRequest.WithHint("Pushed authorization requests are not supported by this authorization server.")
+	}
+
+	return response, nil
+}
+
+func (o *OAuth2) WritePushedAuthorizeError(ctx context.Context, rw http.ResponseWriter, err error) {
+	o.writeError(ctx, rw, err)
+}
+
+func (o *OAuth2) WritePushedAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, resp *PushedAuthorizeResponse) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	jsonPayload, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	rw.WriteHeader(http.StatusCreated)
+	_, _ = rw.Write(jsonPayload)
+}
+
+// authorizeRequestFromPAR replaces the parameters of an authorization request with the pushed ones when it refers to
+// a request_uri handle. Only client_id is read from the request itself, every other parameter is ignored.
+func (o *OAuth2) authorizeRequestFromPAR(ctx context.Context, ar *AuthorizeRequest) error {
+	requestURI := ar.Form.Get("request_uri")
+	if !strings.HasPrefix(requestURI, PushedAuthorizeRequestURIPrefix) {
+		return nil
+	}
+
+	client, err := o.store.GetClient(ctx, ar.Form.Get("client_id"))
+	if err != nil {
+		return ErrInvalidClient.
+			WithHint("The requested OAuth 2.0 Client does not exist.").
+			WithWrap(err).
+			WithDebug("%s", err)
+	}
+
+	for _, ph := range o.pushedAuthorizeHandlers {
+		form, err := ph.GetPushedAuthorizeRequest(ctx, requestURI, client)
+		if errors.Is(err, ErrUnknownRequest) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		ar.Client = client
+		ar.Form = form
+		ar.PushedRequestURI = requestURI
+		return nil
+	}
+
+	return ErrInvalidRequestURI.WithHint("The 'request_uri' is invalid, expired or has already been used.")
+}
+
+// isPushedAuthorizationRequired reports whether the client must use the pushed authorization request endpoint.
+func (o *OAuth2) isPushedAuthorizationRequired(client Client) bool {
+	if o.config.IsPushedAuthorizationRequired() {
+		return true
+	}
+
+	pc, ok := client.(PushedAuthorizeClient)
+	return ok && pc.IsPushedAuthorizationRequired()
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/tuanta7/hydros/core/x"
+)
+
+type RevocationRequest struct {
+	Token         string    `json:"token" form:"token"`
+	TokenTypeHint TokenType `json:"token_type_hint" form:"token_type_hint"`
+	Client        Client    `json:"-" form:"-"`
+}
+
+// NewRevocationRequest handles an RFC 7009 token revocation request. Tokens that are unknown to the server are not
+// considered an error, the response must be the same as if the token has been revoked.
+func (o *OAuth2) NewRevocationRequest(ctx context.Context, req *http.Request) error {
+	if req.Method != http.MethodPost {
+		return ErrInvalidRequest.WithHint("HTTP method is '%s', expected 'POST'.", req.Method)
+	}
+
+	form, err := x.BindPostForm(req)
+	if err != nil {
+		return ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err)
+	} else if len(form) == 0 {
+		return ErrInvalidRequest.WithHint("The POST body can not be empty.")
+	}
+
+	client, err := o.AuthenticateClient(ctx, req, form)
+	if err != nil {
+		return err
+	}
+
+	rr := &RevocationRequest{
+		Token:         form.Get("token"),
+		TokenTypeHint: TokenType(form.Get("token_type_hint")),
+		Client:        client,
+	}
+
+	if rr.Token == "" {
+		return ErrInvalidRequest.WithHint("The 'token' parameter is required.")
+	}
+
+	switch rr.TokenTypeHint {
+	case "", AccessToken, RefreshToken:
+	default:
+		return ErrUnsupportedTokenType.WithHint("Token type hint '%s' is not supported.", rr.TokenTypeHint)
+	}
+
+	for _, rh := range o.revocationHandlers {
+		if re := rh.RevokeToken(ctx, rr); re == nil || errors.Is(re, ErrUnknownRequest) {
+			continue
+		} else if re != nil {
+			return re
+		}
+	}
+
+	return nil
+}
+
+func (o *OAuth2) WriteRevocationResponse(ctx context.Context, rw http.ResponseWriter, err error) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	if err == nil {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if errors.Is(err, ErrInvalidRequest) ||
+		errors.Is(err, ErrInvalidClient) ||
+		errors.Is(err, ErrUnauthorizedClient) ||
+		errors.Is(err, ErrUnsupportedTokenType) {
+		o.writeError(ctx, rw, err)
+		return
+	}
+
+	o.writeError(ctx, rw, ErrServerError.WithWrap(err).WithDebug("%s", err))
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRevocationHandler records the revocation requests, it revokes nothing.
+type testRevocationHandler struct {
+	requests []*RevocationRequest
+	err      error
+}
+
+func (h *testRevocationHandler) RevokeToken(ctx context.Context, rr *RevocationRequest) error {
+	h.requests = append(h.requests, rr)
+	return h.err
+}
+
+func TestNewRevocationRequest(t *testing.T) {
+	hashedSecret, err := NewBCryptHasher(4).Hash(context.Background(), []byte("secret"))
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		form       url.Values
+		handlerErr error
+		wantStatus int
+		wantHint   TokenType
+	}{
+		{
+			name:       "without hint",
+			form:       url.Values{"token": {"token"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "refresh_token hint",
+			form:       url.Values{"token": {"token"}, "token_type_hint": {"refresh_token"}},
+			wantStatus: http.StatusOK,
+			wantHint:   RefreshToken,
+		},
+		{
+			name:       "unsupported hint",
+			form:       url.Values{"token": {"token"}, "token_type_hint": {"id_token"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing token",
+			form:       url.Values{"token_type_hint": {"access_token"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "token of another client",
+			form:       url.Values{"token": {"token"}},
+			handlerErr: ErrUnauthorizedClient,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "handler does not support the token",
+			form:       url.Values{"token": {"token"}},
+			handlerErr: ErrUnknownRequest,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &testRevocationHandler{err: tc.handlerErr}
+			o := &OAuth2{
+				config:             newTestConfig(),
+				store:              newTestStore(&testClient{id: "client", hashedSecret: hashedSecret}),
+				revocationHandlers: []RevocationHandler{handler},
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/oauth/revoke", strings.NewReader(tc.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.SetBasicAuth("client", "secret")
+
+			rw := httptest.NewRecorder()
+			o.WriteRevocationResponse(context.Background(), rw, o.NewRevocationRequest(context.Background(), r))
+			assert.Equal(t, tc.wantStatus, rw.Code)
+			assert.Equal(t, "no-store", rw.Header().Get("Cache-Control"))
+
+			if tc.wantStatus == http.StatusOK && tc.handlerErr == nil {
+				require.Len(t, handler.requests, 1)
+				assert.Equal(t, tc.wantHint, handler.requests[0].TokenTypeHint)
+				assert.Equal(t, "client", handler.requests[0].Client.GetID())
+			}
+		})
+	}
+}
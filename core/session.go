@@ -19,3 +19,17 @@ type JWTProfileSession interface {
 type ExtraClaimsSession interface {
 	GetExtraClaims() map[string]any
 }
+
+// Confirmation is the cnf claim of a sender-constrained token, see RFC 7800 section 3.1.
+type Confirmation struct {
+	// JKT is the SHA-256 thumbprint of the DPoP key the token is bound to, see RFC 9449 section 6.
+	JKT string `json:"jkt,omitempty"`
+	// X5TS256 is the SHA-256 thumbprint of the client certificate the token is bound to, see RFC 8705 section 3.1.
+	X5TS256 string `json:"x5t#S256,omitempty"`
+}
+
+// ConfirmationSession is implemented by sessions that can bind the issued tokens to a key held by the client.
+type ConfirmationSession interface {
+	GetConfirmation() *Confirmation
+	SetConfirmation(cnf *Confirmation)
+}
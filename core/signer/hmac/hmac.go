@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strings"
@@ -18,6 +20,7 @@ var b64 = base64.URLEncoding.WithPadding(base64.NoPadding)
 type SignerConfigurator interface {
 	core.TokenEntropyProvider
 	core.GlobalSecretProvider
+	core.RotatedSecretsProvider
 	core.HMACHashingProvider
 }
 
@@ -31,6 +34,12 @@ func NewSigner(config SignerConfigurator) (*DefaultSigner, error) {
 		return nil, fmt.Errorf("secret for signing HMAC-SHA512/256 is expected to be at least 64 bytes long, got %d bytes", len(secret))
 	}
 
+	for i, rotated := range config.GetRotatedSecrets() {
+		if len(rotated) < 64 {
+			return nil, fmt.Errorf("rotated secret #%d is expected to be at least 64 bytes long, got %d bytes", i+1, len(rotated))
+		}
+	}
+
 	return &DefaultSigner{
 		config: config,
 	}, nil
@@ -94,12 +103,30 @@ func (s *DefaultSigner) Validate(ctx context.Context, token string) (err error)
 		return fmt.Errorf("secret for signing HMAC-SHA512/256 is expected to be at least 64 bytes long, got %d bytes", len(secret))
 	}
 
-	expectedSignature := s.hmacSign(decodedTokenKey, secret[:64])
-	if !hmac.Equal(expectedSignature, decodedTokenSignature) {
-		return core.ErrTokenSignatureMismatch
+	// The global secret is tried first, the rotated secrets only keep tokens issued before the rotation valid.
+	for _, secret := range append([][]byte{secret}, s.config.GetRotatedSecrets()...) {
+		if len(secret) < 64 {
+			continue
+		}
+
+		expectedSignature := s.hmacSign(decodedTokenKey, secret[:64])
+		if hmac.Equal(expectedSignature, decodedTokenSignature) {
+			return nil
+		}
+	}
+
+	return core.ErrTokenSignatureMismatch
+}
+
+// SecretID returns a short fingerprint of a secret which can be stored along with the tokens it signed, so it is
+// possible to tell when a rotated secret no longer has any live token.
+func SecretID(secret []byte) string {
+	if len(secret) > 64 {
+		secret = secret[:64]
 	}
 
-	return nil
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:8])
 }
 
 func (s *DefaultSigner) hmacSign(tokenKey []byte, secret []byte) []byte {
@@ -0,0 +1,97 @@
+package hmac
+
+import (
+	"context"
+	"crypto/sha512"
+	"hash"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+)
+
+type testConfig struct {
+	secret         []byte
+	rotatedSecrets [][]byte
+}
+
+func (c *testConfig) GetTokenEntropy() int            { return 32 }
+func (c *testConfig) GetGlobalSecret() []byte         { return c.secret }
+func (c *testConfig) GetRotatedSecrets() [][]byte     { return c.rotatedSecrets }
+func (c *testConfig) GetHMACHasher() func() hash.Hash { return sha512.New512_256 }
+
+func newTestSecret(c string) []byte {
+	return []byte(strings.Repeat(c, 64))
+}
+
+func TestNewSigner_SecretLength(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  *testConfig
+		wantErr bool
+	}{
+		{name: "valid secrets", config: &testConfig{secret: newTestSecret("a"), rotatedSecrets: [][]byte{newTestSecret("b")}}},
+		{name: "short global secret", config: &testConfig{secret: []byte("short")}, wantErr: true},
+		{name: "short rotated secret", config: &testConfig{secret: newTestSecret("a"), rotatedSecrets: [][]byte{newTestSecret("b"), []byte("short")}}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSigner(tc.config)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDefaultSigner_Rotation(t *testing.T) {
+	ctx := context.Background()
+	cfg := &testConfig{secret: newTestSecret("a")}
+	signer, err := NewSigner(cfg)
+	require.NoError(t, err)
+
+	token, signature, err := signer.Generate(ctx, core.NewRequest())
+	require.NoError(t, err)
+	assert.Equal(t, signature, signer.GetSignature(token))
+	require.NoError(t, signer.Validate(ctx, token))
+
+	// the previous global secret keeps validating once it is moved to the rotated secrets
+	cfg.secret, cfg.rotatedSecrets = newTestSecret("b"), [][]byte{newTestSecret("a")}
+	assert.NoError(t, signer.Validate(ctx, token))
+
+	// new tokens are signed with the new global secret only
+	newToken, _, err := signer.Generate(ctx, core.NewRequest())
+	require.NoError(t, err)
+	assert.NoError(t, signer.Validate(ctx, newToken))
+
+	cfg.rotatedSecrets = nil
+	assert.ErrorIs(t, signer.Validate(ctx, token), core.ErrTokenSignatureMismatch)
+	assert.NoError(t, signer.Validate(ctx, newToken))
+}
+
+func TestDefaultSigner_UnknownSecret(t *testing.T) {
+	ctx := context.Background()
+	other, err := NewSigner(&testConfig{secret: newTestSecret("x")})
+	require.NoError(t, err)
+
+	token, _, err := other.Generate(ctx, core.NewRequest())
+	require.NoError(t, err)
+
+	signer, err := NewSigner(&testConfig{secret: newTestSecret("a"), rotatedSecrets: [][]byte{newTestSecret("b")}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, signer.Validate(ctx, token), core.ErrTokenSignatureMismatch)
+}
+
+func TestDefaultSigner_InvalidFormat(t *testing.T) {
+	signer, err := NewSigner(&testConfig{secret: newTestSecret("a")})
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "no-separator", ".signature", "key."} {
+		assert.ErrorIs(t, signer.Validate(context.Background(), token), core.ErrInvalidTokenFormat, token)
+	}
+}
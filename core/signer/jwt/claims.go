@@ -1,9 +1,11 @@
 package jwt
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core"
 )
 
 type IDTokenClaims struct {
@@ -15,6 +17,7 @@ type IDTokenClaims struct {
 	AMR             []string       `json:"amr"`
 	CodeHash        string         `json:"c_hash"`
 	AccessTokenHash string         `json:"at_hash"`
+	SessionID       string         `json:"sid,omitempty"`
 	Extra           map[string]any `json:"ext"`
 }
 
@@ -25,4 +28,73 @@ type Claims struct {
 	AuthTime int64  `json:"auth_time,omitempty"`
 	ACR      string `json:"acr,omitempty"`
 	AMR      string `json:"amr,omitempty"`
+	// Actor is set on tokens obtained through token exchange, see RFC 8693 section 4.1.
+	Actor *ActorClaims `json:"act,omitempty"`
+	// Confirmation is set on sender-constrained tokens, see RFC 9449 section 6.1 and RFC 8705 section 3.1.
+	Confirmation *core.Confirmation `json:"cnf,omitempty"`
+	// Extra holds the custom claims of the session, e.g. the ones set when the consent request was accepted.
+	Extra map[string]any `json:"ext,omitempty"`
+}
+
+// ActorClaims identifies the party acting on behalf of the subject. Prior actors of a delegation chain are nested.
+type ActorClaims struct {
+	Subject  string       `json:"sub"`
+	ClientID string       `json:"client_id,omitempty"`
+	Actor    *ActorClaims `json:"act,omitempty"`
+}
+
+// reservedClaims can never be set by custom claims, whether they are present in the token or not.
+var reservedClaims = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+	"client_id": true, "scope": true, "auth_time": true, "acr": true, "amr": true, "act": true, "cnf": true,
+	"nonce": true, "rat": true, "c_hash": true, "at_hash": true, "sid": true, "azp": true, "ext": true,
+}
+
+// ToMapClaims returns the claims of an ID token with the custom claims at the top level, as relying parties read them
+// the same way as the standard claims.
+func (c *IDTokenClaims) ToMapClaims() (jwt.MapClaims, error) {
+	claims, err := toMapClaims(c)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(claims, "ext")
+	for name, value := range c.Extra {
+		if !reservedClaims[name] {
+			claims[name] = value
+		}
+	}
+
+	return claims, nil
+}
+
+// ToMapClaims returns the claims of an access token with the allowed custom claims copied to the top level. Every
+// custom claim stays in the ext claim.
+func (c *Claims) ToMapClaims(topLevel []string) (jwt.MapClaims, error) {
+	claims, err := toMapClaims(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range topLevel {
+		if value, ok := c.Extra[name]; ok && !reservedClaims[name] {
+			claims[name] = value
+		}
+	}
+
+	return claims, nil
+}
+
+func toMapClaims(v any) (jwt.MapClaims, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	if err = json.Unmarshal(raw, &claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
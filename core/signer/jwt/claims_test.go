@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+)
+
+func TestToMapClaims_ReservedNames(t *testing.T) {
+	cases := []struct {
+		name  string
+		claim string
+		want  any // the value of the claim in the token, nil when the token has no such claim
+	}{
+		{name: "issuer", claim: "iss", want: "https://auth.example.com"},
+		{name: "subject", claim: "sub", want: "alice"},
+		{name: "audience", claim: "aud", want: []any{"client"}},
+		{name: "expiration", claim: "exp", want: float64(2000000000)},
+		{name: "issued at", claim: "iat", want: float64(1000000000)},
+		{name: "token id", claim: "jti", want: "token-id"},
+		{name: "not before", claim: "nbf"},
+		{name: "client id", claim: "client_id", want: "client"},
+		{name: "scope", claim: "scope", want: "openid"},
+		{name: "actor", claim: "act"},
+		{name: "confirmation", claim: "cnf", want: map[string]any{"jkt": "thumbprint"}},
+		{name: "auth time", claim: "auth_time"},
+		{name: "nonce", claim: "nonce"},
+		{name: "access token hash", claim: "at_hash"},
+		{name: "session id", claim: "sid"},
+		{name: "authorized party", claim: "azp"},
+	}
+
+	registered := jwt.RegisteredClaims{
+		ID:        "token-id",
+		Issuer:    "https://auth.example.com",
+		Subject:   "alice",
+		Audience:  jwt.ClaimStrings{"client"},
+		ExpiresAt: jwt.NewNumericDate(time.Unix(2000000000, 0)),
+		IssuedAt:  jwt.NewNumericDate(time.Unix(1000000000, 0)),
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			extra := map[string]any{tc.claim: "overridden", "tenant": "acme"}
+
+			access := &Claims{
+				RegisteredClaims: registered,
+				ClientID:         "client",
+				Scope:            "openid",
+				Confirmation:     &core.Confirmation{JKT: "thumbprint"},
+				Extra:            extra,
+			}
+
+			claims, err := access.ToMapClaims([]string{tc.claim, "tenant"})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, claims[tc.claim], "access token")
+			assert.Equal(t, "acme", claims["tenant"])
+			assert.Equal(t, extra, claims["ext"])
+
+			id := &IDTokenClaims{RegisteredClaims: registered, Extra: extra}
+			claims, err = id.ToMapClaims()
+			require.NoError(t, err)
+			assert.NotEqual(t, "overridden", claims[tc.claim], "ID token")
+			assert.Equal(t, "acme", claims["tenant"])
+			assert.NotContains(t, claims, "ext")
+		})
+	}
+}
@@ -2,6 +2,9 @@ package jwt
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"errors"
 	"strings"
@@ -13,11 +16,17 @@ import (
 
 var SupportedAlgorithm = map[string]gojwt.SigningMethod{
 	"RS512": gojwt.SigningMethodRS512,
+	"RS384": gojwt.SigningMethodRS384,
 	"RS256": gojwt.SigningMethodRS256,
+	"PS512": gojwt.SigningMethodPS512,
+	"PS384": gojwt.SigningMethodPS384,
+	"PS256": gojwt.SigningMethodPS256,
+	"ES512": gojwt.SigningMethodES512,
+	"ES384": gojwt.SigningMethodES384,
+	"ES256": gojwt.SigningMethodES256,
+	"EdDSA": gojwt.SigningMethodEdDSA,
 	"HS512": gojwt.SigningMethodHS512,
 	"HS256": gojwt.SigningMethodHS256,
-	//"ES256": gojwt.SigningMethodES256,
-	//"ES512": gojwt.SigningMethodES512,
 }
 
 type Configurator interface {
@@ -25,7 +34,8 @@ type Configurator interface {
 }
 
 // GetPrivateKeyFn return the current active key or an inactive key but still allowed to validate token signature.
-// The kid is optional, which is used to select the key that might be inactivated.
+// The kid is optional, which is used to select the key that might be inactivated. It must fail rather than create a key
+// when no key with the given kid can be used.
 type GetPrivateKeyFn func(ctx context.Context, kid ...string) (any, error)
 
 type DefaultSigner struct {
@@ -40,10 +50,29 @@ func NewSigner(cfg Configurator, fn GetPrivateKeyFn) (*DefaultSigner, error) {
 	}, nil
 }
 
+// Generate signs the claims with the active key. The kid header is set to the id of that key, so the token can still be
+// verified after the key is rotated.
 func (s *DefaultSigner) Generate(ctx context.Context, claims gojwt.Claims, headers ...map[string]any) (string, string, error) {
-	privateKey, algorithm, err := s.getSignKey(ctx)
+	privateKey, algorithm, kid, err := s.getSignKey(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if algorithm == nil {
+		return "", "", errors.New("signing algorithm is not supported")
+	}
 
 	token := gojwt.NewWithClaims(algorithm, claims)
+	for _, h := range headers {
+		for k, v := range h {
+			token.Header[k] = v
+		}
+	}
+
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
 	signedToken, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", "", err
@@ -52,25 +81,34 @@ func (s *DefaultSigner) Generate(ctx context.Context, claims gojwt.Claims, heade
 	return signedToken, s.GetSignature(signedToken), nil
 }
 
-func (s *DefaultSigner) getSignKey(ctx context.Context) (any, gojwt.SigningMethod, error) {
+func (s *DefaultSigner) getSignKey(ctx context.Context) (any, gojwt.SigningMethod, string, error) {
 	key, err := s.getPrivateKeyFn(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 
 	var privateKey any
 	var algorithm gojwt.SigningMethod
+	var kid string
 
 	switch t := key.(type) {
 	case *jose.JSONWebKey:
 		privateKey = t.Key
 		algorithm = SupportedAlgorithm[t.Algorithm]
+		kid = t.KeyID
 	case jose.JSONWebKey:
 		privateKey = t.Key
 		algorithm = SupportedAlgorithm[t.Algorithm]
+		kid = t.KeyID
 	case *rsa.PrivateKey:
 		privateKey = t
 		algorithm = gojwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		privateKey = t
+		algorithm = ecdsaSigningMethod(t)
+	case ed25519.PrivateKey:
+		privateKey = t
+		algorithm = gojwt.SigningMethodEdDSA
 	case string:
 		privateKey = []byte(t)
 		algorithm = gojwt.SigningMethodHS256
@@ -79,7 +117,7 @@ func (s *DefaultSigner) getSignKey(ctx context.Context) (any, gojwt.SigningMetho
 		algorithm = gojwt.SigningMethodHS256
 	}
 
-	return privateKey, algorithm, nil
+	return privateKey, algorithm, kid, nil
 }
 
 func (s *DefaultSigner) GetSignature(token string) string {
@@ -91,14 +129,16 @@ func (s *DefaultSigner) GetSignature(token string) string {
 }
 
 func (s *DefaultSigner) Validate(ctx context.Context, token string) (err error) {
-	publicKey, err := s.getVerificationKey(ctx)
-	if err != nil {
-		return err
-	}
+	return s.Decode(ctx, token, &Claims{})
+}
 
-	parser := gojwt.Parser{}
-	t, err := parser.ParseWithClaims(token, &Claims{}, func(t *gojwt.Token) (any, error) {
-		return publicKey, nil
+// Decode verifies the token signature and unmarshals the payload into claims. The parser options can be used to relax
+// the claims validation, e.g. to accept an expired ID token as a hint.
+func (s *DefaultSigner) Decode(ctx context.Context, token string, claims gojwt.Claims, opts ...gojwt.ParserOption) (err error) {
+	parser := gojwt.NewParser(opts...)
+	t, err := parser.ParseWithClaims(token, claims, func(t *gojwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.getVerificationKey(ctx, t.Method, kid)
 	})
 	if err != nil {
 		return toRFCErr(err)
@@ -111,25 +151,46 @@ func (s *DefaultSigner) Validate(ctx context.Context, token string) (err error)
 	return nil
 }
 
-func (s *DefaultSigner) getVerificationKey(ctx context.Context) (any, error) {
-	key, err := s.getPrivateKeyFn(ctx)
+// getVerificationKey resolves the key identified by kid, which may be a key retired by a rotation. Tokens without a kid
+// were signed before keys were identified and are verified against the active key.
+func (s *DefaultSigner) getVerificationKey(ctx context.Context, method gojwt.SigningMethod, kid string) (any, error) {
+	var key any
+	var err error
+
+	if kid != "" {
+		key, err = s.getPrivateKeyFn(ctx, kid)
+	} else {
+		key, err = s.getPrivateKeyFn(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	var algorithm string
 	if t, ok := key.(*jose.JSONWebKey); ok {
-		key = t.Key
+		key, algorithm = t.Key, t.Algorithm
 	}
 
 	if t, ok := key.(jose.JSONWebKey); ok {
-		key = t.Key
+		key, algorithm = t.Key, t.Algorithm
+	}
+
+	// a key is bound to one algorithm, e.g. an RSA key must not verify a token whose alg header was changed to HS256
+	if algorithm != "" && method.Alg() != algorithm {
+		return nil, gojwt.ErrTokenSignatureInvalid
 	}
 
 	switch t := key.(type) {
 	case *rsa.PrivateKey:
 		return t.Public(), nil
-	case string:
+	case *ecdsa.PrivateKey:
+		return t.Public(), nil
+	case ed25519.PrivateKey:
+		return t.Public(), nil
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
 		return t, nil
+	case string:
+		return []byte(t), nil
 	case []byte:
 		return t, nil
 	}
@@ -137,6 +198,18 @@ func (s *DefaultSigner) getVerificationKey(ctx context.Context) (any, error) {
 	return nil, errors.New("public key is not set")
 }
 
+// ecdsaSigningMethod returns the algorithm of RFC 7518 section 3.4 that matches the curve of the key.
+func ecdsaSigningMethod(key *ecdsa.PrivateKey) gojwt.SigningMethod {
+	switch key.Curve {
+	case elliptic.P384():
+		return gojwt.SigningMethodES384
+	case elliptic.P521():
+		return gojwt.SigningMethodES512
+	default:
+		return gojwt.SigningMethodES256
+	}
+}
+
 func toRFCErr(err error) *core.RFC6749Error {
 	switch {
 	case err == nil:
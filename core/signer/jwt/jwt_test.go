@@ -1 +1,179 @@
 package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct{}
+
+func (testConfig) GetAccessTokenIssuer() string { return "https://auth.example.com" }
+
+// testKeyring mimics the JWK use case: the active key signs, retired keys can only be resolved by kid.
+type testKeyring struct {
+	active string
+	keys   map[string]any
+}
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newTestJWK(t *testing.T, kid string) *jose.JSONWebKey {
+	return &jose.JSONWebKey{Key: newTestRSAKey(t), KeyID: kid, Algorithm: "RS256", Use: "sig"}
+}
+
+func (k *testKeyring) add(kid string, key any) {
+	if k.keys == nil {
+		k.keys = map[string]any{}
+	}
+	k.keys[kid] = key
+	k.active = kid
+}
+
+func (k *testKeyring) getPrivateKey(ctx context.Context, kid ...string) (any, error) {
+	id := k.active
+	if len(kid) > 0 {
+		id = kid[0]
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return key, nil
+}
+
+func newTestSigner(t *testing.T, keyring *testKeyring) *DefaultSigner {
+	signer, err := NewSigner(testConfig{}, keyring.getPrivateKey)
+	require.NoError(t, err)
+	return signer
+}
+
+func newTestClaims() *Claims {
+	return &Claims{
+		RegisteredClaims: gojwt.RegisteredClaims{
+			Subject:   "alice",
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		ClientID: "client",
+	}
+}
+
+func TestDefaultSigner_GenerateSetsKid(t *testing.T) {
+	keyring := &testKeyring{}
+	keyring.add("a", newTestJWK(t, "a"))
+	signer := newTestSigner(t, keyring)
+
+	token, signature, err := signer.Generate(context.Background(), newTestClaims())
+	require.NoError(t, err)
+	assert.Equal(t, signer.GetSignature(token), signature)
+
+	parsed, _, err := gojwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "a", parsed.Header["kid"])
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+}
+
+func TestDefaultSigner_VerifiesRotatedKeyByKid(t *testing.T) {
+	ctx := context.Background()
+	keyring := &testKeyring{}
+	keyring.add("a", newTestJWK(t, "a"))
+	signer := newTestSigner(t, keyring)
+
+	tokenA, _, err := signer.Generate(ctx, newTestClaims())
+	require.NoError(t, err)
+
+	keyring.add("b", newTestJWK(t, "b"))
+	tokenB, _, err := signer.Generate(ctx, newTestClaims())
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	require.NoError(t, signer.Decode(ctx, tokenA, claims))
+	assert.Equal(t, "alice", claims.Subject)
+	assert.NoError(t, signer.Validate(ctx, tokenB))
+}
+
+func TestDefaultSigner_UnknownKid(t *testing.T) {
+	ctx := context.Background()
+	keyring := &testKeyring{}
+	keyring.add("a", newTestJWK(t, "a"))
+	signer := newTestSigner(t, keyring)
+
+	token, _, err := signer.Generate(ctx, newTestClaims())
+	require.NoError(t, err)
+
+	// the key is removed from the key set, its tokens must not fall back to the active key
+	keyring.add("b", newTestJWK(t, "b"))
+	delete(keyring.keys, "a")
+
+	assert.Error(t, signer.Validate(ctx, token))
+}
+
+func TestDefaultSigner_NoKidFallsBackToActiveKey(t *testing.T) {
+	ctx := context.Background()
+	jwk := newTestJWK(t, "a")
+	keyring := &testKeyring{}
+	keyring.add("a", jwk)
+	signer := newTestSigner(t, keyring)
+
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodRS256, newTestClaims()).SignedString(jwk.Key)
+	require.NoError(t, err)
+	assert.NoError(t, signer.Validate(ctx, token))
+
+	keyring.add("b", newTestJWK(t, "b"))
+	assert.Error(t, signer.Validate(ctx, token))
+}
+
+func TestDefaultSigner_RejectsAlgorithmConfusion(t *testing.T) {
+	ctx := context.Background()
+	jwk := newTestJWK(t, "a")
+	publicKey, err := x509.MarshalPKIXPublicKey(&jwk.Key.(*rsa.PrivateKey).PublicKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name string
+		key  any
+	}{
+		{name: "key with an algorithm", key: jwk},
+		{name: "raw RSA key", key: jwk.Key},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyring := &testKeyring{}
+			keyring.add("a", tc.key)
+			signer := newTestSigner(t, keyring)
+
+			// the public key is known to everyone, it must not be usable as an HMAC secret
+			forged := gojwt.NewWithClaims(gojwt.SigningMethodHS256, newTestClaims())
+			forged.Header["kid"] = "a"
+			token, err := forged.SignedString(publicKey)
+			require.NoError(t, err)
+
+			assert.Error(t, signer.Validate(ctx, token))
+		})
+	}
+}
+
+func TestDefaultSigner_RejectsNone(t *testing.T) {
+	keyring := &testKeyring{}
+	keyring.add("a", newTestJWK(t, "a"))
+	signer := newTestSigner(t, keyring)
+
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodNone, newTestClaims()).SignedString(gojwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	assert.Error(t, signer.Validate(context.Background(), token))
+}
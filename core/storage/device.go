@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+)
+
+// DeviceCodeStorage stores device authorization requests, which can be looked up by either the device code or the
+// user code signature.
+type DeviceCodeStorage interface {
+	CreateDeviceCodeSession(ctx context.Context, deviceCodeSignature, userCodeSignature string, req *core.Request) error
+	GetDeviceCodeSession(ctx context.Context, deviceCodeSignature string, session core.Session) (*core.DeviceRequest, error)
+	GetUserCodeSession(ctx context.Context, userCodeSignature string, session core.Session) (*core.DeviceRequest, error)
+	// HandleUserCodeSession records the decision of the end-user. When accepted, the granted scope, audience and
+	// session of req replace the stored ones.
+	HandleUserCodeSession(ctx context.Context, userCodeSignature string, state core.UserCodeState, req *core.Request) error
+	UpdateDeviceCodePolledAt(ctx context.Context, deviceCodeSignature string, polledAt time.Time) error
+	// InvalidateDeviceCodeSession returns core.ErrInactiveToken when the device code has already been exchanged, so a
+	// device code polled concurrently is only exchanged once.
+	InvalidateDeviceCodeSession(ctx context.Context, deviceCodeSignature string) error
+}
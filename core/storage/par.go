@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+)
+
+// PARStorage stores pushed authorization requests under their request_uri handle.
+type PARStorage interface {
+	CreatePARSession(ctx context.Context, requestURI string, req *core.AuthorizeRequest, expiresAt time.Time) error
+	// GetPARSession returns core.ErrNotFound when the handle does not exist or has expired.
+	GetPARSession(ctx context.Context, requestURI string) (*core.Request, error)
+	DeletePARSession(ctx context.Context, requestURI string) error
+}
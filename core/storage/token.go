@@ -15,6 +15,8 @@ type TokenStorage interface {
 type AuthorizationCodeStorage interface {
 	CreateAuthorizeCodeSession(ctx context.Context, signature string, req *core.Request) (err error)
 	GetAuthorizationCodeSession(ctx context.Context, signature string, session core.Session) (*core.Request, error)
+	// InvalidateAuthorizeCodeSession returns core.ErrInactiveToken when the authorization code is no longer active, so
+	// that only one of the concurrent requests using the same code can exchange it.
 	InvalidateAuthorizeCodeSession(ctx context.Context, signature string) (err error)
 }
 
@@ -29,6 +31,8 @@ type RefreshTokenStorage interface {
 	CreateRefreshTokenSession(ctx context.Context, signature string, accessSignature string, req *core.Request) (err error)
 	GetRefreshTokenSession(ctx context.Context, signature string, session core.Session) (*core.Request, error)
 	DeleteRefreshTokenSession(ctx context.Context, signature string) (err error)
+	// RotateRefreshToken returns core.ErrInactiveToken when the refresh token is no longer active, so that only one of
+	// the concurrent requests using the same refresh token can rotate it.
 	RotateRefreshToken(ctx context.Context, requestID string, signature string) (err error)
 	RevokeRefreshToken(ctx context.Context, requestID string) error
 }
@@ -16,5 +16,6 @@ type OpaqueSigner interface {
 type JWTSigner interface {
 	Generate(ctx context.Context, claims jwt.Claims, headers ...map[string]any) (token string, signature string, err error)
 	Validate(ctx context.Context, token string) (err error)
+	Decode(ctx context.Context, token string, claims jwt.Claims, opts ...jwt.ParserOption) (err error)
 	GetSignature(token string) string
 }
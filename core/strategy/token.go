@@ -30,3 +30,16 @@ type AuthorizeCodeStrategy interface {
 	GenerateAuthorizeCode(ctx context.Context, request *core.Request) (code string, signature string, err error)
 	ValidateAuthorizeCode(ctx context.Context, request *core.Request, code string) (err error)
 }
+
+type DeviceCodeStrategy interface {
+	DeviceCodeSignature(ctx context.Context, code string) string
+	GenerateDeviceCode(ctx context.Context, request *core.Request) (code string, signature string, err error)
+	ValidateDeviceCode(ctx context.Context, request *core.Request, code string) (err error)
+}
+
+type UserCodeStrategy interface {
+	UserCodeSignature(ctx context.Context, code string) string
+	// UserCodeSignatures returns every signature the user code may be stored under, the current one first.
+	UserCodeSignatures(ctx context.Context, code string) []string
+	GenerateUserCode(ctx context.Context) (code string, signature string, err error)
+}
@@ -36,6 +36,8 @@ type TokenResponse struct {
 	Scope        string        `json:"scope,omitempty" form:"scope"`
 	RefreshToken string        `json:"refresh_token,omitempty" form:"refresh_token"`
 	IDToken      string        `json:"id_token,omitempty" form:"id_token"`
+	// IssuedTokenType is only set in token exchange responses.
+	IssuedTokenType string `json:"issued_token_type,omitempty" form:"issued_token_type"`
 }
 
 func NewTokenResponse() *TokenResponse {
@@ -90,9 +92,27 @@ func (o *OAuth2) NewTokenRequest(ctx context.Context, req *http.Request, session
 		return nil, ErrInvalidRequest.WithHint("The requested grant type is not supported by this authorization server.")
 	}
 
+	if err = o.handleTokenDPoPProof(ctx, req, tokenRequest); err != nil {
+		return nil, err
+	}
+
+	if err = o.handleTokenCertificateBinding(req, tokenRequest); err != nil {
+		return nil, err
+	}
+
 	return tokenRequest, nil
 }
 
+// AccessTokenType returns the token_type of the access token issued for the request, which is DPoP when the tokens
+// are bound to a DPoP key.
+func (r *TokenRequest) AccessTokenType() string {
+	if cnf := confirmationFromSession(r.Session); cnf != nil && cnf.JKT != "" {
+		return DPoPTokenType
+	}
+
+	return BearerToken
+}
+
 func (o *OAuth2) NewTokenResponse(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
 	tokenResponse := NewTokenResponse()
 
@@ -115,6 +135,10 @@ func (o *OAuth2) NewTokenResponse(ctx context.Context, req *TokenRequest) (*Toke
 }
 
 func (o *OAuth2) WriteTokenError(ctx context.Context, rw http.ResponseWriter, req *TokenRequest, err error) {
+	if errors.Is(err, ErrUseDPoPNonce) {
+		rw.Header().Set(DPoPNonceHeader, o.NewDPoPNonce())
+	}
+
 	o.writeError(ctx, rw, err)
 }
 
@@ -128,6 +152,11 @@ func (o *OAuth2) WriteTokenResponse(ctx context.Context, rw http.ResponseWriter,
 		return
 	}
 
+	if o.config.IsDPoPNonceRequired() {
+		// a fresh nonce saves the client a round-trip on its next request
+		rw.Header().Set(DPoPNonceHeader, o.NewDPoPNonce())
+	}
+
 	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
 	rw.WriteHeader(http.StatusOK)
 	_, _ = rw.Write(jsonPayload)
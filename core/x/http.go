@@ -30,7 +30,8 @@ func BindPostForm(r *http.Request) (url.Values, error) {
 
 func AccessTokenFromRequest(r *http.Request) string {
 	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 3)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+	// DPoP-bound access tokens use the DPoP scheme, see RFC 9449 section 7.1
+	if len(parts) != 2 || !(strings.EqualFold(parts[0], "Bearer") || strings.EqualFold(parts[0], "DPoP")) {
 		form, err := BindForm(r) // this gets both url and body params
 		if err != nil {
 			return ""
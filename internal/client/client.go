@@ -10,7 +10,6 @@ import (
 )
 
 // Client represents an OAuth2.1 and IDToken Connect client.
-// TODO: Support RFC7591 dynamic client registration
 type Client struct {
 	ID            string             `json:"id" db:"id"`
 	Name          string             `json:"name" db:"name"`
@@ -22,13 +21,56 @@ type Client struct {
 	ResponseTypes dbtype.StringArray `json:"response_types" db:"response_types"`
 	Audience      dbtype.StringArray `json:"audience" db:"audience"`
 	RequestURIs   dbtype.StringArray `json:"request_uris,omitempty" db:"request_uris"`
-	// JWKs and JWKsURI are mutually exclusive, they are used for authenticate clients using the private_key_jwt method.
+	// JWKs and JWKsURI are mutually exclusive, they are used for authenticate clients using the private_key_jwt method
+	// and to encrypt responses to the client.
 	JWKs                        *dbtype.JWKSet `json:"jwks,omitempty" db:"jwks"`
 	JWKsURI                     string         `json:"jwks_uri,omitempty" db:"jwks_uri"`
 	TokenEndpointAuthMethod     string         `json:"token_endpoint_auth_method,omitempty" db:"token_endpoint_auth_method"`
 	TokenEndpointAuthSigningAlg string         `json:"token_endpoint_auth_signing_alg,omitempty" db:"token_endpoint_auth_signing_alg"`
-	CreatedAt                   time.Time      `json:"created_at,omitempty" db:"created_at"`
-	UpdatedAt                   time.Time      `json:"updated_at,omitempty" db:"updated_at"`
+	// UserinfoSignedResponseAlg makes the UserInfo endpoint respond with a signed JWT instead of plain JSON.
+	UserinfoSignedResponseAlg string `json:"userinfo_signed_response_alg,omitempty" db:"userinfo_signed_response_alg"`
+	// PostLogoutRedirectURIs and the logout URIs are described in OpenID Connect RP-Initiated, Front-Channel and
+	// Back-Channel Logout.
+	PostLogoutRedirectURIs            dbtype.StringArray `json:"post_logout_redirect_uris,omitempty" db:"post_logout_redirect_uris"`
+	FrontChannelLogoutURI             string             `json:"frontchannel_logout_uri,omitempty" db:"frontchannel_logout_uri"`
+	FrontChannelLogoutSessionRequired bool               `json:"frontchannel_logout_session_required,omitempty" db:"frontchannel_logout_session_required"`
+	BackChannelLogoutURI              string             `json:"backchannel_logout_uri,omitempty" db:"backchannel_logout_uri"`
+	BackChannelLogoutSessionRequired  bool               `json:"backchannel_logout_session_required,omitempty" db:"backchannel_logout_session_required"`
+	CreatedAt                         time.Time          `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt                         time.Time          `json:"updated_at,omitempty" db:"updated_at"`
+	// SecretCiphertext keeps the secret of client_secret_jwt clients encrypted, as it is needed in plaintext to verify
+	// the HMAC signature of their client assertions.
+	SecretCiphertext string `json:"-" db:"secret_ciphertext"`
+	// RequirePushedAuthorizationRequests only accepts authorization requests pushed first, see RFC 9126 section 6.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty" db:"require_pushed_authorization_requests"`
+	// RegistrationAccessToken is the hash of the token used to manage a dynamically registered client, see RFC 7592.
+	RegistrationAccessToken string `json:"-" db:"registration_access_token"`
+	// The TLS client auth fields identify the certificate of tls_client_auth clients, only one of them is set. See
+	// RFC 8705 section 2.1.2.
+	TLSClientAuthSubjectDN                string `json:"tls_client_auth_subject_dn,omitempty" db:"tls_client_auth_subject_dn"`
+	TLSClientAuthSANDNS                   string `json:"tls_client_auth_san_dns,omitempty" db:"tls_client_auth_san_dns"`
+	TLSClientAuthSANURI                   string `json:"tls_client_auth_san_uri,omitempty" db:"tls_client_auth_san_uri"`
+	TLSClientAuthSANIP                    string `json:"tls_client_auth_san_ip,omitempty" db:"tls_client_auth_san_ip"`
+	TLSClientAuthSANEmail                 string `json:"tls_client_auth_san_email,omitempty" db:"tls_client_auth_san_email"`
+	TLSClientCertificateBoundAccessTokens bool   `json:"tls_client_certificate_bound_access_tokens,omitempty" db:"tls_client_certificate_bound_access_tokens"`
+	// ResponseModes replaces the default response modes when set. The authorization response fields configure the
+	// JWT secured authorization responses, see JARM section 3.
+	ResponseModes                     dbtype.StringArray `json:"response_modes,omitempty" db:"response_modes"`
+	AuthorizationSignedResponseAlg    string             `json:"authorization_signed_response_alg,omitempty" db:"authorization_signed_response_alg"`
+	AuthorizationEncryptedResponseAlg string             `json:"authorization_encrypted_response_alg,omitempty" db:"authorization_encrypted_response_alg"`
+	AuthorizationEncryptedResponseEnc string             `json:"authorization_encrypted_response_enc,omitempty" db:"authorization_encrypted_response_enc"`
+	// SubjectType is either public or pairwise, the sector of pairwise clients is the host of SectorIdentifierURI or
+	// of the redirect URIs. See OpenID Connect Core 1.0 section 8.
+	SubjectType         string `json:"subject_type,omitempty" db:"subject_type"`
+	SectorIdentifierURI string `json:"sector_identifier_uri,omitempty" db:"sector_identifier_uri"`
+	// The encrypted response fields make ID tokens and UserInfo responses JWEs encrypted to a key of the client, see
+	// OpenID Connect Dynamic Client Registration 1.0 section 2.
+	IDTokenEncryptedResponseAlg  string `json:"id_token_encrypted_response_alg,omitempty" db:"id_token_encrypted_response_alg"`
+	IDTokenEncryptedResponseEnc  string `json:"id_token_encrypted_response_enc,omitempty" db:"id_token_encrypted_response_enc"`
+	UserinfoEncryptedResponseAlg string `json:"userinfo_encrypted_response_alg,omitempty" db:"userinfo_encrypted_response_alg"`
+	UserinfoEncryptedResponseEnc string `json:"userinfo_encrypted_response_enc,omitempty" db:"userinfo_encrypted_response_enc"`
+
+	plainSecret []byte
 }
 
 func (c *Client) GetID() string {
@@ -39,6 +81,10 @@ func (c *Client) GetHashedSecret() []byte {
 	return []byte(c.Secret)
 }
 
+func (c *Client) GetClientSecret() []byte {
+	return c.plainSecret
+}
+
 func (c *Client) GetRedirectURIs() []string {
 	return c.RedirectURIs
 }
@@ -86,33 +132,166 @@ func (c *Client) GetTokenEndpointAuthMethod() string {
 	return c.TokenEndpointAuthMethod
 }
 
+func (c *Client) GetTokenEndpointAuthSigningAlg() string {
+	return c.TokenEndpointAuthSigningAlg
+}
+
+func (c *Client) GetUserinfoSignedResponseAlg() string {
+	return c.UserinfoSignedResponseAlg
+}
+
+func (c *Client) GetPostLogoutRedirectURIs() []string {
+	return c.PostLogoutRedirectURIs
+}
+
+func (c *Client) GetFrontChannelLogoutURI() string {
+	return c.FrontChannelLogoutURI
+}
+
+func (c *Client) IsFrontChannelLogoutSessionRequired() bool {
+	return c.FrontChannelLogoutSessionRequired
+}
+
+func (c *Client) GetBackChannelLogoutURI() string {
+	return c.BackChannelLogoutURI
+}
+
+func (c *Client) IsBackChannelLogoutSessionRequired() bool {
+	return c.BackChannelLogoutSessionRequired
+}
+
+func (c *Client) IsPushedAuthorizationRequired() bool {
+	return c.RequirePushedAuthorizationRequests
+}
+
+func (c *Client) GetTLSClientAuthSubjectDN() string {
+	return c.TLSClientAuthSubjectDN
+}
+
+func (c *Client) GetTLSClientAuthSANDNS() string {
+	return c.TLSClientAuthSANDNS
+}
+
+func (c *Client) GetTLSClientAuthSANURI() string {
+	return c.TLSClientAuthSANURI
+}
+
+func (c *Client) GetTLSClientAuthSANIP() string {
+	return c.TLSClientAuthSANIP
+}
+
+func (c *Client) GetTLSClientAuthSANEmail() string {
+	return c.TLSClientAuthSANEmail
+}
+
+func (c *Client) IsTLSClientCertificateBoundAccessTokens() bool {
+	return c.TLSClientCertificateBoundAccessTokens
+}
+
 func (c *Client) GetResponseModes() []core.ResponseMode {
-	// fixed for now
-	return []core.ResponseMode{
-		core.ResponseModeDefault,
-		core.ResponseModeQuery,
-		core.ResponseModeFormPost,
-		core.ResponseModeFragment,
+	if len(c.ResponseModes) == 0 {
+		return []core.ResponseMode{
+			core.ResponseModeDefault,
+			core.ResponseModeQuery,
+			core.ResponseModeFormPost,
+			core.ResponseModeFragment,
+			core.ResponseModeJWT,
+			core.ResponseModeQueryJWT,
+			core.ResponseModeFormPostJWT,
+			core.ResponseModeFragmentJWT,
+		}
 	}
+
+	// omitting the response_mode parameter is always allowed
+	modes := []core.ResponseMode{core.ResponseModeDefault}
+	for _, mode := range c.ResponseModes {
+		modes = append(modes, core.ResponseMode(mode))
+	}
+	return modes
+}
+
+func (c *Client) GetSubjectType() string {
+	if c.SubjectType == "" {
+		return SubjectTypePublic
+	}
+	return c.SubjectType
+}
+
+func (c *Client) GetSectorIdentifierURI() string {
+	return c.SectorIdentifierURI
+}
+
+func (c *Client) GetAuthorizationSignedResponseAlg() string {
+	return c.AuthorizationSignedResponseAlg
+}
+
+func (c *Client) GetAuthorizationEncryptedResponseAlg() string {
+	return c.AuthorizationEncryptedResponseAlg
+}
+
+func (c *Client) GetAuthorizationEncryptedResponseEnc() string {
+	return c.AuthorizationEncryptedResponseEnc
+}
+
+func (c *Client) GetIDTokenEncryptedResponseAlg() string {
+	return c.IDTokenEncryptedResponseAlg
+}
+
+func (c *Client) GetIDTokenEncryptedResponseEnc() string {
+	return c.IDTokenEncryptedResponseEnc
+}
+
+func (c *Client) GetUserinfoEncryptedResponseAlg() string {
+	return c.UserinfoEncryptedResponseAlg
+}
+
+func (c *Client) GetUserinfoEncryptedResponseEnc() string {
+	return c.UserinfoEncryptedResponseEnc
 }
 
 func (c *Client) ColumnMap() map[string]any {
 	return map[string]any{
-		"id":                              c.ID,
-		"name":                            c.Name,
-		"description":                     c.Description,
-		"secret":                          c.Secret,
-		"scope":                           c.Scope,
-		"redirect_uris":                   c.RedirectURIs,
-		"grant_types":                     c.GrantTypes,
-		"response_types":                  c.ResponseTypes,
-		"audience":                        c.Audience,
-		"request_uris":                    c.RequestURIs,
-		"jwks":                            c.JWKs,
-		"jwks_uri":                        c.JWKsURI,
-		"token_endpoint_auth_method":      c.TokenEndpointAuthMethod,
-		"token_endpoint_auth_signing_alg": c.TokenEndpointAuthSigningAlg,
-		"created_at":                      c.CreatedAt,
-		"updated_at":                      c.UpdatedAt,
+		"id":                                    c.ID,
+		"name":                                  c.Name,
+		"description":                           c.Description,
+		"secret":                                c.Secret,
+		"secret_ciphertext":                     c.SecretCiphertext,
+		"scope":                                 c.Scope,
+		"redirect_uris":                         c.RedirectURIs,
+		"grant_types":                           c.GrantTypes,
+		"response_types":                        c.ResponseTypes,
+		"audience":                              c.Audience,
+		"request_uris":                          c.RequestURIs,
+		"jwks":                                  c.JWKs,
+		"jwks_uri":                              c.JWKsURI,
+		"token_endpoint_auth_method":            c.TokenEndpointAuthMethod,
+		"token_endpoint_auth_signing_alg":       c.TokenEndpointAuthSigningAlg,
+		"userinfo_signed_response_alg":          c.UserinfoSignedResponseAlg,
+		"post_logout_redirect_uris":             c.PostLogoutRedirectURIs,
+		"frontchannel_logout_uri":               c.FrontChannelLogoutURI,
+		"frontchannel_logout_session_required":  c.FrontChannelLogoutSessionRequired,
+		"backchannel_logout_uri":                c.BackChannelLogoutURI,
+		"backchannel_logout_session_required":   c.BackChannelLogoutSessionRequired,
+		"require_pushed_authorization_requests": c.RequirePushedAuthorizationRequests,
+		"response_modes":                        c.ResponseModes,
+		"authorization_signed_response_alg":     c.AuthorizationSignedResponseAlg,
+		"authorization_encrypted_response_alg":  c.AuthorizationEncryptedResponseAlg,
+		"authorization_encrypted_response_enc":  c.AuthorizationEncryptedResponseEnc,
+		"subject_type":                          c.SubjectType,
+		"sector_identifier_uri":                 c.SectorIdentifierURI,
+		"id_token_encrypted_response_alg":       c.IDTokenEncryptedResponseAlg,
+		"id_token_encrypted_response_enc":       c.IDTokenEncryptedResponseEnc,
+		"userinfo_encrypted_response_alg":       c.UserinfoEncryptedResponseAlg,
+		"userinfo_encrypted_response_enc":       c.UserinfoEncryptedResponseEnc,
+		"registration_access_token":             c.RegistrationAccessToken,
+		"tls_client_auth_subject_dn":            c.TLSClientAuthSubjectDN,
+		"tls_client_auth_san_dns":               c.TLSClientAuthSANDNS,
+		"tls_client_auth_san_uri":               c.TLSClientAuthSANURI,
+		"tls_client_auth_san_ip":                c.TLSClientAuthSANIP,
+		"tls_client_auth_san_email":             c.TLSClientAuthSANEmail,
+		"created_at":                            c.CreatedAt,
+		"updated_at":                            c.UpdatedAt,
+
+		"tls_client_certificate_bound_access_tokens": c.TLSClientCertificateBoundAccessTokens,
 	}
 }
@@ -11,6 +11,9 @@ func SanitizeClient(cl *Client) *Client {
 	cc := &Client{}
 	*cc = *cl // copy
 	cc.Secret = ""
+	cc.SecretCiphertext = ""
+	cc.RegistrationAccessToken = ""
+	cc.plainSecret = nil
 
 	return cc
 }
@@ -0,0 +1,572 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/pkg/dbtype"
+	"github.com/tuanta7/hydros/pkg/helper/stringx"
+	"go.uber.org/zap"
+)
+
+var (
+	registrationGrantTypes = []string{
+		string(core.GrantTypeAuthorizationCode),
+		string(core.GrantTypeRefreshToken),
+		string(core.GrantTypeClientCredentials),
+		string(core.GrantTypeDeviceCode),
+		string(core.GrantTypeTokenExchange),
+	}
+	registrationAuthMethods = []string{
+		core.ClientAuthenticationMethodBasic,
+		core.ClientAuthenticationMethodPost,
+		core.ClientAuthenticationMethodJWT,
+		core.ClientAuthenticationMethodSecretJWT,
+		core.ClientAuthenticationMethodNone,
+		core.ClientAuthenticationMethodTLS,
+		core.ClientAuthenticationMethodSelfSignedTLS,
+	}
+	registrationResponseModes = []string{
+		string(core.ResponseModeQuery),
+		string(core.ResponseModeFragment),
+		string(core.ResponseModeFormPost),
+		string(core.ResponseModeJWT),
+		string(core.ResponseModeQueryJWT),
+		string(core.ResponseModeFragmentJWT),
+		string(core.ResponseModeFormPostJWT),
+	}
+	// privilegedGrantTypes issue tokens without an end-user, they are only granted to clients registered with an
+	// initial access token.
+	privilegedGrantTypes = []string{
+		string(core.GrantTypeClientCredentials),
+		string(core.GrantTypeTokenExchange),
+	}
+	privateKeyJWTAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+	secretJWTAlgorithms     = []string{"HS256", "HS384", "HS512"}
+)
+
+// Metadata is the client metadata that can be registered and updated by the client itself, as described in RFC 7591
+// section 2.
+type Metadata struct {
+	ClientName                         string         `json:"client_name,omitempty"`
+	RedirectURIs                       []string       `json:"redirect_uris,omitempty"`
+	GrantTypes                         []string       `json:"grant_types,omitempty"`
+	ResponseTypes                      []string       `json:"response_types,omitempty"`
+	ResponseModes                      []string       `json:"response_modes,omitempty"`
+	Scope                              string         `json:"scope,omitempty"`
+	Audience                           []string       `json:"audience,omitempty"`
+	TokenEndpointAuthMethod            string         `json:"token_endpoint_auth_method,omitempty"`
+	TokenEndpointAuthSigningAlg        string         `json:"token_endpoint_auth_signing_alg,omitempty"`
+	JWKs                               *dbtype.JWKSet `json:"jwks,omitempty"`
+	JWKsURI                            string         `json:"jwks_uri,omitempty"`
+	RequestURIs                        []string       `json:"request_uris,omitempty"`
+	UserinfoSignedResponseAlg          string         `json:"userinfo_signed_response_alg,omitempty"`
+	AuthorizationSignedResponseAlg     string         `json:"authorization_signed_response_alg,omitempty"`
+	AuthorizationEncryptedResponseAlg  string         `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc  string         `json:"authorization_encrypted_response_enc,omitempty"`
+	SubjectType                        string         `json:"subject_type,omitempty"`
+	SectorIdentifierURI                string         `json:"sector_identifier_uri,omitempty"`
+	IDTokenEncryptedResponseAlg        string         `json:"id_token_encrypted_response_alg,omitempty"`
+	IDTokenEncryptedResponseEnc        string         `json:"id_token_encrypted_response_enc,omitempty"`
+	UserinfoEncryptedResponseAlg       string         `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserinfoEncryptedResponseEnc       string         `json:"userinfo_encrypted_response_enc,omitempty"`
+	PostLogoutRedirectURIs             []string       `json:"post_logout_redirect_uris,omitempty"`
+	FrontChannelLogoutURI              string         `json:"frontchannel_logout_uri,omitempty"`
+	FrontChannelLogoutSessionRequired  bool           `json:"frontchannel_logout_session_required,omitempty"`
+	BackChannelLogoutURI               string         `json:"backchannel_logout_uri,omitempty"`
+	BackChannelLogoutSessionRequired   bool           `json:"backchannel_logout_session_required,omitempty"`
+	RequirePushedAuthorizationRequests bool           `json:"require_pushed_authorization_requests,omitempty"`
+	TLSClientAuthSubjectDN             string         `json:"tls_client_auth_subject_dn,omitempty"`
+	TLSClientAuthSANDNS                string         `json:"tls_client_auth_san_dns,omitempty"`
+	TLSClientAuthSANURI                string         `json:"tls_client_auth_san_uri,omitempty"`
+	TLSClientAuthSANIP                 string         `json:"tls_client_auth_san_ip,omitempty"`
+	TLSClientAuthSANEmail              string         `json:"tls_client_auth_san_email,omitempty"`
+
+	TLSClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+}
+
+// RegistrationResponse is the client information response of RFC 7591 section 3.2.1 and RFC 7592 section 3. The
+// client secret and the registration access token are only returned when they are issued.
+type RegistrationResponse struct {
+	Metadata
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64  `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   int64  `json:"client_secret_expires_at"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+}
+
+// RegisterClient validates the metadata and creates a new client that can manage itself with the returned
+// registration access token. trusted reports whether the request carried a valid initial access token.
+func (u *UseCase) RegisterClient(ctx context.Context, md *Metadata, trusted bool) (*RegistrationResponse, error) {
+	if err := u.validateMetadata(md); err != nil {
+		return nil, err
+	}
+
+	if err := u.validateRegistrationPolicy(md, trusted, &Client{}); err != nil {
+		return nil, err
+	}
+
+	client := &Client{}
+	md.applyTo(client)
+
+	token := stringx.GenerateSecret(32)
+	hashedToken, err := u.cfg.GetSecretsHasher().Hash(ctx, []byte(token))
+	if err != nil {
+		return nil, err
+	}
+	client.RegistrationAccessToken = string(hashedToken)
+
+	if err = u.CreateClient(ctx, client); err != nil {
+		return nil, err
+	}
+
+	res := u.registrationResponse(client)
+	res.RegistrationAccessToken = token
+	if !client.IsPublic() {
+		// CreateClient returns the plaintext secret only once
+		res.ClientSecret = client.Secret
+	}
+
+	return res, nil
+}
+
+// AuthenticateRegistration returns the client if token is its registration access token. Unknown clients and clients
+// created by other means are reported with the same error so the client IDs can not be probed.
+func (u *UseCase) AuthenticateRegistration(ctx context.Context, id, token string) (*Client, error) {
+	if id == "" || token == "" {
+		return nil, core.ErrInvalidToken
+	}
+
+	client, err := u.clientRepo.Get(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, core.ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if client.RegistrationAccessToken == "" {
+		return nil, core.ErrInvalidToken
+	}
+
+	err = u.cfg.GetSecretsHasher().Compare(ctx, []byte(client.RegistrationAccessToken), []byte(token))
+	if err != nil {
+		return nil, core.ErrInvalidToken.WithWrap(err)
+	}
+
+	return client, nil
+}
+
+// GetRegisteredClient returns the client information of a registered client as described in RFC 7592 section 2.1.
+func (u *UseCase) GetRegisteredClient(client *Client) *RegistrationResponse {
+	return u.registrationResponse(client)
+}
+
+// UpdateRegisteredClient replaces the metadata of a registered client as described in RFC 7592 section 2.2. Omitted
+// fields are reset to their defaults. A new secret is issued when the client switches to a method that needs a
+// secret the server does not have. Privileged grant types, scopes and audiences outside the allow-lists can be kept
+// but not added.
+func (u *UseCase) UpdateRegisteredClient(ctx context.Context, client *Client, md *Metadata) (*RegistrationResponse, error) {
+	if err := u.validateMetadata(md); err != nil {
+		return nil, err
+	}
+
+	if err := u.validateRegistrationPolicy(md, false, client); err != nil {
+		return nil, err
+	}
+
+	wasPublic := client.IsPublic()
+	md.applyTo(client)
+	client.UpdatedAt = x.NowUTC().Round(time.Second)
+
+	if err := u.validateSubjectType(ctx, client); err != nil {
+		return nil, err
+	}
+
+	secret := ""
+	needsCiphertext := client.TokenEndpointAuthMethod == core.ClientAuthenticationMethodSecretJWT && client.SecretCiphertext == ""
+	if !client.IsPublic() && (wasPublic || needsCiphertext) {
+		secret = stringx.GenerateSecret(26)
+		if err := u.setSecret(ctx, client, secret); err != nil {
+			return nil, err
+		}
+	} else if client.TokenEndpointAuthMethod != core.ClientAuthenticationMethodSecretJWT {
+		client.SecretCiphertext = ""
+	}
+
+	if err := u.clientRepo.Update(ctx, client); err != nil {
+		u.logger.Error("error while updating client",
+			zap.Error(err),
+			zap.String("method", "clientRepo.Update"),
+		)
+		return nil, err
+	}
+
+	res := u.registrationResponse(client)
+	res.ClientSecret = secret
+	return res, nil
+}
+
+// DeleteRegisteredClient deregisters a client as described in RFC 7592 section 2.3.
+func (u *UseCase) DeleteRegisteredClient(ctx context.Context, client *Client) error {
+	err := u.clientRepo.Delete(ctx, client.ID)
+	if err != nil {
+		u.logger.Error("error while deleting client",
+			zap.Error(err),
+			zap.String("method", "clientRepo.Delete"),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (u *UseCase) registrationResponse(client *Client) *RegistrationResponse {
+	jwks := client.JWKs
+	if jwks != nil && jwks.JSONWebKeySet == nil {
+		jwks = nil
+	}
+
+	registrationURI, err := url.JoinPath(u.cfg.GetClientRegistrationURL(), url.PathEscape(client.ID))
+	if err != nil {
+		registrationURI = u.cfg.GetClientRegistrationURL() + "/" + url.PathEscape(client.ID)
+	}
+
+	return &RegistrationResponse{
+		Metadata: Metadata{
+			ClientName:                         client.Name,
+			RedirectURIs:                       client.RedirectURIs,
+			GrantTypes:                         client.GrantTypes,
+			ResponseTypes:                      client.ResponseTypes,
+			ResponseModes:                      client.ResponseModes,
+			Scope:                              client.Scope,
+			Audience:                           client.Audience,
+			TokenEndpointAuthMethod:            client.TokenEndpointAuthMethod,
+			TokenEndpointAuthSigningAlg:        client.TokenEndpointAuthSigningAlg,
+			JWKs:                               jwks,
+			JWKsURI:                            client.JWKsURI,
+			RequestURIs:                        client.RequestURIs,
+			UserinfoSignedResponseAlg:          client.UserinfoSignedResponseAlg,
+			AuthorizationSignedResponseAlg:     client.AuthorizationSignedResponseAlg,
+			AuthorizationEncryptedResponseAlg:  client.AuthorizationEncryptedResponseAlg,
+			AuthorizationEncryptedResponseEnc:  client.AuthorizationEncryptedResponseEnc,
+			SubjectType:                        client.SubjectType,
+			SectorIdentifierURI:                client.SectorIdentifierURI,
+			IDTokenEncryptedResponseAlg:        client.IDTokenEncryptedResponseAlg,
+			IDTokenEncryptedResponseEnc:        client.IDTokenEncryptedResponseEnc,
+			UserinfoEncryptedResponseAlg:       client.UserinfoEncryptedResponseAlg,
+			UserinfoEncryptedResponseEnc:       client.UserinfoEncryptedResponseEnc,
+			PostLogoutRedirectURIs:             client.PostLogoutRedirectURIs,
+			FrontChannelLogoutURI:              client.FrontChannelLogoutURI,
+			FrontChannelLogoutSessionRequired:  client.FrontChannelLogoutSessionRequired,
+			BackChannelLogoutURI:               client.BackChannelLogoutURI,
+			BackChannelLogoutSessionRequired:   client.BackChannelLogoutSessionRequired,
+			RequirePushedAuthorizationRequests: client.RequirePushedAuthorizationRequests,
+			TLSClientAuthSubjectDN:             client.TLSClientAuthSubjectDN,
+			TLSClientAuthSANDNS:                client.TLSClientAuthSANDNS,
+			TLSClientAuthSANURI:                client.TLSClientAuthSANURI,
+			TLSClientAuthSANIP:                 client.TLSClientAuthSANIP,
+			TLSClientAuthSANEmail:              client.TLSClientAuthSANEmail,
+
+			TLSClientCertificateBoundAccessTokens: client.TLSClientCertificateBoundAccessTokens,
+		},
+		ClientID:              client.ID,
+		ClientIDIssuedAt:      client.CreatedAt.Unix(),
+		ClientSecretExpiresAt: 0, // secrets do not expire
+		RegistrationClientURI: registrationURI,
+	}
+}
+
+// applyTo copies the metadata to the client, the defaults are set by validateMetadata.
+func (md *Metadata) applyTo(client *Client) {
+	client.Name = md.ClientName
+	client.RedirectURIs = md.RedirectURIs
+	client.GrantTypes = md.GrantTypes
+	client.ResponseTypes = md.ResponseTypes
+	client.ResponseModes = md.ResponseModes
+	client.Scope = md.Scope
+	client.Audience = md.Audience
+	client.TokenEndpointAuthMethod = md.TokenEndpointAuthMethod
+	client.TokenEndpointAuthSigningAlg = md.TokenEndpointAuthSigningAlg
+	client.JWKs = md.JWKs
+	client.JWKsURI = md.JWKsURI
+	client.RequestURIs = md.RequestURIs
+	client.UserinfoSignedResponseAlg = md.UserinfoSignedResponseAlg
+	client.AuthorizationSignedResponseAlg = md.AuthorizationSignedResponseAlg
+	client.AuthorizationEncryptedResponseAlg = md.AuthorizationEncryptedResponseAlg
+	client.AuthorizationEncryptedResponseEnc = md.AuthorizationEncryptedResponseEnc
+	client.SubjectType = md.SubjectType
+	client.SectorIdentifierURI = md.SectorIdentifierURI
+	client.IDTokenEncryptedResponseAlg = md.IDTokenEncryptedResponseAlg
+	client.IDTokenEncryptedResponseEnc = md.IDTokenEncryptedResponseEnc
+	client.UserinfoEncryptedResponseAlg = md.UserinfoEncryptedResponseAlg
+	client.UserinfoEncryptedResponseEnc = md.UserinfoEncryptedResponseEnc
+	client.PostLogoutRedirectURIs = md.PostLogoutRedirectURIs
+	client.FrontChannelLogoutURI = md.FrontChannelLogoutURI
+	client.FrontChannelLogoutSessionRequired = md.FrontChannelLogoutSessionRequired
+	client.BackChannelLogoutURI = md.BackChannelLogoutURI
+	client.BackChannelLogoutSessionRequired = md.BackChannelLogoutSessionRequired
+	client.RequirePushedAuthorizationRequests = md.RequirePushedAuthorizationRequests
+	client.TLSClientAuthSubjectDN = md.TLSClientAuthSubjectDN
+	client.TLSClientAuthSANDNS = md.TLSClientAuthSANDNS
+	client.TLSClientAuthSANURI = md.TLSClientAuthSANURI
+	client.TLSClientAuthSANIP = md.TLSClientAuthSANIP
+	client.TLSClientAuthSANEmail = md.TLSClientAuthSANEmail
+	client.TLSClientCertificateBoundAccessTokens = md.TLSClientCertificateBoundAccessTokens
+
+	if client.JWKs == nil {
+		client.JWKs = &dbtype.JWKSet{}
+	}
+}
+
+// validateRegistrationPolicy limits what a client can grant itself. The requested scope and audience must be part of
+// the configured allow-lists, and the grant types that issue tokens without an end-user require an initial access
+// token. Values the current client already holds are accepted, so an administrator can widen a registered client.
+func (u *UseCase) validateRegistrationPolicy(md *Metadata, trusted bool, current *Client) error {
+	// the allow-lists are owned by the config and shared by concurrent registrations, so they are copied before being
+	// extended
+	allowedScope := slices.Concat(u.cfg.GetClientRegistrationAllowedScope(), current.GetScopes())
+	for _, scope := range strings.Fields(md.Scope) {
+		if !slices.Contains(allowedScope, scope) {
+			return core.ErrInvalidClientMetadata.WithHint("The scope '%s' can not be requested by registered clients.", scope)
+		}
+	}
+
+	allowedAudience := slices.Concat(u.cfg.GetClientRegistrationAllowedAudience(), current.GetAudience())
+	for _, audience := range md.Audience {
+		if !slices.Contains(allowedAudience, audience) {
+			return core.ErrInvalidClientMetadata.WithHint("The audience '%s' can not be requested by registered clients.", audience)
+		}
+	}
+
+	if trusted {
+		return nil
+	}
+
+	for _, gt := range privilegedGrantTypes {
+		if slices.Contains(md.GrantTypes, gt) && !slices.Contains(current.GrantTypes, gt) {
+			return core.ErrInvalidClientMetadata.WithHint("The grant type '%s' requires a valid initial access token.", gt)
+		}
+	}
+
+	return nil
+}
+
+// validateMetadata checks the metadata as described in RFC 7591 section 2 and sets the defaults of omitted fields.
+func (u *UseCase) validateMetadata(md *Metadata) error {
+	if len(md.GrantTypes) == 0 {
+		md.GrantTypes = []string{string(core.GrantTypeAuthorizationCode)}
+	}
+
+	if len(md.ResponseTypes) == 0 && slices.Contains(md.GrantTypes, string(core.GrantTypeAuthorizationCode)) {
+		md.ResponseTypes = []string{"code"}
+	}
+
+	if md.TokenEndpointAuthMethod == "" {
+		md.TokenEndpointAuthMethod = core.ClientAuthenticationMethodBasic
+	}
+
+	if md.Scope == "" {
+		md.Scope = u.cfg.GetClientRegistrationDefaultScope()
+	}
+
+	for _, gt := range md.GrantTypes {
+		if !slices.Contains(registrationGrantTypes, gt) {
+			return core.ErrInvalidClientMetadata.WithHint("The grant type '%s' is not supported.", gt)
+		}
+	}
+
+	for _, rt := range md.ResponseTypes {
+		if rt != "code" {
+			return core.ErrInvalidClientMetadata.WithHint("The response type '%s' is not supported.", rt)
+		}
+	}
+
+	usesCode := slices.Contains(md.GrantTypes, string(core.GrantTypeAuthorizationCode))
+	if usesCode != slices.Contains(md.ResponseTypes, "code") {
+		return core.ErrInvalidClientMetadata.WithHint("The grant type 'authorization_code' and the response type 'code' must be registered together.")
+	}
+
+	if slices.Contains(md.GrantTypes, string(core.GrantTypeRefreshToken)) && len(md.GrantTypes) == 1 {
+		return core.ErrInvalidClientMetadata.WithHint("The grant type 'refresh_token' must be registered with a grant type that issues refresh tokens.")
+	}
+
+	if usesCode && len(md.RedirectURIs) == 0 {
+		return core.ErrInvalidRedirectURI.WithHint("At least one redirect URI is required for the 'authorization_code' grant type.")
+	}
+
+	for _, uri := range md.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return err
+		}
+	}
+
+	for _, uri := range md.PostLogoutRedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return err
+		}
+	}
+
+	if !slices.Contains(registrationAuthMethods, md.TokenEndpointAuthMethod) {
+		return core.ErrInvalidClientMetadata.WithHint("The token endpoint authentication method '%s' is not supported.", md.TokenEndpointAuthMethod)
+	}
+
+	if md.TokenEndpointAuthMethod == core.ClientAuthenticationMethodNone && slices.Contains(md.GrantTypes, string(core.GrantTypeClientCredentials)) {
+		return core.ErrInvalidClientMetadata.WithHint("Public clients can not use the 'client_credentials' grant type.")
+	}
+
+	hasJWKs := md.JWKs != nil && md.JWKs.JSONWebKeySet != nil && len(md.JWKs.Keys) > 0
+	if hasJWKs && md.JWKsURI != "" {
+		return core.ErrInvalidClientMetadata.WithHint("The 'jwks' and 'jwks_uri' parameters can not be used together.")
+	}
+
+	if hasJWKs {
+		for _, key := range md.JWKs.Keys {
+			if !key.Valid() || !key.IsPublic() {
+				return core.ErrInvalidClientMetadata.WithHint("The 'jwks' parameter must only contain valid public keys.")
+			}
+		}
+	}
+
+	uris := map[string]string{
+		"jwks_uri":                md.JWKsURI,
+		"frontchannel_logout_uri": md.FrontChannelLogoutURI,
+		"backchannel_logout_uri":  md.BackChannelLogoutURI,
+		"sector_identifier_uri":   md.SectorIdentifierURI,
+	}
+	for name, uri := range uris {
+		if err := validateSecureURI(name, uri); err != nil {
+			return err
+		}
+	}
+
+	for _, uri := range md.RequestURIs {
+		if err := validateSecureURI("request_uris", uri); err != nil {
+			return err
+		}
+	}
+
+	var algorithms []string
+	switch md.TokenEndpointAuthMethod {
+	case core.ClientAuthenticationMethodJWT:
+		if !hasJWKs && md.JWKsURI == "" {
+			return core.ErrInvalidClientMetadata.WithHint("The 'private_key_jwt' authentication method requires either 'jwks' or 'jwks_uri'.")
+		}
+		algorithms = privateKeyJWTAlgorithms
+	case core.ClientAuthenticationMethodSecretJWT:
+		algorithms = secretJWTAlgorithms
+	case core.ClientAuthenticationMethodTLS:
+		subjects := 0
+		for _, v := range []string{
+			md.TLSClientAuthSubjectDN,
+			md.TLSClientAuthSANDNS,
+			md.TLSClientAuthSANURI,
+			md.TLSClientAuthSANIP,
+			md.TLSClientAuthSANEmail,
+		} {
+			if v != "" {
+				subjects++
+			}
+		}
+		if subjects != 1 {
+			return core.ErrInvalidClientMetadata.WithHint("The 'tls_client_auth' authentication method requires exactly one of the 'tls_client_auth_subject_dn' or 'tls_client_auth_san_*' parameters.")
+		}
+	case core.ClientAuthenticationMethodSelfSignedTLS:
+		if !hasJWKs && md.JWKsURI == "" {
+			return core.ErrInvalidClientMetadata.WithHint("The 'self_signed_tls_client_auth' authentication method requires either 'jwks' or 'jwks_uri'.")
+		}
+	}
+
+	if alg := md.TokenEndpointAuthSigningAlg; alg != "" && alg != "none" && !slices.Contains(algorithms, alg) {
+		return core.ErrInvalidClientMetadata.WithHint("The signing algorithm '%s' can not be used with the '%s' authentication method.", alg, md.TokenEndpointAuthMethod)
+	}
+
+	if alg := md.UserinfoSignedResponseAlg; alg != "" && alg != "none" && alg != u.cfg.GetIDTokenAlgorithm() {
+		return core.ErrInvalidClientMetadata.WithHint("The UserInfo signing algorithm '%s' is not supported.", alg)
+	}
+
+	for _, mode := range md.ResponseModes {
+		if !slices.Contains(registrationResponseModes, mode) {
+			return core.ErrInvalidClientMetadata.WithHint("The response mode '%s' is not supported.", mode)
+		}
+	}
+
+	if alg := md.AuthorizationSignedResponseAlg; alg != "" && alg != u.cfg.GetIDTokenAlgorithm() {
+		return core.ErrInvalidClientMetadata.WithHint("The authorization response signing algorithm '%s' is not supported.", alg)
+	}
+
+	hasKeys := hasJWKs || md.JWKsURI != ""
+	encryptedResponses := []struct{ prefix, alg, enc string }{
+		{"authorization", md.AuthorizationEncryptedResponseAlg, md.AuthorizationEncryptedResponseEnc},
+		{"id_token", md.IDTokenEncryptedResponseAlg, md.IDTokenEncryptedResponseEnc},
+		{"userinfo", md.UserinfoEncryptedResponseAlg, md.UserinfoEncryptedResponseEnc},
+	}
+
+	for _, r := range encryptedResponses {
+		if err := validateEncryptedResponse(r.prefix, r.alg, r.enc, hasKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEncryptedResponse checks the <prefix>_encrypted_response_alg and <prefix>_encrypted_response_enc metadata.
+// The content encryption defaults to A128CBC-HS256 and can only be registered together with the key algorithm.
+func validateEncryptedResponse(prefix, alg, enc string, hasKeys bool) error {
+	if alg == "" && enc != "" {
+		return core.ErrInvalidClientMetadata.WithHint("The '%[1]s_encrypted_response_enc' parameter requires '%[1]s_encrypted_response_alg'.", prefix)
+	}
+
+	if alg == "" {
+		return nil
+	}
+
+	if !slices.Contains(core.ResponseEncryptionAlgorithms, alg) {
+		return core.ErrInvalidClientMetadata.WithHint("The '%s_encrypted_response_alg' value '%s' is not supported.", prefix, alg)
+	}
+
+	if enc != "" && !slices.Contains(core.ResponseEncryptionEncodings, enc) {
+		return core.ErrInvalidClientMetadata.WithHint("The '%s_encrypted_response_enc' value '%s' is not supported.", prefix, enc)
+	}
+
+	if !hasKeys {
+		return core.ErrInvalidClientMetadata.WithHint("The '%s_encrypted_response_alg' parameter requires either 'jwks' or 'jwks_uri'.", prefix)
+	}
+
+	return nil
+}
+
+func validateRedirectURI(raw string) error {
+	uri, err := url.Parse(raw)
+	if err != nil || !uri.IsAbs() || !x.IsValidRedirectURI(raw) {
+		return core.ErrInvalidRedirectURI.WithHint("The redirect URI '%s' must be an absolute URI without a fragment.", raw)
+	}
+
+	if !x.IsURISecureLax(uri) {
+		return core.ErrInvalidRedirectURI.WithHint("The redirect URI '%s' must use HTTPS unless it points to localhost.", raw)
+	}
+
+	return nil
+}
+
+func validateSecureURI(name, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	uri, err := url.Parse(raw)
+	if err != nil || !uri.IsAbs() || !x.IsURISecure(uri) {
+		return core.ErrInvalidClientMetadata.WithHint("The '%s' parameter must be an absolute HTTPS URI.", name)
+	}
+
+	return nil
+}
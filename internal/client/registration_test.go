@@ -0,0 +1,102 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/config"
+)
+
+func newRegistrationUseCase() *UseCase {
+	return &UseCase{cfg: &config.Config{
+		ClientRegistration: config.ClientRegistrationConfig{
+			DefaultScope:    "openid",
+			AllowedScope:    []string{"profile", "email"},
+			AllowedAudience: []string{"https://api.example.com"},
+		},
+	}}
+}
+
+func TestValidateRegistrationPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		md      Metadata
+		trusted bool
+		current *Client
+		wantErr bool
+	}{
+		{
+			name:    "allowed scope and audience",
+			md:      Metadata{Scope: "openid profile", Audience: []string{"https://api.example.com"}},
+			current: &Client{},
+		},
+		{
+			name:    "scope outside the allow-list",
+			md:      Metadata{Scope: "openid admin"},
+			current: &Client{},
+			wantErr: true,
+		},
+		{
+			name:    "audience outside the allow-list",
+			md:      Metadata{Scope: "openid", Audience: []string{"https://admin.example.com"}},
+			current: &Client{},
+			wantErr: true,
+		},
+		{
+			name:    "scope already held by the client",
+			md:      Metadata{Scope: "openid admin"},
+			current: &Client{Scope: "openid admin"},
+		},
+		{
+			name:    "client_credentials without initial access token",
+			md:      Metadata{Scope: "openid", GrantTypes: []string{string(core.GrantTypeClientCredentials)}},
+			current: &Client{},
+			wantErr: true,
+		},
+		{
+			name:    "token exchange without initial access token",
+			md:      Metadata{Scope: "openid", GrantTypes: []string{string(core.GrantTypeTokenExchange)}},
+			current: &Client{},
+			wantErr: true,
+		},
+		{
+			name:    "client_credentials with initial access token",
+			md:      Metadata{Scope: "openid", GrantTypes: []string{string(core.GrantTypeClientCredentials)}},
+			trusted: true,
+			current: &Client{},
+		},
+		{
+			name:    "client_credentials kept on update",
+			md:      Metadata{Scope: "openid", GrantTypes: []string{string(core.GrantTypeClientCredentials)}},
+			current: &Client{GrantTypes: []string{string(core.GrantTypeClientCredentials)}},
+		},
+	}
+
+	u := newRegistrationUseCase()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := u.validateRegistrationPolicy(&tc.md, tc.trusted, tc.current)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, core.ErrInvalidClientMetadata)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRegistrationPolicy_DoesNotShareAllowList(t *testing.T) {
+	u := newRegistrationUseCase()
+	allowed := make([]string, 1, 4)
+	allowed[0] = "https://api.example.com"
+	u.cfg.ClientRegistration.AllowedAudience = allowed
+
+	// the audience held by this client must not leak into the allow-list of the config
+	admin := &Client{Audience: []string{"https://admin.example.com"}}
+	assert.NoError(t, u.validateRegistrationPolicy(&Metadata{Scope: "openid", Audience: admin.Audience}, false, admin))
+	assert.Equal(t, []string{"https://api.example.com", ""}, allowed[:2])
+
+	err := u.validateRegistrationPolicy(&Metadata{Scope: "openid", Audience: admin.Audience}, false, &Client{})
+	assert.ErrorIs(t, err, core.ErrInvalidClientMetadata)
+}
@@ -2,9 +2,12 @@ package client
 
 import (
 	"context"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
 	"github.com/tuanta7/hydros/pkg/postgres"
 )
 
@@ -12,16 +15,23 @@ type Repository interface {
 	List(ctx context.Context, page, pageSize uint64) ([]*Client, error)
 	Create(ctx context.Context, client *Client) error
 	Get(ctx context.Context, id string) (*Client, error)
+	Update(ctx context.Context, client *Client) error
+	UpdateSecret(ctx context.Context, id, secret string) error
+	Delete(ctx context.Context, id string) error
+	ExistsAssertionJTI(ctx context.Context, jti string) (bool, error)
+	CreateAssertionJTI(ctx context.Context, jti string, exp time.Time) error
 }
 
 type clientRepository struct {
 	table    string
+	jtiTable string
 	pgClient postgres.Client
 }
 
 func NewClientRepository(pgc postgres.Client) Repository {
 	return &clientRepository{
 		table:    "client",
+		jtiTable: "client_assertion_jti",
 		pgClient: pgc,
 	}
 }
@@ -101,3 +111,133 @@ func (r *clientRepository) Get(ctx context.Context, id string) (*Client, error)
 
 	return client, nil
 }
+
+func (r *clientRepository) Update(ctx context.Context, client *Client) error {
+	m := client.ColumnMap()
+	delete(m, "id")
+	delete(m, "created_at")
+
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(r.table).
+		SetMap(m).
+		Where(squirrel.Eq{"id": client.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	ct, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *clientRepository) UpdateSecret(ctx context.Context, id, secret string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(r.table).
+		Set("secret", secret).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	ct, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *clientRepository) Delete(ctx context.Context, id string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(r.table).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	ct, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// ExistsAssertionJTI reports whether the jti of a client assertion is known and not yet expired.
+func (r *clientRepository) ExistsAssertionJTI(ctx context.Context, jti string) (bool, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("1").
+		Prefix("SELECT EXISTS (").
+		From(r.jtiTable).
+		Where(squirrel.Eq{"jti": jti}).
+		Where(squirrel.Gt{"expires_at": x.NowUTC()}).
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = r.pgClient.QueryProvider(ctx).QueryRow(ctx, query, args...).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// CreateAssertionJTI stores the jti until it expires. An expired row with the same jti is overwritten, otherwise
+// core.ErrJTIKnown is returned so concurrent requests with the same assertion can not both succeed.
+func (r *clientRepository) CreateAssertionJTI(ctx context.Context, jti string, exp time.Time) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(r.jtiTable).
+		Where(squirrel.LtOrEq{"expires_at": x.NowUTC()}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	query, args, err = r.pgClient.SQLBuilder().
+		Insert(r.jtiTable).
+		Columns("jti", "expires_at").
+		Values(jti, exp.UTC()).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	ct, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return core.ErrJTIKnown
+	}
+
+	return nil
+}
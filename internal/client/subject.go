@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+)
+
+const (
+	SubjectTypePublic   = "public"
+	SubjectTypePairwise = "pairwise"
+
+	maxSectorIdentifierDocumentSize = 64 << 10
+)
+
+var sectorIdentifierHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// SubjectIdentifier returns the subject the client knows the end-user by. Clients with the pairwise subject type
+// receive a value derived from their sector, so clients of different sectors can not correlate the end-user, see
+// OpenID Connect Core 1.0 section 8.1.
+func (u *UseCase) SubjectIdentifier(c core.Client, subject string) string {
+	client, ok := c.(*Client)
+	if !ok || client.SubjectType != SubjectTypePairwise || subject == "" {
+		return subject
+	}
+
+	sum := sha256.Sum256([]byte(client.sectorIdentifier() + subject + u.cfg.GetPairwiseSalt()))
+	return hex.EncodeToString(sum[:])
+}
+
+// sectorIdentifier is the host of the sector_identifier_uri, or of the redirect URIs when it is not registered.
+// Documents read from a file have no host, their location is used instead.
+func (c *Client) sectorIdentifier() string {
+	raw := c.SectorIdentifierURI
+	if raw == "" && len(c.RedirectURIs) > 0 {
+		raw = c.RedirectURIs[0]
+	}
+
+	uri, err := url.Parse(raw)
+	if err != nil || uri.Host == "" {
+		return raw
+	}
+
+	return uri.Hostname()
+}
+
+// validateSubjectType checks the subject type of the client and its sector_identifier_uri document, which must list
+// every redirect URI of the client, see OpenID Connect Dynamic Client Registration 1.0 section 5.
+func (u *UseCase) validateSubjectType(ctx context.Context, client *Client) error {
+	switch client.SubjectType {
+	case "":
+		client.SubjectType = SubjectTypePublic
+	case SubjectTypePublic:
+	case SubjectTypePairwise:
+		if u.cfg.GetPairwiseSalt() == "" {
+			return core.ErrInvalidClientMetadata.WithHint("The subject type 'pairwise' is not enabled.")
+		}
+	default:
+		return core.ErrInvalidClientMetadata.WithHint("The subject type '%s' is not supported.", client.SubjectType)
+	}
+
+	if client.SectorIdentifierURI == "" {
+		if client.SubjectType == SubjectTypePairwise && !sameHost(client.RedirectURIs) {
+			return core.ErrInvalidClientMetadata.WithHint("A 'sector_identifier_uri' is required when the redirect URIs of a pairwise client use multiple hosts.")
+		}
+		return nil
+	}
+
+	redirectURIs, err := loadSectorIdentifierDocument(ctx, client.SectorIdentifierURI)
+	if err != nil {
+		return core.ErrInvalidClientMetadata.
+			WithHint("Unable to load the document at the 'sector_identifier_uri'.").
+			WithWrap(err).
+			WithDebug("%s", err)
+	}
+
+	for _, uri := range client.RedirectURIs {
+		if !slices.Contains(redirectURIs, uri) {
+			return core.ErrInvalidClientMetadata.WithHint("The redirect URI '%s' is not listed in the 'sector_identifier_uri' document.", uri)
+		}
+	}
+
+	return nil
+}
+
+// loadSectorIdentifierDocument reads the JSON array of redirect URIs at location. Documents kept next to the server
+// can be referenced with the file scheme, which is rejected for dynamically registered clients.
+func loadSectorIdentifierDocument(ctx context.Context, location string) ([]string, error) {
+	uri, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	switch uri.Scheme {
+	case "file":
+		body, err = os.ReadFile(uri.Path)
+	case "https", "http":
+		body, err = fetchSectorIdentifierDocument(ctx, location)
+	default:
+		err = fmt.Errorf("unsupported scheme %q", uri.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var redirectURIs []string
+	if err = json.Unmarshal(body, &redirectURIs); err != nil {
+		return nil, err
+	}
+
+	return redirectURIs, nil
+}
+
+func fetchSectorIdentifierDocument(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := sectorIdentifierHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected status code 200 but got %d when fetching %s", resp.StatusCode, location)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSectorIdentifierDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) > maxSectorIdentifierDocumentSize {
+		return nil, fmt.Errorf("sector identifier document at %s exceeds %d bytes", location, maxSectorIdentifierDocumentSize)
+	}
+
+	return body, nil
+}
+
+func sameHost(uris []string) bool {
+	host := ""
+	for _, raw := range uris {
+		uri, err := url.Parse(raw)
+		if err != nil {
+			return false
+		}
+
+		if host != "" && uri.Hostname() != host {
+			return false
+		}
+		host = uri.Hostname()
+	}
+
+	return true
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/config"
+)
+
+func newSubjectUseCase(salt string) *UseCase {
+	return &UseCase{cfg: &config.Config{OIDC: config.OIDCConfig{PairwiseSalt: salt}}}
+}
+
+// newSectorServer serves redirectURIs as the sector_identifier_uri document.
+func newSectorServer(t *testing.T, redirectURIs ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(redirectURIs)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubjectIdentifier(t *testing.T) {
+	u := newSubjectUseCase("salt")
+
+	web := &Client{SubjectType: SubjectTypePairwise, SectorIdentifierURI: "https://sector.example.com/redirect_uris.json"}
+	mobile := &Client{SubjectType: SubjectTypePairwise, SectorIdentifierURI: "https://sector.example.com/other.json"}
+	other := &Client{SubjectType: SubjectTypePairwise, RedirectURIs: []string{"https://other.example.com/callback"}}
+	public := &Client{SubjectType: SubjectTypePublic}
+
+	// clients of the same sector know the end-user by the same subject
+	assert.Equal(t, u.SubjectIdentifier(web, "alice"), u.SubjectIdentifier(mobile, "alice"))
+	assert.NotEqual(t, "alice", u.SubjectIdentifier(web, "alice"))
+	assert.NotEqual(t, u.SubjectIdentifier(web, "alice"), u.SubjectIdentifier(web, "bob"))
+
+	// clients of another sector can not correlate it
+	assert.NotEqual(t, u.SubjectIdentifier(web, "alice"), u.SubjectIdentifier(other, "alice"))
+
+	// the salt is part of the identifier
+	assert.NotEqual(t, u.SubjectIdentifier(web, "alice"), newSubjectUseCase("pepper").SubjectIdentifier(web, "alice"))
+
+	assert.Equal(t, "alice", u.SubjectIdentifier(public, "alice"))
+	assert.Empty(t, u.SubjectIdentifier(web, ""))
+}
+
+func TestValidateSubjectType(t *testing.T) {
+	sector := newSectorServer(t, "https://app.example.com/callback", "https://mobile.example.com/callback")
+
+	cases := []struct {
+		name    string
+		salt    string
+		client  *Client
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "defaults to public",
+			client: &Client{RedirectURIs: []string{"https://app.example.com/callback"}},
+			want:   SubjectTypePublic,
+		},
+		{
+			name:    "unsupported subject type",
+			salt:    "salt",
+			client:  &Client{SubjectType: "random"},
+			wantErr: true,
+		},
+		{
+			name:    "pairwise without a salt",
+			client:  &Client{SubjectType: SubjectTypePairwise, RedirectURIs: []string{"https://app.example.com/callback"}},
+			wantErr: true,
+		},
+		{
+			name:   "pairwise with redirect URIs on one host",
+			salt:   "salt",
+			client: &Client{SubjectType: SubjectTypePairwise, RedirectURIs: []string{"https://app.example.com/a", "https://app.example.com/b"}},
+			want:   SubjectTypePairwise,
+		},
+		{
+			name:    "pairwise with redirect URIs on multiple hosts",
+			salt:    "salt",
+			client:  &Client{SubjectType: SubjectTypePairwise, RedirectURIs: []string{"https://app.example.com/callback", "https://mobile.example.com/callback"}},
+			wantErr: true,
+		},
+		{
+			name: "redirect URIs listed in the sector document",
+			salt: "salt",
+			client: &Client{
+				SubjectType:         SubjectTypePairwise,
+				SectorIdentifierURI: sector.URL + "/",
+				RedirectURIs:        []string{"https://app.example.com/callback", "https://mobile.example.com/callback"},
+			},
+			want: SubjectTypePairwise,
+		},
+		{
+			name: "redirect URI missing from the sector document",
+			salt: "salt",
+			client: &Client{
+				SubjectType:         SubjectTypePairwise,
+				SectorIdentifierURI: sector.URL + "/",
+				RedirectURIs:        []string{"https://app.example.com/callback", "https://evil.example.com/callback"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unreachable sector document",
+			salt: "salt",
+			client: &Client{
+				SubjectType:         SubjectTypePairwise,
+				SectorIdentifierURI: sector.URL + "/missing",
+				RedirectURIs:        []string{"https://app.example.com/callback"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newSubjectUseCase(tc.salt).validateSubjectType(context.Background(), tc.client)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, core.ErrInvalidClientMetadata)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, tc.client.SubjectType)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/tuanta7/hydros/core"
 	"github.com/tuanta7/hydros/core/x"
 	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/pkg/aead"
 	"github.com/tuanta7/hydros/pkg/dbtype"
 	"github.com/tuanta7/hydros/pkg/helper/stringx"
 
@@ -18,13 +19,15 @@ import (
 type UseCase struct {
 	cfg        *config.Config
 	clientRepo Repository
+	aead       aead.Cipher
 	logger     *zapx.ZapLogger
 }
 
-func NewUseCase(cfg *config.Config, clientRepo Repository, logger *zapx.ZapLogger) *UseCase {
+func NewUseCase(cfg *config.Config, clientRepo Repository, aead aead.Cipher, logger *zapx.ZapLogger) *UseCase {
 	return &UseCase{
 		cfg:        cfg,
 		clientRepo: clientRepo,
+		aead:       aead,
 		logger:     logger,
 	}
 }
@@ -48,6 +51,10 @@ func (u *UseCase) CreateClient(ctx context.Context, client *Client) error {
 		return errors.New("client cannot be nil")
 	}
 
+	if client.ID == "" {
+		client.ID = x.RandomUUID()
+	}
+
 	secret := ""
 	if client.Secret != "" {
 		secret = client.Secret
@@ -55,17 +62,11 @@ func (u *UseCase) CreateClient(ctx context.Context, client *Client) error {
 		secret = stringx.GenerateSecret(26)
 	}
 
-	hashedSecret, err := u.cfg.GetSecretsHasher().Hash(ctx, []byte(secret))
+	err := u.setSecret(ctx, client, secret)
 	if err != nil {
 		return err
 	}
 
-	client.Secret = string(hashedSecret)
-
-	if client.ID == "" {
-		client.ID = x.RandomUUID()
-	}
-
 	if client.JWKs == nil {
 		client.JWKs = &dbtype.JWKSet{}
 	}
@@ -78,6 +79,10 @@ func (u *UseCase) CreateClient(ctx context.Context, client *Client) error {
 		client.TokenEndpointAuthSigningAlg = "none"
 	}
 
+	if err = u.validateSubjectType(ctx, client); err != nil {
+		return err
+	}
+
 	client.CreatedAt = x.NowUTC().Round(time.Second)
 	client.UpdatedAt = client.CreatedAt
 
@@ -98,6 +103,26 @@ func (u *UseCase) CreateClient(ctx context.Context, client *Client) error {
 	return nil
 }
 
+// setSecret stores the hash of secret, and the encrypted secret for client_secret_jwt clients.
+func (u *UseCase) setSecret(ctx context.Context, client *Client, secret string) error {
+	hashedSecret, err := u.cfg.GetSecretsHasher().Hash(ctx, []byte(secret))
+	if err != nil {
+		return err
+	}
+
+	client.Secret = string(hashedSecret)
+	client.SecretCiphertext = ""
+
+	if client.TokenEndpointAuthMethod == core.ClientAuthenticationMethodSecretJWT {
+		client.SecretCiphertext, err = u.aead.Encrypt(ctx, []byte(secret), []byte(client.ID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (u *UseCase) GetClient(ctx context.Context, id string) (core.Client, error) {
 	client, err := u.clientRepo.Get(ctx, id)
 	if err != nil {
@@ -108,5 +133,51 @@ func (u *UseCase) GetClient(ctx context.Context, id string) (core.Client, error)
 		return nil, err
 	}
 
+	if client.SecretCiphertext != "" {
+		client.plainSecret, err = u.aead.Decrypt(ctx, client.SecretCiphertext, []byte(client.ID))
+		if err != nil {
+			u.logger.Error("cannot decrypt client secret",
+				zap.Error(err),
+				zap.String("method", "aead.Decrypt"),
+			)
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
+
+// UpdateClientSecretHash stores the rehash of a client secret, the secret itself is unchanged.
+func (u *UseCase) UpdateClientSecretHash(ctx context.Context, id string, hash []byte) error {
+	err := u.clientRepo.UpdateSecret(ctx, id, string(hash))
+	if err != nil {
+		u.logger.Error("cannot rehash client secret",
+			zap.Error(err),
+			zap.String("method", "clientRepo.UpdateSecret"),
+		)
+		return err
+	}
+
+	u.logger.Info("client secret rehashed",
+		zap.String("client_id", id),
+		zap.String("algorithm", core.HashAlgorithm(hash)),
+	)
+	return nil
+}
+
+func (u *UseCase) ClientAssertionJWTValid(ctx context.Context, jti string) error {
+	exists, err := u.clientRepo.ExistsAssertionJTI(ctx, jti)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return core.ErrJTIKnown
+	}
+
+	return nil
+}
+
+func (u *UseCase) SetClientAssertionJWT(ctx context.Context, jti string, exp time.Time) error {
+	return u.clientRepo.CreateAssertionJTI(ctx, jti, exp)
+}
@@ -34,6 +34,10 @@ type Config struct {
 	JWT           JWTConfig           `koanf:"jwt"`
 	Obfuscation   ObfuscationConfig   `koanf:"obfuscation"`
 	Identity      IdentityConfig      `koanf:"identity"`
+
+	ClientRegistration ClientRegistrationConfig `koanf:"client_registration"`
+	TLS                TLSConfig                `koanf:"tls"`
+	KeyRotation        KeyRotationConfig        `koanf:"key_rotation"`
 }
 
 func (c *Config) IsDebugging() bool {
@@ -11,9 +11,14 @@ type SessionCookieConfig struct {
 	// KeyPairs    [][]byte `koanf:"key_pairs"`
 }
 
+// CookieKeyPairs returns the hash and block key pairs of the cookie store. Cookies are signed with the global secret
+// and the rotated secrets only validate them, block keys are not set so cookies are not encrypted.
 func (c *Config) CookieKeyPairs() [][]byte {
 	var keyPairs [][]byte
-	keyPairs = append(keyPairs, c.GetGlobalSecret())
+	keyPairs = append(keyPairs, c.GetGlobalSecret(), nil)
+	for _, secret := range c.GetRotatedSecrets() {
+		keyPairs = append(keyPairs, secret, nil)
+	}
 	return keyPairs
 }
 
@@ -3,13 +3,16 @@ package config
 import (
 	"crypto/sha512"
 	"hash"
+	"strings"
 )
 
 type HMACConfig struct {
-	GlobalSecret  string           `koanf:"global_secret" json:"-" validate:"required"`
-	RotatedSecret string           `koanf:"rotated_secret" json:"-"`
-	KeyEntropy    int              `koanf:"key_entropy"`
-	Hasher        func() hash.Hash `koanf:"-" json:"-"`
+	GlobalSecret string `koanf:"global_secret" json:"-" validate:"required"`
+	// RotatedSecrets are the previous global secrets, newest first. They no longer sign anything but still validate
+	// the tokens and cookies that were signed before the rotation.
+	RotatedSecrets []string         `koanf:"rotated_secrets" json:"-"`
+	KeyEntropy     int              `koanf:"key_entropy"`
+	Hasher         func() hash.Hash `koanf:"-" json:"-"`
 }
 
 func (c *Config) GetTokenEntropy() int {
@@ -31,3 +34,14 @@ func (c *Config) GetHMACHasher() func() hash.Hash {
 func (c *Config) GetGlobalSecret() []byte {
 	return []byte(c.HMAC.GlobalSecret)
 }
+
+func (c *Config) GetRotatedSecrets() [][]byte {
+	secrets := make([][]byte, 0, len(c.HMAC.RotatedSecrets))
+	for _, secret := range c.HMAC.RotatedSecrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, []byte(secret))
+		}
+	}
+
+	return secrets
+}
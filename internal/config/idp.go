@@ -9,6 +9,9 @@ type IdentityConfig struct {
 	RegistrationURL      string        `koanf:"registration_url"`
 	LoginPageURL         string        `koanf:"login_page_url"`
 	ConsentPageURL       string        `koanf:"consent_page_url"`
+	LogoutPageURL        string        `koanf:"logout_page_url"`
+	PostLogoutURL        string        `koanf:"post_logout_url"`
+	DevicePageURL        string        `koanf:"device_page_url"`
 	ConsentRequestMaxAge time.Duration `koanf:"consent_request_max_age"`
 }
 
@@ -35,6 +38,33 @@ func (c *Config) GetConsentPageURL() *url.URL {
 	return urlWithDefault(c.Identity.ConsentPageURL, def)
 }
 
+func (c *Config) GetLogoutPageURL() *url.URL {
+	def, _ := url.Parse("/self-service/logout")
+	return urlWithDefault(c.Identity.LogoutPageURL, def)
+}
+
+// GetPostLogoutURL is where the user ends up after logging out when the client did not ask for a redirect.
+func (c *Config) GetPostLogoutURL() *url.URL {
+	def, _ := url.Parse("/self-service/logout")
+	return urlWithDefault(c.Identity.PostLogoutURL, def)
+}
+
+func (c *Config) GetDevicePageURL() *url.URL {
+	def, _ := url.Parse("/self-service/device")
+	return urlWithDefault(c.Identity.DevicePageURL, def)
+}
+
+// GetDeviceVerificationURL is the verification_uri shown to the end-user, relative page URLs are resolved against
+// the issuer since the user types it on another device.
+func (c *Config) GetDeviceVerificationURL() string {
+	issuer, err := url.Parse(c.GetIDTokenIssuer())
+	if err != nil {
+		return c.GetDevicePageURL().String()
+	}
+
+	return issuer.ResolveReference(c.GetDevicePageURL()).String()
+}
+
 func urlWithDefault(s string, def *url.URL) *url.URL {
 	parsed, err := url.Parse(s)
 	if err == nil && parsed.String() != "" {
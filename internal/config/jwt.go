@@ -1,11 +1,30 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SigningAlgorithms are the algorithms keys of the id-token and access-token sets can be generated for.
+var SigningAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+	"HS256", "HS512",
+}
 
 type JWTConfig struct {
+	// Algorithm signs JWT access tokens, IDTokenAlgorithm signs ID tokens, UserInfo and authorization responses. They
+	// are used for keys generated from now on, existing keys keep their algorithm until they are rotated.
 	Algorithm         string `koanf:"algorithm"`
 	KeySize           int    `koanf:"key_size"`
 	AccessTokenIssuer string `koanf:"access_token_issuer" validate:"required"`
+	IDTokenAlgorithm  string `koanf:"id_token_algorithm"`
+	// AllowedTopLevelClaims are the custom claims copied to the top level of JWT access tokens, all custom claims are
+	// set in the ext claim.
+	AllowedTopLevelClaims []string `koanf:"allowed_top_level_claims"`
 }
 
 func (c *Config) GetAccessTokenIssuer() string {
@@ -17,12 +36,31 @@ func (c *Config) GetAccessTokenIssuer() string {
 }
 
 func (c *Config) GetAccessTokenAlgorithm() string {
-	supported := []string{"RS256", "RS512", "HS256", "HS512"}
-	for _, alg := range supported {
-		if alg == c.JWT.Algorithm {
-			return alg
-		}
+	if slices.Contains(SigningAlgorithms, c.JWT.Algorithm) {
+		return c.JWT.Algorithm
+	}
+
+	return "RS256"
+}
+
+// GetIDTokenAlgorithm only accepts asymmetric algorithms, relying parties could not verify ID tokens without knowing
+// the secret otherwise.
+func (c *Config) GetIDTokenAlgorithm() string {
+	if slices.Contains(SigningAlgorithms, c.JWT.IDTokenAlgorithm) && !strings.HasPrefix(c.JWT.IDTokenAlgorithm, "HS") {
+		return c.JWT.IDTokenAlgorithm
 	}
 
 	return "RS256"
 }
+
+// GetRSAKeySize returns the size in bits of generated RSA keys, at least 2048 as required by RFC 7518 section 3.3.
+func (c *Config) GetRSAKeySize() int {
+	if c.JWT.KeySize < 2048 {
+		return 2048
+	}
+	return c.JWT.KeySize
+}
+
+func (c *Config) GetAllowedTopLevelClaims() []string {
+	return c.JWT.AllowedTopLevelClaims
+}
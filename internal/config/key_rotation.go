@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+type KeyRotationConfig struct {
+	// IDToken and AccessToken are how long a key of each set signs tokens before it is rotated. Scheduled rotation of
+	// a set is disabled when its interval is zero.
+	IDToken     time.Duration `koanf:"id_token"`
+	AccessToken time.Duration `koanf:"access_token"`
+	// PublishAhead is how long the next key is published in the JWKS before it starts signing tokens, it must be
+	// longer than relying parties cache the JWKS.
+	PublishAhead time.Duration `koanf:"publish_ahead" default:"24h"`
+	// CheckInterval is how often the rotator checks whether a key is due.
+	CheckInterval time.Duration `koanf:"check_interval" default:"1h"`
+}
+
+func (c *Config) GetIDTokenKeyRotationInterval() time.Duration {
+	return c.KeyRotation.IDToken
+}
+
+func (c *Config) GetAccessTokenKeyRotationInterval() time.Duration {
+	return c.KeyRotation.AccessToken
+}
+
+func (c *Config) GetKeyPublishAhead() time.Duration {
+	if c.KeyRotation.PublishAhead == 0 {
+		return time.Hour * 24
+	}
+	return c.KeyRotation.PublishAhead
+}
+
+func (c *Config) GetKeyRotationCheckInterval() time.Duration {
+	if c.KeyRotation.CheckInterval == 0 {
+		return time.Hour
+	}
+	return c.KeyRotation.CheckInterval
+}
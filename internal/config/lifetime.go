@@ -6,6 +6,14 @@ type LifetimeConfig struct {
 	AuthorizationCode time.Duration `koanf:"authorize_code" default:"10m"`
 	AccessToken       time.Duration `koanf:"access_token" default:"1h"`
 	RefreshToken      time.Duration `koanf:"refresh_token" default:"720h"`
+	DeviceCode        time.Duration `koanf:"device_code" default:"10m"`
+	// PushedAuthorizeRequest is how long a request_uri from the pushed authorization request endpoint can be used.
+	PushedAuthorizeRequest time.Duration `koanf:"pushed_authorize_request" default:"5m"`
+	// AuthorizeResponseJWT is how long a JWT secured authorization response is valid, see JARM section 2.1.
+	AuthorizeResponseJWT time.Duration `koanf:"authorize_response_jwt" default:"10m"`
+	// RetiredKey is how long a signing key keeps verifying tokens after the next key is activated. It defaults to the
+	// longest JWT lifetime, so no token outlives the key that signed it.
+	RetiredKey time.Duration `koanf:"retired_key"`
 }
 
 func (c *Config) GetRefreshTokenLifetime() time.Duration {
@@ -28,3 +36,31 @@ func (c *Config) GetAccessTokenLifetime() time.Duration {
 	}
 	return c.Lifetime.AccessToken
 }
+
+func (c *Config) GetDeviceCodeLifetime() time.Duration {
+	if c.Lifetime.DeviceCode == 0 {
+		return time.Minute * 10
+	}
+	return c.Lifetime.DeviceCode
+}
+
+func (c *Config) GetPushedAuthorizeRequestLifetime() time.Duration {
+	if c.Lifetime.PushedAuthorizeRequest == 0 {
+		return time.Minute * 5
+	}
+	return c.Lifetime.PushedAuthorizeRequest
+}
+
+func (c *Config) GetAuthorizeResponseJWTLifetime() time.Duration {
+	if c.Lifetime.AuthorizeResponseJWT == 0 {
+		return time.Minute * 10
+	}
+	return c.Lifetime.AuthorizeResponseJWT
+}
+
+func (c *Config) GetRetiredKeyLifetime() time.Duration {
+	if c.Lifetime.RetiredKey == 0 {
+		return max(c.GetAccessTokenLifetime(), c.GetIDTokenLifetime())
+	}
+	return c.Lifetime.RetiredKey
+}
@@ -1,6 +1,13 @@
 package config
 
-import "github.com/tuanta7/hydros/core/strategy"
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/strategy"
+)
 
 const (
 	MinParameterEntropy = 8
@@ -19,6 +26,23 @@ type OAuthConfig struct {
 	MinParameterEntropy            int    `koanf:"min_parameter_entropy"`
 	DisableRefreshTokenValidation  bool   `koanf:"disable_refresh_token_validation"`
 	EnablePKCEPlainChallengeMethod bool   `koanf:"enable_pkce_plain_challenge_method"`
+	// JWKSCacheTTL is how long the key sets fetched from client jwks_uri are cached.
+	JWKSCacheTTL time.Duration `koanf:"jwks_cache_ttl"`
+	// DevicePollingInterval is the minimum time a device must wait between token requests.
+	DevicePollingInterval time.Duration `koanf:"device_polling_interval"`
+	// RequirePushedAuthorizationRequests rejects authorization requests that were not pushed first, for all clients.
+	RequirePushedAuthorizationRequests bool `koanf:"require_pushed_authorization_requests"`
+	// ClientAssertionLifetime is the longest time a client assertion may be valid for. Used jti values are kept until
+	// the assertion expires, so the lifetime bounds how long they are stored.
+	ClientAssertionLifetime time.Duration `koanf:"client_assertion_lifetime"`
+	// DPoPProofLifetime is how far the iat of a DPoP proof may be from the current time.
+	DPoPProofLifetime time.Duration `koanf:"dpop_proof_lifetime"`
+	// DPoPRequireNonce makes DPoP proofs carry a nonce issued by the server in the DPoP-Nonce header.
+	DPoPRequireNonce  bool          `koanf:"dpop_require_nonce"`
+	DPoPNonceLifetime time.Duration `koanf:"dpop_nonce_lifetime"`
+
+	jwksFetcher     core.JWKSFetcher
+	jwksFetcherOnce sync.Once
 }
 
 func (c *Config) GetScopeStrategy() strategy.ScopeStrategy {
@@ -64,3 +88,65 @@ func (c *Config) IsDisableRefreshTokenValidation() bool {
 func (c *Config) IsEnablePKCEPlainChallengeMethod() bool {
 	return c.OAuth.EnablePKCEPlainChallengeMethod
 }
+
+func (c *Config) IsPushedAuthorizationRequired() bool {
+	return c.OAuth.RequirePushedAuthorizationRequests
+}
+
+func (c *Config) GetTokenURL() string {
+	u, err := url.JoinPath(c.GetIDTokenIssuer(), "/oauth/token")
+	if err != nil {
+		return c.GetIDTokenIssuer() + "/oauth/token"
+	}
+
+	return u
+}
+
+func (c *Config) GetDeviceAuthorizationPollingInterval() time.Duration {
+	if c.OAuth.DevicePollingInterval < time.Second {
+		return 5 * time.Second
+	}
+
+	return c.OAuth.DevicePollingInterval
+}
+
+func (c *Config) GetClientAssertionLifetime() time.Duration {
+	if c.OAuth.ClientAssertionLifetime <= 0 {
+		return time.Hour
+	}
+
+	return c.OAuth.ClientAssertionLifetime
+}
+
+func (c *Config) GetDPoPProofLifetime() time.Duration {
+	if c.OAuth.DPoPProofLifetime <= 0 {
+		return time.Minute
+	}
+
+	return c.OAuth.DPoPProofLifetime
+}
+
+func (c *Config) IsDPoPNonceRequired() bool {
+	return c.OAuth.DPoPRequireNonce
+}
+
+func (c *Config) GetDPoPNonceLifetime() time.Duration {
+	if c.OAuth.DPoPNonceLifetime <= 0 {
+		return 5 * time.Minute
+	}
+
+	return c.OAuth.DPoPNonceLifetime
+}
+
+func (c *Config) GetJWKSFetcher() core.JWKSFetcher {
+	c.OAuth.jwksFetcherOnce.Do(func() {
+		ttl := c.OAuth.JWKSCacheTTL
+		if ttl == 0 {
+			ttl = time.Hour
+		}
+
+		c.OAuth.jwksFetcher = core.NewDefaultJWKSFetcher(nil, ttl)
+	})
+
+	return c.OAuth.jwksFetcher
+}
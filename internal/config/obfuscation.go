@@ -3,16 +3,39 @@ package config
 import "github.com/tuanta7/hydros/core"
 
 type ObfuscationConfig struct {
-	EncryptSessionData bool        `koanf:"encrypt_session_data" json:"encrypt_session_data"`
-	SecretHasher       core.Hasher `koanf:"-" json:"-"` // bcrypt, argon2, pbkdf2, etc.
-	BCryptCost         int         `koanf:"bcrypt_cost" json:"bcrypt_cost"`
-	AESSecretKey       string      `koanf:"aes_secret_key" json:"aes_secret_key"`
+	EncryptSessionData  bool        `koanf:"encrypt_session_data" json:"encrypt_session_data"`
+	SecretHasher        core.Hasher `koanf:"-" json:"-"` // overrides SecretHashAlgorithm when set from code
+	SecretHashAlgorithm string      `koanf:"secret_hash_algorithm" json:"secret_hash_algorithm" validate:"omitempty,oneof=bcrypt argon2id pbkdf2-sha256"`
+	BCryptCost          int         `koanf:"bcrypt_cost" json:"bcrypt_cost"`
+	Argon2Memory        uint32      `koanf:"argon2_memory" json:"argon2_memory"` // KiB
+	Argon2Iterations    uint32      `koanf:"argon2_iterations" json:"argon2_iterations"`
+	Argon2Parallelism   uint8       `koanf:"argon2_parallelism" json:"argon2_parallelism"`
+	PBKDF2Iterations    int         `koanf:"pbkdf2_iterations" json:"pbkdf2_iterations"`
+	AESSecretKey        string      `koanf:"aes_secret_key" json:"aes_secret_key"`
 }
 
 func (c *Config) GetSecretsHasher() core.Hasher {
-	if c.Obfuscation.SecretHasher == nil {
-		return core.NewBCryptHasher(c.Obfuscation.BCryptCost)
+	if c.Obfuscation.SecretHasher != nil {
+		return c.Obfuscation.SecretHasher
 	}
 
-	return c.Obfuscation.SecretHasher
+	return core.NewMultiHasher(c.GetSecretHashAlgorithm(), map[string]core.RehashableHasher{
+		core.HashAlgorithmBCrypt: core.NewBCryptHasher(c.Obfuscation.BCryptCost),
+		core.HashAlgorithmArgon2id: core.NewArgon2idHasher(
+			c.Obfuscation.Argon2Memory,
+			c.Obfuscation.Argon2Iterations,
+			c.Obfuscation.Argon2Parallelism,
+		),
+		core.HashAlgorithmPBKDF2: core.NewPBKDF2Hasher(c.Obfuscation.PBKDF2Iterations),
+	})
+}
+
+// GetSecretHashAlgorithm returns the configured algorithm, an unsupported one is rejected when the config is loaded
+// instead of silently hashing with bcrypt.
+func (c *Config) GetSecretHashAlgorithm() string {
+	if c.Obfuscation.SecretHashAlgorithm == "" {
+		return core.HashAlgorithmBCrypt
+	}
+
+	return c.Obfuscation.SecretHashAlgorithm
 }
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tuanta7/hydros/core"
+)
+
+func TestSecretHashAlgorithm(t *testing.T) {
+	cases := []struct {
+		name      string
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		{name: "default", want: core.HashAlgorithmBCrypt},
+		{name: "bcrypt", algorithm: core.HashAlgorithmBCrypt, want: core.HashAlgorithmBCrypt},
+		{name: "argon2id", algorithm: core.HashAlgorithmArgon2id, want: core.HashAlgorithmArgon2id},
+		{name: "pbkdf2", algorithm: core.HashAlgorithmPBKDF2, want: core.HashAlgorithmPBKDF2},
+		{name: "unsupported", algorithm: "sha1", wantErr: true},
+		{name: "wrong case", algorithm: "BCrypt", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				HMAC:        HMACConfig{GlobalSecret: "secret"},
+				JWT:         JWTConfig{AccessTokenIssuer: "https://auth.example.com"},
+				Obfuscation: ObfuscationConfig{SecretHashAlgorithm: tc.algorithm},
+			}
+
+			err := validateConfig(cfg)
+			if tc.wantErr {
+				assert.ErrorContains(t, err, "SECRET_HASH_ALGORITHM")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, cfg.GetSecretHashAlgorithm())
+		})
+	}
+}
@@ -11,6 +11,10 @@ type OIDCConfig struct {
 	AllowedPrompts  []string
 	Issuer          string
 	IDTokenLifetime time.Duration
+	SupportedScopes []string `koanf:"supported_scopes"`
+	// PairwiseSalt is mixed into pairwise subject identifiers, changing it changes the subject of every end-user of
+	// pairwise clients.
+	PairwiseSalt string `koanf:"pairwise_salt"`
 }
 
 func (c *Config) GetAllowedPrompts() []string {
@@ -22,9 +26,18 @@ func (c *Config) GetRedirectSecureChecker() func(*url.URL) bool {
 }
 
 func (c *Config) GetIDTokenIssuer() string {
+	if c.OIDC.Issuer == "" {
+		return c.GetAccessTokenIssuer()
+	}
+
 	return c.OIDC.Issuer
 }
 
+// GetPairwiseSalt returns the salt of pairwise subject identifiers, pairwise clients can not be registered without it.
+func (c *Config) GetPairwiseSalt() string {
+	return c.OIDC.PairwiseSalt
+}
+
 func (c *Config) GetIDTokenLifetime() time.Duration {
 	if c.OIDC.IDTokenLifetime == 0 {
 		return time.Hour
@@ -32,3 +45,12 @@ func (c *Config) GetIDTokenLifetime() time.Duration {
 
 	return c.OIDC.IDTokenLifetime
 }
+
+// GetSupportedScopes returns the scopes advertised in the discovery document.
+func (c *Config) GetSupportedScopes() []string {
+	if len(c.OIDC.SupportedScopes) == 0 {
+		return []string{"openid", "offline_access", "offline", "profile", "email", "address", "phone"}
+	}
+
+	return c.OIDC.SupportedScopes
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"crypto/subtle"
+	"net/url"
+	"strings"
+)
+
+// ClientRegistrationConfig configures the dynamic client registration endpoints of RFC 7591 and RFC 7592.
+type ClientRegistrationConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// InitialAccessTokens gate who may register clients, registration is open to anyone when the list is empty.
+	InitialAccessTokens []string `koanf:"initial_access_tokens"`
+	// DefaultScope is granted to registered clients that do not request a scope.
+	DefaultScope string `koanf:"default_scope"`
+	// AllowedScope and AllowedAudience list the values registered clients may request, besides the default scope.
+	AllowedScope    []string `koanf:"allowed_scope"`
+	AllowedAudience []string `koanf:"allowed_audience"`
+}
+
+func (c *Config) IsClientRegistrationEnabled() bool {
+	return c.ClientRegistration.Enabled
+}
+
+func (c *Config) IsInitialAccessTokenRequired() bool {
+	return len(c.ClientRegistration.InitialAccessTokens) > 0
+}
+
+func (c *Config) IsInitialAccessTokenValid(token string) bool {
+	valid := 0
+	for _, t := range c.ClientRegistration.InitialAccessTokens {
+		valid |= subtle.ConstantTimeCompare([]byte(t), []byte(token))
+	}
+
+	return token != "" && valid == 1
+}
+
+func (c *Config) GetClientRegistrationDefaultScope() string {
+	return c.ClientRegistration.DefaultScope
+}
+
+// GetClientRegistrationAllowedScope returns the scopes registered clients may request, the default scope is always
+// allowed.
+func (c *Config) GetClientRegistrationAllowedScope() []string {
+	return append(strings.Fields(c.ClientRegistration.DefaultScope), c.ClientRegistration.AllowedScope...)
+}
+
+func (c *Config) GetClientRegistrationAllowedAudience() []string {
+	return c.ClientRegistration.AllowedAudience
+}
+
+// GetClientRegistrationURL is the registration endpoint, the client configuration endpoint of a registered client is
+// this URL followed by its client_id.
+func (c *Config) GetClientRegistrationURL() string {
+	u, err := url.JoinPath(c.GetIDTokenIssuer(), "/oauth/register")
+	if err != nil {
+		return c.GetIDTokenIssuer() + "/oauth/register"
+	}
+
+	return u
+}
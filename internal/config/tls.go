@@ -0,0 +1,55 @@
+package config
+
+import (
+	"crypto/x509"
+	"log"
+	"os"
+	"sync"
+)
+
+// TLSConfig makes the REST server listen with TLS and request client certificates for mutual TLS client
+// authentication, see RFC 8705.
+type TLSConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	CertFile string `koanf:"cert_file"`
+	KeyFile  string `koanf:"key_file"`
+	// ClientCAFile contains the PEM encoded certificate authorities trusted for tls_client_auth, no certificate is
+	// trusted when it is empty.
+	ClientCAFile string `koanf:"client_ca_file"`
+
+	clientCAs     *x509.CertPool
+	clientCAsOnce sync.Once
+}
+
+func (c *Config) IsTLSEnabled() bool {
+	return c.TLS.Enabled
+}
+
+func (c *Config) GetTLSCertFile() string {
+	return c.TLS.CertFile
+}
+
+func (c *Config) GetTLSKeyFile() string {
+	return c.TLS.KeyFile
+}
+
+func (c *Config) GetTLSClientCAs() *x509.CertPool {
+	c.TLS.clientCAsOnce.Do(func() {
+		c.TLS.clientCAs = x509.NewCertPool() // trust nothing rather than the system roots
+		if c.TLS.ClientCAFile == "" {
+			return
+		}
+
+		pem, err := os.ReadFile(c.TLS.ClientCAFile)
+		if err != nil {
+			log.Printf("error reading client CA file: %v", err)
+			return
+		}
+
+		if !c.TLS.clientCAs.AppendCertsFromPEM(pem) {
+			log.Printf("no certificates found in client CA file %s", c.TLS.ClientCAFile)
+		}
+	})
+
+	return c.TLS.clientCAs
+}
@@ -0,0 +1,98 @@
+package device
+
+import (
+	"context"
+	stderr "errors"
+
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/storage"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/flow"
+	"github.com/tuanta7/hydros/internal/session"
+)
+
+// UseCase handles the end-user side of the device authorization grant, where the user code typed on the device page
+// is approved or denied after the login and consent round-trip.
+type UseCase struct {
+	storage  storage.DeviceCodeStorage
+	strategy strategy.UserCodeStrategy
+}
+
+func NewUseCase(storage storage.DeviceCodeStorage, strategy strategy.UserCodeStrategy) *UseCase {
+	return &UseCase{
+		storage:  storage,
+		strategy: strategy,
+	}
+}
+
+// GetDeviceRequest returns the device authorization request of a user code that can still be approved.
+func (u *UseCase) GetDeviceRequest(ctx context.Context, userCode string) (*core.DeviceRequest, error) {
+	if userCode == "" {
+		return nil, core.ErrInvalidRequest.WithHint("The 'user_code' parameter is missing.")
+	}
+
+	dr, err := u.getUserCodeSession(ctx, userCode)
+	if stderr.Is(err, core.ErrNotFound) || stderr.Is(err, core.ErrInactiveToken) {
+		return nil, core.ErrInvalidRequest.WithHint("The user code is invalid or has already been used.")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if dr.UserCodeState != core.UserCodeUnused {
+		return nil, core.ErrInvalidRequest.WithHint("The user code is invalid or has already been used.")
+	}
+
+	if exp := dr.Session.GetExpiresAt(core.DeviceCode); !exp.IsZero() && exp.Before(x.NowUTC()) {
+		return nil, core.ErrDeviceExpiredToken.WithHint("The user code has expired, please start over on your device.")
+	}
+
+	return dr, nil
+}
+
+// AcceptUserCode stores the subject and the scope and audience granted in the consent flow, the device receives them
+// with its next token request.
+func (u *UseCase) AcceptUserCode(ctx context.Context, userCode string, dr *core.DeviceRequest, f *flow.Flow) error {
+	s, ok := dr.Session.(*session.Session)
+	if !ok {
+		return core.ErrServerError.WithDebug("expected device request session to be of type *Session, but got: %T", dr.Session)
+	}
+
+	s.SetSubject(f.SubjectIdentifier())
+	s.Claims.SessionID = f.LoginSessionID.String()
+	s.Claims.Extra = f.SessionIDToken
+	s.Extra = f.SessionAccessToken
+	s.ClientID = dr.Client.GetID()
+
+	dr.GrantedScope = core.Arguments(f.GrantedScope)
+	dr.GrantedAudience = core.Arguments(f.GrantedAudience)
+
+	return u.handleUserCodeSession(ctx, userCode, core.UserCodeAccepted, &dr.Request)
+}
+
+func (u *UseCase) RejectUserCode(ctx context.Context, userCode string) error {
+	return u.handleUserCodeSession(ctx, userCode, core.UserCodeRejected, nil)
+}
+
+// getUserCodeSession looks the user code up under the signature of every secret, so user codes issued before a secret
+// rotation can still be entered.
+func (u *UseCase) getUserCodeSession(ctx context.Context, userCode string) (*core.DeviceRequest, error) {
+	for _, signature := range u.strategy.UserCodeSignatures(ctx, userCode) {
+		dr, err := u.storage.GetUserCodeSession(ctx, signature, session.NewSession(""))
+		if !stderr.Is(err, core.ErrNotFound) {
+			return dr, err
+		}
+	}
+
+	return nil, core.ErrNotFound
+}
+
+func (u *UseCase) handleUserCodeSession(ctx context.Context, userCode string, state core.UserCodeState, req *core.Request) error {
+	for _, signature := range u.strategy.UserCodeSignatures(ctx, userCode) {
+		if err := u.storage.HandleUserCodeSession(ctx, signature, state, req); !stderr.Is(err, core.ErrNotFound) {
+			return err
+		}
+	}
+
+	return core.ErrNotFound
+}
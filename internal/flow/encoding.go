@@ -16,6 +16,8 @@ var (
 	AsLoginVerifier    AdditionalData = []byte("login_verifier")
 	AsConsentChallenge AdditionalData = []byte("consent_challenge")
 	AsConsentVerifier  AdditionalData = []byte("consent_verifier")
+	AsLogoutChallenge  AdditionalData = []byte("logout_challenge")
+	AsLogoutVerifier   AdditionalData = []byte("logout_verifier")
 )
 
 func EncodeFlow(ctx context.Context, cipher aead.Cipher, f *Flow, data AdditionalData) (string, error) {
@@ -23,13 +25,39 @@ func EncodeFlow(ctx context.Context, cipher aead.Cipher, f *Flow, data Additiona
 		f.ClientID = f.Client.ID
 	}
 
+	return encode(ctx, cipher, f, data)
+}
+
+func DecodeFlow(ctx context.Context, cipher aead.Cipher, encoded string, data AdditionalData) (*Flow, error) {
+	var f Flow
+	if err := decode(ctx, cipher, encoded, data, &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func EncodeLogoutRequest(ctx context.Context, cipher aead.Cipher, lr *LogoutRequest, data AdditionalData) (string, error) {
+	return encode(ctx, cipher, lr, data)
+}
+
+func DecodeLogoutRequest(ctx context.Context, cipher aead.Cipher, encoded string, data AdditionalData) (*LogoutRequest, error) {
+	var lr LogoutRequest
+	if err := decode(ctx, cipher, encoded, data, &lr); err != nil {
+		return nil, err
+	}
+
+	return &lr, nil
+}
+
+func encode(ctx context.Context, cipher aead.Cipher, v any, data AdditionalData) (string, error) {
 	var bb bytes.Buffer
 	gz, err := gzip.NewWriterLevel(&bb, gzip.BestCompression)
 	if err != nil {
 		return "", err
 	}
 
-	if err = json.NewEncoder(gz).Encode(f); err != nil {
+	if err = json.NewEncoder(gz).Encode(v); err != nil {
 		return "", err
 	}
 
@@ -40,25 +68,20 @@ func EncodeFlow(ctx context.Context, cipher aead.Cipher, f *Flow, data Additiona
 	return cipher.Encrypt(ctx, bb.Bytes(), data)
 }
 
-func DecodeFlow(ctx context.Context, cipher aead.Cipher, encoded string, data AdditionalData) (*Flow, error) {
+func decode(ctx context.Context, cipher aead.Cipher, encoded string, data AdditionalData, v any) error {
 	plain, err := cipher.Decrypt(ctx, encoded, data)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var f Flow
 	gz, err := gzip.NewReader(bytes.NewReader(plain))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if err = json.NewDecoder(gz).Decode(&f); err != nil {
-		return nil, err
-	}
-
-	if err = gz.Close(); err != nil {
-		return nil, err
+	if err = json.NewDecoder(gz).Decode(v); err != nil {
+		return err
 	}
 
-	return &f, nil
+	return gz.Close()
 }
@@ -49,6 +49,10 @@ type Flow struct {
 	ConsentError       *RequestDeniedError `db:"consent_error" json:"cx"`
 	ConsentWasHandled  bool                `db:"consent_was_handled" json:"cw,omitempty"`
 
+	// SessionAccessToken and SessionIDToken are the custom claims of the tokens, set when the consent is accepted.
+	SessionAccessToken dbtype.MapStringAny `db:"session_access_token" json:"ta,omitempty"`
+	SessionIDToken     dbtype.MapStringAny `db:"session_id_token" json:"ti,omitempty"`
+
 	RequestedAt       time.Time          `db:"requested_at" json:"ia,omitempty"`
 	RequestURL        string             `db:"request_url" json:"r,omitempty"`
 	RequestedScope    dbtype.StringArray `db:"requested_scope" json:"rs,omitempty"`
@@ -62,6 +66,15 @@ type Flow struct {
 	State             int16              `db:"state" json:"q,omitempty"`
 }
 
+// SubjectIdentifier returns the subject the client knows the end-user by, which is the pairwise identifier for
+// pairwise clients. Subject is the internal identifier and must never be sent to the client.
+func (f *Flow) SubjectIdentifier() string {
+	if f.ForcedSubjectIdentifier != "" {
+		return f.ForcedSubjectIdentifier
+	}
+	return f.Subject
+}
+
 func (f *Flow) ColumnMap() map[string]any {
 	return map[string]any{
 		"id":                            f.ID,
@@ -89,6 +102,9 @@ func (f *Flow) ColumnMap() map[string]any {
 		"consent_error":        f.ConsentError,
 		"consent_was_handled":  f.ConsentWasHandled,
 
+		"session_access_token": f.SessionAccessToken,
+		"session_id_token":     f.SessionIDToken,
+
 		"requested_at":       f.RequestedAt,
 		"request_url":        f.RequestURL,
 		"requested_scope":    f.RequestedScope,
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/tuanta7/hydros/core/x"
 	"github.com/tuanta7/hydros/pkg/dbtype"
@@ -29,6 +30,10 @@ func (f *Flow) HandleConsentRequest(h *HandledConsentRequest) error {
 	if h.Context != nil {
 		f.Context = h.Context
 	}
+	if h.Session != nil {
+		f.SessionAccessToken = h.Session.AccessToken
+		f.SessionIDToken = h.Session.IDToken
+	}
 
 	return nil
 }
@@ -53,4 +58,44 @@ type HandledConsentRequest struct {
 	RememberFor     int                 `json:"remember_for" form:"remember_for"`
 	Error           *RequestDeniedError `json:"-"`
 	Context         json.RawMessage     `json:"context"`
+	Session         *ConsentSession     `json:"session"`
+}
+
+// ConsentSession carries the custom claims of the tokens issued for an accepted consent request. They are kept for
+// the lifetime of the tokens, so refreshed tokens carry them too.
+type ConsentSession struct {
+	AccessToken map[string]any `json:"access_token"`
+	IDToken     map[string]any `json:"id_token"`
+}
+
+// GrantedConsent is the latest consent a subject granted to a client.
+type GrantedConsent struct {
+	ClientID    string    `json:"client_id"`
+	Scope       []string  `json:"scope"`
+	Audience    []string  `json:"audience"`
+	GrantedAt   time.Time `json:"granted_at"`
+	Remember    bool      `json:"remember"`
+	RememberFor int       `json:"remember_for"` // seconds, 0 remembers the consent until it is revoked
+}
+
+func newGrantedConsent(f *Flow) *GrantedConsent {
+	c := &GrantedConsent{
+		ClientID:  f.ClientID,
+		Scope:     f.GrantedScope,
+		Audience:  f.GrantedAudience,
+		GrantedAt: time.Time(f.ConsentGrantedAt),
+		Remember:  f.ConsentRemember,
+	}
+
+	if c.Scope == nil {
+		c.Scope = []string{}
+	}
+	if c.Audience == nil {
+		c.Audience = []string{}
+	}
+	if f.ConsentRememberFor != nil {
+		c.RememberFor = *f.ConsentRememberFor
+	}
+
+	return c
 }
@@ -0,0 +1,46 @@
+package flow
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StateLogoutInitialized = int16(10) // start the logout flow
+	StateLogoutAccepted    = int16(11) // logout confirmed on the user side
+	StateLogoutRejected    = int16(12) // logout denied on the user side
+)
+
+// LogoutRequest represents an OpenID Connect RP-Initiated Logout request. Unlike the login and consent flow it is never
+// persisted, the whole request travels inside the encrypted logout challenge and verifier.
+type LogoutRequest struct {
+	ID                    string    `json:"i"`
+	Subject               string    `json:"s,omitempty"`
+	LoginSessionID        string    `json:"si,omitempty"`
+	ClientID              string    `json:"ci,omitempty"`
+	PostLogoutRedirectURI string    `json:"pr,omitempty"`
+	State                 string    `json:"st,omitempty"`
+	RPInitiated           bool      `json:"rp,omitempty"`
+	CSRF                  string    `json:"cr,omitempty"`
+	RequestURL            string    `json:"r,omitempty"`
+	RequestedAt           time.Time `json:"ia,omitempty"`
+	FlowState             int16     `json:"q,omitempty"`
+}
+
+func (lr *LogoutRequest) HandleLogoutRequest(accepted bool) error {
+	if lr.FlowState != StateLogoutInitialized {
+		return fmt.Errorf("invalid logout state: expected %d, got %d", StateLogoutInitialized, lr.FlowState)
+	}
+
+	if accepted {
+		lr.FlowState = StateLogoutAccepted
+	} else {
+		lr.FlowState = StateLogoutRejected
+	}
+
+	return nil
+}
+
+func (lr *LogoutRequest) IsAccepted() bool {
+	return lr.FlowState == StateLogoutAccepted
+}
@@ -85,3 +85,110 @@ func (r *Repository) GetGrantedAndRememberedConsent(ctx context.Context, client,
 
 	return flow, nil
 }
+
+func (r *Repository) ListClientIDsByLoginSession(ctx context.Context, loginSessionID string) ([]string, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("DISTINCT client_id").
+		From(r.table).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"login_session_id": loginSessionID},
+				squirrel.Eq{"state": StateConsentHandled},
+			},
+		).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// ListGrantedConsents returns the latest consent the subject granted to each client, or to one client when clientID
+// is set. Consents that were skipped because an earlier one was remembered are not listed.
+func (r *Repository) ListGrantedConsents(ctx context.Context, subject, clientID string) ([]*Flow, error) {
+	where := squirrel.And{
+		squirrel.Eq{"subject": subject},
+		squirrel.Or{
+			squirrel.Eq{"state": StateConsentGranted},
+			squirrel.Eq{"state": StateConsentHandled},
+		},
+		squirrel.Eq{"consent_skip": false},
+		squirrel.Eq{"consent_error": []byte("{}")},
+	}
+	if clientID != "" {
+		where = append(where, squirrel.Eq{"client_id": clientID})
+	}
+
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("DISTINCT ON (client_id) *").
+		From(r.table).
+		Where(where).
+		OrderBy("client_id", "requested_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, postgres.ToObject[Flow])
+}
+
+// DeleteConsents deletes the flows of the subject with every client, or with one client when clientID is set.
+func (r *Repository) DeleteConsents(ctx context.Context, subject, clientID string) error {
+	where := squirrel.Eq{"subject": subject}
+	if clientID != "" {
+		where["client_id"] = clientID
+	}
+
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(r.table).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListHandledByLoginSession returns the completed flows of a login session, each of them issued tokens to its client.
+func (r *Repository) ListHandledByLoginSession(ctx context.Context, loginSessionID string) ([]*Flow, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"login_session_id": loginSessionID},
+				squirrel.Eq{"state": StateConsentHandled},
+			},
+		).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, postgres.ToObject[Flow])
+}
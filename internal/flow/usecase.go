@@ -15,19 +15,36 @@ import (
 	"go.uber.org/zap"
 )
 
+// TokenRevoker revokes the tokens issued to a client for a subject, when the subject withdraws its consent.
+type TokenRevoker interface {
+	RevokeSubjectTokens(ctx context.Context, clientID, subject string) error
+}
+
+// FlowStorage persists the flows, it is implemented by Repository.
+type FlowStorage interface {
+	Create(ctx context.Context, flow *Flow) error
+	GetGrantedAndRememberedConsent(ctx context.Context, client, subject string) (*Flow, error)
+	ListClientIDsByLoginSession(ctx context.Context, loginSessionID string) ([]string, error)
+	ListGrantedConsents(ctx context.Context, subject, clientID string) ([]*Flow, error)
+	DeleteConsents(ctx context.Context, subject, clientID string) error
+	ListHandledByLoginSession(ctx context.Context, loginSessionID string) ([]*Flow, error)
+}
+
 type UseCase struct {
-	cfg      *config.Config
-	flowRepo *Repository
-	aead     aead.Cipher
-	logger   *zapx.ZapLogger
+	cfg          *config.Config
+	flowRepo     FlowStorage
+	tokenRevoker TokenRevoker
+	aead         aead.Cipher
+	logger       *zapx.ZapLogger
 }
 
-func NewUseCase(cfg *config.Config, flowRepo *Repository, aead aead.Cipher, logger *zapx.ZapLogger) *UseCase {
+func NewUseCase(cfg *config.Config, flowRepo FlowStorage, tokenRevoker TokenRevoker, aead aead.Cipher, logger *zapx.ZapLogger) *UseCase {
 	return &UseCase{
-		cfg:      cfg,
-		flowRepo: flowRepo,
-		aead:     aead,
-		logger:   logger,
+		cfg:          cfg,
+		flowRepo:     flowRepo,
+		tokenRevoker: tokenRevoker,
+		aead:         aead,
+		logger:       logger,
 	}
 }
 
@@ -39,10 +56,18 @@ func (u *UseCase) DecodeFlow(ctx context.Context, encoded string, a AdditionalDa
 	return DecodeFlow(ctx, u.aead, encoded, a)
 }
 
+func (u *UseCase) EncodeLogoutRequest(ctx context.Context, lr *LogoutRequest, a AdditionalData) (string, error) {
+	return EncodeLogoutRequest(ctx, u.aead, lr, a)
+}
+
+func (u *UseCase) DecodeLogoutRequest(ctx context.Context, encoded string, a AdditionalData) (*LogoutRequest, error) {
+	return DecodeLogoutRequest(ctx, u.aead, encoded, a)
+}
+
 func (u *UseCase) GetLoginRequest(ctx context.Context, challenge string) (*Flow, error) {
 	f, err := DecodeFlow(ctx, u.aead, challenge, AsLoginChallenge)
 	if err != nil {
-		return nil, err
+		return nil, core.ErrInvalidRequest.WithHint("The login challenge is invalid.").WithWrap(err).WithDebug("%s", err)
 	}
 
 	if f.RequestedAt.Add(u.cfg.GetConsentRequestMaxAge()).Before(x.NowUTC()) {
@@ -55,7 +80,7 @@ func (u *UseCase) GetLoginRequest(ctx context.Context, challenge string) (*Flow,
 func (u *UseCase) GetConsentRequest(ctx context.Context, challenge string) (*Flow, error) {
 	f, err := DecodeFlow(ctx, u.aead, challenge, AsConsentChallenge)
 	if err != nil {
-		return nil, err
+		return nil, core.ErrInvalidRequest.WithHint("The consent challenge is invalid.").WithWrap(err).WithDebug("%s", err)
 	}
 
 	if f.RequestedAt.Add(u.cfg.GetConsentRequestMaxAge()).Before(x.NowUTC()) {
@@ -65,6 +90,25 @@ func (u *UseCase) GetConsentRequest(ctx context.Context, challenge string) (*Flo
 	return f, nil
 }
 
+func (u *UseCase) GetLogoutRequest(ctx context.Context, challenge string) (*LogoutRequest, error) {
+	lr, err := DecodeLogoutRequest(ctx, u.aead, challenge, AsLogoutChallenge)
+	if err != nil {
+		return nil, core.ErrInvalidRequest.WithHint("The logout challenge is invalid.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if lr.RequestedAt.Add(u.cfg.GetConsentRequestMaxAge()).Before(x.NowUTC()) {
+		return nil, core.ErrRequestUnauthorized.WithHint("The logout request has expired, please try again.")
+	}
+
+	return lr, nil
+}
+
+// ListLoggedInClientIDs returns the clients that were granted consent during the given login session, they are the
+// clients that hold tokens for it and must be notified when it ends.
+func (u *UseCase) ListLoggedInClientIDs(ctx context.Context, loginSessionID string) ([]string, error) {
+	return u.flowRepo.ListClientIDsByLoginSession(ctx, loginSessionID)
+}
+
 func (u *UseCase) FindGrantedAndRememberedConsentRequest(ctx context.Context, client, user string) (*Flow, error) {
 	flow, err := u.flowRepo.GetGrantedAndRememberedConsent(ctx, client, user)
 	if stderr.Is(err, sql.ErrNoRows) {
@@ -98,3 +142,98 @@ func (u *UseCase) SaveFlow(ctx context.Context, f *Flow) error {
 
 	return nil
 }
+
+// ListGrantedConsents returns the latest consent the subject granted to each client, or to one client when clientID
+// is set.
+func (u *UseCase) ListGrantedConsents(ctx context.Context, subject, clientID string) ([]*GrantedConsent, error) {
+	flows, err := u.flowRepo.ListGrantedConsents(ctx, subject, clientID)
+	if err != nil {
+		u.logger.Error("cannot list granted consents",
+			zap.Error(err),
+			zap.String("method", "flowRepo.ListGrantedConsents"),
+		)
+		return nil, err
+	}
+
+	consents := make([]*GrantedConsent, 0, len(flows))
+	for _, f := range flows {
+		consents = append(consents, newGrantedConsent(f))
+	}
+
+	return consents, nil
+}
+
+// RevokeConsents withdraws the consents the subject granted to a client, or to every client when clientID is empty.
+// The tokens issued under them are revoked first, so a failure leaves the consents to be revoked again.
+func (u *UseCase) RevokeConsents(ctx context.Context, subject, clientID string) error {
+	flows, err := u.flowRepo.ListGrantedConsents(ctx, subject, clientID)
+	if err != nil {
+		return err
+	}
+
+	if len(flows) == 0 {
+		return errors.ErrNotFound.WithHint("The subject has not granted consent to the client.")
+	}
+
+	err = u.revokeFlowTokens(ctx, flows)
+	if err != nil {
+		return err
+	}
+
+	err = u.flowRepo.DeleteConsents(ctx, subject, clientID)
+	if err != nil {
+		u.logger.Error("cannot delete consents",
+			zap.Error(err),
+			zap.String("method", "flowRepo.DeleteConsents"),
+		)
+		return err
+	}
+
+	u.logger.Info("consent revoked",
+		zap.String("subject", subject),
+		zap.String("client_id", clientID),
+		zap.Int("clients", len(flows)),
+	)
+	return nil
+}
+
+// RevokeLoginSessionTokens revokes the tokens issued to the clients the login session was used with. Tokens are not
+// bound to a login session, so the tokens the subject got from these clients through other sessions are revoked too.
+func (u *UseCase) RevokeLoginSessionTokens(ctx context.Context, loginSessionID string) error {
+	flows, err := u.flowRepo.ListHandledByLoginSession(ctx, loginSessionID)
+	if err != nil {
+		u.logger.Error("cannot list flows of login session",
+			zap.Error(err),
+			zap.String("method", "flowRepo.ListHandledByLoginSession"),
+		)
+		return err
+	}
+
+	return u.revokeFlowTokens(ctx, flows)
+}
+
+func (u *UseCase) revokeFlowTokens(ctx context.Context, flows []*Flow) error {
+	revoked := make(map[[2]string]bool)
+	for _, f := range flows {
+		// tokens carry the pairwise identifier of pairwise clients, older ones may carry the subject itself
+		for _, sub := range []string{f.Subject, f.SubjectIdentifier()} {
+			key := [2]string{f.ClientID, sub}
+			if revoked[key] {
+				continue
+			}
+
+			err := u.tokenRevoker.RevokeSubjectTokens(ctx, f.ClientID, sub)
+			if err != nil {
+				u.logger.Error("cannot revoke tokens",
+					zap.Error(err),
+					zap.String("client_id", f.ClientID),
+					zap.String("method", "tokenRevoker.RevokeSubjectTokens"),
+				)
+				return err
+			}
+			revoked[key] = true
+		}
+	}
+
+	return nil
+}
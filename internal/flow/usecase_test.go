@@ -0,0 +1,251 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/internal/config"
+	herrors "github.com/tuanta7/hydros/internal/errors"
+	"github.com/tuanta7/hydros/pkg/dbtype"
+	"github.com/tuanta7/hydros/pkg/zapx"
+)
+
+var errStorage = errors.New("storage unavailable")
+
+// memoryFlowStorage keeps the granted consents in memory, it follows the filters of the postgres repository.
+type memoryFlowStorage struct {
+	flows     []*Flow
+	listErr   error
+	deleteErr error
+}
+
+func (s *memoryFlowStorage) Create(ctx context.Context, flow *Flow) error {
+	s.flows = append(s.flows, flow)
+	return nil
+}
+
+func (s *memoryFlowStorage) GetGrantedAndRememberedConsent(ctx context.Context, client, subject string) (*Flow, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *memoryFlowStorage) ListClientIDsByLoginSession(ctx context.Context, loginSessionID string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *memoryFlowStorage) ListGrantedConsents(ctx context.Context, subject, clientID string) ([]*Flow, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+
+	var flows []*Flow
+	for _, f := range s.flows {
+		if f.Subject == subject && (clientID == "" || f.ClientID == clientID) {
+			flows = append(flows, f)
+		}
+	}
+	return flows, nil
+}
+
+func (s *memoryFlowStorage) DeleteConsents(ctx context.Context, subject, clientID string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+
+	kept := s.flows[:0]
+	for _, f := range s.flows {
+		if f.Subject != subject || (clientID != "" && f.ClientID != clientID) {
+			kept = append(kept, f)
+		}
+	}
+	s.flows = kept
+	return nil
+}
+
+func (s *memoryFlowStorage) ListHandledByLoginSession(ctx context.Context, loginSessionID string) ([]*Flow, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+
+	var flows []*Flow
+	for _, f := range s.flows {
+		if string(f.LoginSessionID) == loginSessionID && f.State == StateConsentHandled {
+			flows = append(flows, f)
+		}
+	}
+	return flows, nil
+}
+
+// recordingRevoker records the client and subject pairs whose tokens were revoked.
+type recordingRevoker struct {
+	revoked [][2]string
+	err     error
+}
+
+func (r *recordingRevoker) RevokeSubjectTokens(ctx context.Context, clientID, subject string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.revoked = append(r.revoked, [2]string{clientID, subject})
+	return nil
+}
+
+func newTestUseCase(t *testing.T, storage *memoryFlowStorage, revoker *recordingRevoker) *UseCase {
+	logger, err := zapx.NewLogger("fatal")
+	require.NoError(t, err)
+	return NewUseCase(&config.Config{}, storage, revoker, nil, logger)
+}
+
+func newTestFlows() []*Flow {
+	return []*Flow{
+		{ID: "1", Subject: "alice", ClientID: "client-a", LoginSessionID: dbtype.NullString("session-1"), State: StateConsentHandled},
+		{ID: "2", Subject: "alice", ClientID: "client-a", LoginSessionID: dbtype.NullString("session-2"), State: StateConsentHandled},
+		{ID: "3", Subject: "alice", ClientID: "client-b", ForcedSubjectIdentifier: "pairwise-b", LoginSessionID: dbtype.NullString("session-1"), State: StateConsentHandled},
+		{ID: "4", Subject: "bob", ClientID: "client-a", LoginSessionID: dbtype.NullString("session-3"), State: StateConsentHandled},
+	}
+}
+
+func TestUseCase_RevokeConsents(t *testing.T) {
+	cases := []struct {
+		name        string
+		subject     string
+		clientID    string
+		listErr     error
+		revokeErr   error
+		deleteErr   error
+		wantErr     error
+		wantRevoked [][2]string
+		wantKept    []string
+	}{
+		{
+			name:        "one client",
+			subject:     "alice",
+			clientID:    "client-a",
+			wantRevoked: [][2]string{{"client-a", "alice"}},
+			wantKept:    []string{"3", "4"},
+		},
+		{
+			name:        "pairwise client",
+			subject:     "alice",
+			clientID:    "client-b",
+			wantRevoked: [][2]string{{"client-b", "alice"}, {"client-b", "pairwise-b"}},
+			wantKept:    []string{"1", "2", "4"},
+		},
+		{
+			name:        "every client",
+			subject:     "alice",
+			wantRevoked: [][2]string{{"client-a", "alice"}, {"client-b", "alice"}, {"client-b", "pairwise-b"}},
+			wantKept:    []string{"4"},
+		},
+		{
+			name:     "no consent granted",
+			subject:  "alice",
+			clientID: "client-c",
+			wantErr:  herrors.ErrNotFound,
+			wantKept: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:     "consents can not be listed",
+			subject:  "alice",
+			listErr:  errStorage,
+			wantErr:  errStorage,
+			wantKept: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:      "tokens can not be revoked",
+			subject:   "alice",
+			clientID:  "client-a",
+			revokeErr: errStorage,
+			wantErr:   errStorage,
+			wantKept:  []string{"1", "2", "3", "4"}, // kept so the revocation can be retried
+		},
+		{
+			name:        "consents can not be deleted",
+			subject:     "alice",
+			clientID:    "client-a",
+			deleteErr:   errStorage,
+			wantErr:     errStorage,
+			wantRevoked: [][2]string{{"client-a", "alice"}},
+			wantKept:    []string{"1", "2", "3", "4"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &memoryFlowStorage{flows: newTestFlows(), listErr: tc.listErr, deleteErr: tc.deleteErr}
+			revoker := &recordingRevoker{err: tc.revokeErr}
+
+			err := newTestUseCase(t, storage, revoker).RevokeConsents(context.Background(), tc.subject, tc.clientID)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantRevoked, revoker.revoked)
+
+			var kept []string
+			for _, f := range storage.flows {
+				kept = append(kept, f.ID)
+			}
+			assert.Equal(t, tc.wantKept, kept)
+		})
+	}
+}
+
+func TestUseCase_RevokeLoginSessionTokens(t *testing.T) {
+	cases := []struct {
+		name           string
+		loginSessionID string
+		listErr        error
+		revokeErr      error
+		wantErr        error
+		wantRevoked    [][2]string
+	}{
+		{
+			name:           "every client of the session",
+			loginSessionID: "session-1",
+			wantRevoked:    [][2]string{{"client-a", "alice"}, {"client-b", "alice"}, {"client-b", "pairwise-b"}},
+		},
+		{
+			name:           "only the flows of the session",
+			loginSessionID: "session-3",
+			wantRevoked:    [][2]string{{"client-a", "bob"}},
+		},
+		{
+			name:           "unknown session",
+			loginSessionID: "session-4",
+		},
+		{
+			name:           "flows can not be listed",
+			loginSessionID: "session-1",
+			listErr:        errStorage,
+			wantErr:        errStorage,
+		},
+		{
+			name:           "tokens can not be revoked",
+			loginSessionID: "session-1",
+			revokeErr:      errStorage,
+			wantErr:        errStorage,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &memoryFlowStorage{flows: newTestFlows(), listErr: tc.listErr}
+			revoker := &recordingRevoker{err: tc.revokeErr}
+
+			err := newTestUseCase(t, storage, revoker).RevokeLoginSessionTokens(context.Background(), tc.loginSessionID)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantRevoked, revoker.revoked)
+		})
+	}
+}
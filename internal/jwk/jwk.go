@@ -2,6 +2,8 @@ package jwk
 
 import (
 	"time"
+
+	"github.com/go-jose/go-jose/v4"
 )
 
 type Set string
@@ -11,14 +13,41 @@ const (
 	AccessTokenSet Set = "access-token"
 )
 
+var Sets = []Set{IDTokenSet, AccessTokenSet}
+
+func ParseSet(s string) (Set, bool) {
+	for _, set := range Sets {
+		if string(set) == s {
+			return set, true
+		}
+	}
+
+	return "", false
+}
+
+// KeyState is the lifecycle state of a key. A key is created as next, activated to sign tokens, then retired when the
+// next key is activated, and revoked when it must never be trusted again.
+type KeyState string
+
+const (
+	KeyStateNext    KeyState = "next"    // published before it signs anything, so clients have it cached at rotation
+	KeyStateActive  KeyState = "active"  // signs new tokens
+	KeyStateRetired KeyState = "retired" // verifies tokens signed before the rotation until ExpiresAt
+	KeyStateRevoked KeyState = "revoked" // neither signs nor verifies anything
+)
+
 // KeyData is used to store private/secret keys in the database.
 // It is the direct replacement of SQLData in ory/hydra
 type KeyData struct {
 	KeyID     string    `json:"kid" db:"kid"`
 	SetID     Set       `json:"sid" db:"sid"`
-	Key       string    `json:"key" db:"key"`       // encrypted marshalled jose.JSONWebKey
-	Active    bool      `json:"active" db:"active"` // only one key of each set can be active at a time
+	Key       string    `json:"key" db:"key"`     // encrypted marshalled jose.JSONWebKey
+	State     KeyState  `json:"state" db:"state"` // only one key of each set can be active at a time
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// ActivatedAt is the time the key started signing tokens, rotation schedules are counted from it
+	ActivatedAt *time.Time `json:"activated_at" db:"activated_at"`
+	// ExpiresAt is the time until which a retired key can still be used to verify tokens
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
 }
 
 func (dj KeyData) ColumnMap() map[string]any {
@@ -26,7 +55,23 @@ func (dj KeyData) ColumnMap() map[string]any {
 		"kid":        dj.KeyID,
 		"sid":        dj.SetID,
 		"key":        dj.Key,
-		"active":     dj.Active,
+		"state":      dj.State,
 		"created_at": dj.CreatedAt,
+		"expires_at": dj.ExpiresAt,
+
+		"activated_at": dj.ActivatedAt,
 	}
 }
+
+// KeyInfo describes a key without its private or secret material.
+type KeyInfo struct {
+	KeyID       string           `json:"kid"`
+	SetID       Set              `json:"sid"`
+	State       KeyState         `json:"state"`
+	Algorithm   string           `json:"alg"`
+	Use         string           `json:"use"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ActivatedAt *time.Time       `json:"activated_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	PublicKey   *jose.JSONWebKey `json:"public_key,omitempty"` // symmetric keys have no public half
+}
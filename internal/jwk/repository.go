@@ -3,12 +3,18 @@ package jwk
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
+	"github.com/tuanta7/hydros/core/x"
 	"github.com/tuanta7/hydros/pkg/postgres"
 )
 
+// ErrActiveKeyExists is returned when an active key is created for a set that already has one, which happens when
+// concurrent instances create the first key of the set at the same time.
+var ErrActiveKeyExists = errors.New("the set already has an active key")
+
 type Repository struct {
 	table    string
 	pgClient postgres.Client
@@ -59,16 +65,22 @@ func (r *Repository) Create(ctx context.Context, key *KeyData) error {
 		Insert(r.table).
 		Columns(columns...).
 		Values(values...).
+		// a unique violation would abort the surrounding transaction, so the caller could not read the other key
+		Suffix("ON CONFLICT (sid) WHERE state = 'active' DO NOTHING").
 		ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	tag, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return ErrActiveKeyExists
+	}
+
 	return nil
 }
 
@@ -78,7 +90,7 @@ func (r *Repository) GetActiveKey(ctx context.Context, set Set) (*KeyData, error
 		From(r.table).
 		Where(
 			squirrel.And{
-				squirrel.Eq{"active": true},
+				squirrel.Eq{"state": KeyStateActive},
 				squirrel.Eq{"sid": set},
 			},
 		).
@@ -101,6 +113,189 @@ func (r *Repository) GetActiveKey(ctx context.Context, set Set) (*KeyData, error
 	return key, nil
 }
 
+// GetInactiveVerificationKey returns the key with the given kid if it is active, next or a retired key that has not
+// expired yet, so tokens signed before a rotation can still be verified.
 func (r *Repository) GetInactiveVerificationKey(ctx context.Context, set Set, kid string) (*KeyData, error) {
-	return nil, errors.New("using inactive key to verify token is not supported yet")
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"sid": set},
+				squirrel.Eq{"kid": kid},
+				verifiable(),
+			},
+		).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	key, err := pgx.CollectOneRow(rows, postgres.ToObject[KeyData])
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// ListVerificationKeys returns the active key, the next keys and the retired keys that have not expired yet of a set.
+func (r *Repository) ListVerificationKeys(ctx context.Context, set Set) ([]*KeyData, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"sid": set},
+				verifiable(),
+			},
+		).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys, err := pgx.CollectRows(rows, postgres.ToObject[KeyData])
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Get returns the key with the given kid regardless of its state.
+func (r *Repository) Get(ctx context.Context, set Set, kid string) (*KeyData, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"sid": set},
+				squirrel.Eq{"kid": kid},
+			},
+		).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	key, err := pgx.CollectOneRow(rows, postgres.ToObject[KeyData])
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *Repository) UpdateState(ctx context.Context, set Set, kid string, state KeyState, expiresAt *time.Time) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(r.table).
+		Set("state", state).
+		Set("expires_at", expiresAt).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"sid": set},
+				squirrel.Eq{"kid": kid},
+			},
+		).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListBySet returns every key of a set regardless of its state, newest first.
+func (r *Repository) ListBySet(ctx context.Context, set Set) ([]*KeyData, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(squirrel.Eq{"sid": set}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys, err := pgx.CollectRows(rows, postgres.ToObject[KeyData])
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Activate marks the key as the active key of the set. The previously active key must be retired first.
+func (r *Repository) Activate(ctx context.Context, set Set, kid string, activatedAt time.Time) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(r.table).
+		Set("state", KeyStateActive).
+		Set("activated_at", activatedAt).
+		Set("expires_at", nil).
+		Where(
+			squirrel.And{
+				squirrel.Eq{"sid": set},
+				squirrel.Eq{"kid": kid},
+			},
+		).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	return nil
+}
+
+func verifiable() squirrel.Or {
+	return squirrel.Or{
+		squirrel.Eq{"state": []KeyState{KeyStateActive, KeyStateNext}},
+		squirrel.And{
+			squirrel.Eq{"state": KeyStateRetired},
+			squirrel.Gt{"expires_at": x.NowUTC()},
+		},
+	}
 }
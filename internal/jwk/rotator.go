@@ -0,0 +1,81 @@
+package jwk
+
+import (
+	"context"
+	"time"
+
+	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/pkg/zapx"
+	"go.uber.org/zap"
+)
+
+// Rotator rotates the keys of every set that has a rotation interval configured. It runs next to the servers, so it
+// implements the same Run and Shutdown methods.
+type Rotator struct {
+	cfg    *config.Config
+	jwkUC  *UseCase
+	logger *zapx.ZapLogger
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewRotator(cfg *config.Config, jwkUC *UseCase, logger *zapx.ZapLogger) *Rotator {
+	return &Rotator{
+		cfg:    cfg,
+		jwkUC:  jwkUC,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (r *Rotator) Run() error {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.GetKeyRotationCheckInterval())
+	defer ticker.Stop()
+
+	for {
+		r.rotate(context.Background())
+
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return nil
+		}
+	}
+}
+
+func (r *Rotator) Shutdown(ctx context.Context) error {
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Rotator) rotate(ctx context.Context) {
+	intervals := map[Set]time.Duration{
+		IDTokenSet:     r.cfg.GetIDTokenKeyRotationInterval(),
+		AccessTokenSet: r.cfg.GetAccessTokenKeyRotationInterval(),
+	}
+
+	for set, interval := range intervals {
+		if interval <= 0 {
+			continue
+		}
+
+		err := r.jwkUC.Rotate(ctx, set, interval, r.cfg.GetKeyPublishAhead())
+		if err != nil {
+			// the next check retries, a failed rotation only delays it
+			r.logger.Error("error while rotating signing keys",
+				zap.Error(err),
+				zap.String("set", string(set)),
+				zap.String("method", "jwkUC.Rotate"),
+			)
+		}
+	}
+}
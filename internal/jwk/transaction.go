@@ -0,0 +1,35 @@
+package jwk
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func (r *Repository) BeginTX(ctx context.Context) (context.Context, error) {
+	return r.pgClient.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.Serializable,
+	})
+}
+
+func (r *Repository) Commit(ctx context.Context) error {
+	q := r.pgClient.QueryProvider(ctx)
+	tx, ok := q.(*pgxpool.Tx)
+	if !ok {
+		return errors.New("no transaction found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) Rollback(ctx context.Context) error {
+	q := r.pgClient.QueryProvider(ctx)
+	tx, ok := q.(*pgxpool.Tx)
+	if !ok {
+		return errors.New("no transaction found")
+	}
+
+	return tx.Rollback(ctx)
+}
@@ -2,19 +2,25 @@ package jwk
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
-	"errors"
+	stderr "errors"
+	"time"
 
 	"github.com/go-jose/go-jose/v4"
 	"github.com/jackc/pgx/v5"
 	"github.com/tuanta7/hydros/core/signer/jwt"
 	"github.com/tuanta7/hydros/core/x"
 	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/errors"
 	"github.com/tuanta7/hydros/pkg/aead"
 	"github.com/tuanta7/hydros/pkg/zapx"
+	"go.uber.org/zap"
 )
 
 type UseCase struct {
@@ -62,29 +68,66 @@ func (u *UseCase) GenerateJWK(alg jose.SignatureAlgorithm, use string, kid ...st
 			return nil, err
 		}
 		jwk.Key = key
-	case jose.RS256, jose.RS512:
-		bits := 2048
-		privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+		privateKey, err := rsa.GenerateKey(rand.Reader, u.cfg.GetRSAKeySize())
+		if err != nil {
+			return nil, err
+		}
+		jwk.Key = privateKey
+	case jose.ES256, jose.ES384, jose.ES512:
+		curves := map[jose.SignatureAlgorithm]elliptic.Curve{
+			jose.ES256: elliptic.P256(),
+			jose.ES384: elliptic.P384(),
+			jose.ES512: elliptic.P521(),
+		}
+		privateKey, err := ecdsa.GenerateKey(curves[alg], rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Key = privateKey
+	case jose.EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
 		if err != nil {
 			return nil, err
 		}
 		jwk.Key = privateKey
 	default:
-		return nil, errors.New("unsupported algorithm")
+		return nil, errors.ErrUnsupportedKeyAlgorithm.WithHint("Algorithm '%s' is not supported.", alg)
 	}
 
 	return jwk, nil
 }
 
+// CreateJWK stores the key as the active key of the set if there is none, otherwise as the next key, which is published
+// right away but only signs tokens once it is activated.
 func (u *UseCase) CreateJWK(ctx context.Context, set Set, jwk *jose.JSONWebKey) error {
-	active := false
+	now := x.NowUTC()
+	state := KeyStateNext
+	var activatedAt *time.Time
+
 	_, err := u.jwkRepo.GetActiveKey(ctx, set)
-	if errors.Is(err, pgx.ErrNoRows) {
-		active = true
-	} else {
+	if stderr.Is(err, pgx.ErrNoRows) {
+		state = KeyStateActive
+		activatedAt = &now
+	} else if err != nil {
 		return err
 	}
 
+	err = u.storeJWK(ctx, set, jwk, state, activatedAt)
+	if stderr.Is(err, ErrActiveKeyExists) {
+		// another instance created the active key since it was read, so the key becomes the next one
+		_, err = u.jwkRepo.GetActiveKey(ctx, set)
+		if err != nil {
+			return err
+		}
+
+		return u.storeJWK(ctx, set, jwk, KeyStateNext, nil)
+	}
+
+	return err
+}
+
+func (u *UseCase) storeJWK(ctx context.Context, set Set, jwk *jose.JSONWebKey, state KeyState, activatedAt *time.Time) error {
 	jwkBytes, err := json.Marshal(jwk)
 	if err != nil {
 		return err
@@ -96,19 +139,96 @@ func (u *UseCase) CreateJWK(ctx context.Context, set Set, jwk *jose.JSONWebKey)
 	}
 
 	err = u.jwkRepo.Create(ctx, &KeyData{
-		KeyID:     jwk.KeyID,
-		SetID:     set,
-		Key:       encrypted,
-		Active:    active,
-		CreatedAt: x.NowUTC(),
+		KeyID:       jwk.KeyID,
+		SetID:       set,
+		Key:         encrypted,
+		State:       state,
+		CreatedAt:   x.NowUTC(),
+		ActivatedAt: activatedAt,
 	})
 	if err != nil {
 		return err
 	}
 
+	u.logger.Info("signing key created",
+		zap.String("set", string(set)),
+		zap.String("kid", jwk.KeyID),
+		zap.String("alg", jwk.Algorithm),
+		zap.String("state", string(state)),
+	)
+
 	return nil
 }
 
+// GenerateKey creates a next key for the set. The algorithm defaults to the one configured for the set, so rotating
+// keys also moves the set to a newly configured algorithm.
+func (u *UseCase) GenerateKey(ctx context.Context, set Set, alg jose.SignatureAlgorithm) (*KeyInfo, error) {
+	if alg == "" {
+		alg = u.defaultAlgorithm(set)
+	}
+
+	jwk, err := u.GenerateJWK(alg, "sig")
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.CreateJWK(ctx, set, jwk)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := u.jwkRepo.Get(ctx, set, jwk.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.keyInfo(ctx, key)
+}
+
+// ListKeys returns every key of the set, including the retired and revoked ones.
+func (u *UseCase) ListKeys(ctx context.Context, set Set) ([]*KeyInfo, error) {
+	keys, err := u.jwkRepo.ListBySet(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		info, err := u.keyInfo(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (u *UseCase) keyInfo(ctx context.Context, key *KeyData) (*KeyInfo, error) {
+	jwk, err := u.decryptKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &KeyInfo{
+		KeyID:       key.KeyID,
+		SetID:       key.SetID,
+		State:       key.State,
+		Algorithm:   jwk.Algorithm,
+		Use:         jwk.Use,
+		CreatedAt:   key.CreatedAt,
+		ActivatedAt: key.ActivatedAt,
+		ExpiresAt:   key.ExpiresAt,
+	}
+
+	if public := jwk.Public(); public.Valid() {
+		info.PublicKey = &public
+	}
+
+	return info, nil
+}
+
 func (u *UseCase) GetKey(ctx context.Context, set Set, kid ...string) (*jose.JSONWebKey, error) {
 	var key *KeyData
 	var err error
@@ -122,6 +242,49 @@ func (u *UseCase) GetKey(ctx context.Context, set Set, kid ...string) (*jose.JSO
 		return nil, err
 	}
 
+	if key.State == KeyStateRetired {
+		u.logger.Debug("token verified with a retired signing key",
+			zap.String("set", string(set)),
+			zap.String("kid", key.KeyID),
+			zap.Timep("expires_at", key.ExpiresAt),
+		)
+	}
+
+	return u.decryptKey(ctx, key)
+}
+
+// GetPublicJWKs returns the public halves of the keys that can currently be used to verify tokens of the given sets.
+// Symmetric keys have no public half and are never published.
+func (u *UseCase) GetPublicJWKs(ctx context.Context, sets ...Set) (*jose.JSONWebKeySet, error) {
+	jwks := &jose.JSONWebKeySet{
+		Keys: make([]jose.JSONWebKey, 0),
+	}
+
+	for _, set := range sets {
+		keys, err := u.jwkRepo.ListVerificationKeys(ctx, set)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			jwk, err := u.decryptKey(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+
+			public := jwk.Public()
+			if !public.Valid() {
+				continue
+			}
+
+			jwks.Keys = append(jwks.Keys, public)
+		}
+	}
+
+	return jwks, nil
+}
+
+func (u *UseCase) decryptKey(ctx context.Context, key *KeyData) (*jose.JSONWebKey, error) {
 	jwkBytes, err := u.aead.Decrypt(ctx, key.Key, nil)
 	if err != nil {
 		return nil, err
@@ -143,17 +306,22 @@ func (u *UseCase) GetOrCreateJWKFn(set Set) jwt.GetPrivateKeyFn {
 		jwk, err := u.GetKey(ctx, set, kid...)
 		if err == nil {
 			return jwk, nil
-		} else if !errors.Is(err, pgx.ErrNoRows) {
+		} else if !stderr.Is(err, pgx.ErrNoRows) || len(kid) > 0 {
+			// an unknown, expired or revoked kid must fail the verification instead of creating a new key
 			return nil, err
 		}
 
-		jwk, err = u.GenerateJWK(jose.HS256, "sig")
+		jwk, err = u.GenerateJWK(u.defaultAlgorithm(set), "sig")
 		if err != nil {
 			return nil, err
 		}
 
-		err = u.CreateJWK(ctx, set, jwk)
-		if err != nil {
+		now := x.NowUTC()
+		err = u.storeJWK(ctx, set, jwk, KeyStateActive, &now)
+		if stderr.Is(err, ErrActiveKeyExists) {
+			// another instance created the first key in the meantime, sign with that one
+			return u.GetKey(ctx, set)
+		} else if err != nil {
 			return nil, err
 		}
 
@@ -161,6 +329,215 @@ func (u *UseCase) GetOrCreateJWKFn(set Set) jwt.GetPrivateKeyFn {
 	}
 }
 
+func (u *UseCase) defaultAlgorithm(set Set) jose.SignatureAlgorithm {
+	if set == IDTokenSet {
+		return jose.SignatureAlgorithm(u.cfg.GetIDTokenAlgorithm())
+	}
+
+	return jose.SignatureAlgorithm(u.cfg.GetAccessTokenAlgorithm())
+}
+
+// ActiveKey returns the key that currently signs the tokens of the set. If kid is not empty, the active key must have
+// that id, which lets callers detect that a rotation happened in between.
 func (u *UseCase) ActiveKey(ctx context.Context, set Set, kid string) (*jose.JSONWebKey, error) {
-	return nil, nil
+	key, err := u.jwkRepo.GetActiveKey(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+
+	if kid != "" && key.KeyID != kid {
+		return nil, pgx.ErrNoRows
+	}
+
+	return u.decryptKey(ctx, key)
+}
+
+// ActivateKey makes a next key the active key of the set. The previously active key is retired and keeps verifying
+// tokens it signed until the retired key lifetime has passed.
+func (u *UseCase) ActivateKey(ctx context.Context, set Set, kid string) (err error) {
+	ctx, err = u.jwkRepo.BeginTX(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = u.jwkRepo.Rollback(ctx)
+		}
+	}()
+
+	next, err := u.getKeyData(ctx, set, kid)
+	if err != nil {
+		return err
+	}
+
+	if next.State != KeyStateNext {
+		return errors.ErrConflict.WithHint("Key '%s' is %s, only a next key can be activated.", kid, next.State)
+	}
+
+	now := x.NowUTC()
+	var retired *KeyData
+	var expiresAt time.Time
+
+	retired, err = u.jwkRepo.GetActiveKey(ctx, set)
+	if err == nil {
+		expiresAt = now.Add(u.cfg.GetRetiredKeyLifetime())
+		err = u.jwkRepo.UpdateState(ctx, set, retired.KeyID, KeyStateRetired, &expiresAt)
+		if err != nil {
+			return err
+		}
+	} else if stderr.Is(err, pgx.ErrNoRows) {
+		retired = nil
+	} else {
+		return err
+	}
+
+	err = u.jwkRepo.Activate(ctx, set, kid, now)
+	if err != nil {
+		return err
+	}
+
+	err = u.jwkRepo.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Info("signing key activated",
+		zap.String("set", string(set)),
+		zap.String("kid", kid),
+	)
+
+	if retired != nil {
+		u.logger.Info("signing key retired",
+			zap.String("set", string(set)),
+			zap.String("kid", retired.KeyID),
+			zap.Time("expires_at", expiresAt),
+		)
+	}
+
+	return nil
+}
+
+// RetireKey stops publishing a next key or ends the grace period of a retired key. The active key can only be retired
+// by activating another key.
+func (u *UseCase) RetireKey(ctx context.Context, set Set, kid string) error {
+	key, err := u.getKeyData(ctx, set, kid)
+	if err != nil {
+		return err
+	}
+
+	switch key.State {
+	case KeyStateActive:
+		return errors.ErrConflict.WithHint("Key '%s' is active, activate another key to retire it.", kid)
+	case KeyStateRevoked:
+		return errors.ErrConflict.WithHint("Key '%s' is already revoked.", kid)
+	}
+
+	now := x.NowUTC()
+	err = u.jwkRepo.UpdateState(ctx, set, kid, KeyStateRetired, &now)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Info("signing key retired",
+		zap.String("set", string(set)),
+		zap.String("kid", kid),
+		zap.Time("expires_at", now),
+	)
+
+	return nil
+}
+
+// RevokeKey makes the key unusable at once, every token it signed fails the verification. Revoking the active key
+// leaves the set without one, so a new key is generated the next time a token is signed.
+func (u *UseCase) RevokeKey(ctx context.Context, set Set, kid string) error {
+	key, err := u.getKeyData(ctx, set, kid)
+	if err != nil {
+		return err
+	}
+
+	now := x.NowUTC()
+	err = u.jwkRepo.UpdateState(ctx, set, kid, KeyStateRevoked, &now)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Warn("signing key revoked",
+		zap.String("set", string(set)),
+		zap.String("kid", kid),
+		zap.String("previous_state", string(key.State)),
+	)
+
+	return nil
+}
+
+// Rotate publishes a next key once the active key of the set is due for rotation within publishAhead, and activates
+// it when the active key is due, provided it has been published for at least publishAhead. Relying parties that cache
+// the JWKS for less than publishAhead therefore know the key before the first token it signs.
+func (u *UseCase) Rotate(ctx context.Context, set Set, interval, publishAhead time.Duration) error {
+	keys, err := u.jwkRepo.ListBySet(ctx, set)
+	if err != nil {
+		return err
+	}
+
+	var active, next *KeyData
+	for _, key := range keys {
+		switch key.State {
+		case KeyStateActive:
+			active = key
+		case KeyStateNext:
+			next = key // the list is ordered newest first, so the oldest next key is kept
+		}
+	}
+
+	if active == nil {
+		return nil // the first key is created when the first token is signed
+	}
+
+	activatedAt := active.CreatedAt
+	if active.ActivatedAt != nil {
+		activatedAt = *active.ActivatedAt
+	}
+
+	now := x.NowUTC()
+	due := activatedAt.Add(interval)
+	if now.Before(due.Add(-publishAhead)) {
+		return nil
+	}
+
+	if next == nil {
+		info, err := u.GenerateKey(ctx, set, "")
+		if err != nil {
+			return err
+		}
+
+		activatesAt := now.Add(publishAhead)
+		if due.After(activatesAt) {
+			activatesAt = due
+		}
+
+		u.logger.Info("next signing key published",
+			zap.String("set", string(set)),
+			zap.String("kid", info.KeyID),
+			zap.Time("activates_at", activatesAt),
+		)
+		return nil
+	}
+
+	if now.Before(due) || now.Before(next.CreatedAt.Add(publishAhead)) {
+		return nil
+	}
+
+	return u.ActivateKey(ctx, set, next.KeyID)
+}
+
+func (u *UseCase) getKeyData(ctx context.Context, set Set, kid string) (*KeyData, error) {
+	key, err := u.jwkRepo.Get(ctx, set, kid)
+	if stderr.Is(err, pgx.ErrNoRows) {
+		return nil, errors.ErrNotFound.WithHint("Key '%s' does not exist in set '%s'.", kid, set)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return key, nil
 }
@@ -12,6 +12,7 @@ import (
 const (
 	LoginCSRFCookieKey   = "login_csrf_token"
 	ConsentCSRFCookieKey = "consent_csrf_token"
+	LogoutCSRFCookieKey  = "logout_csrf_token"
 )
 
 type CookieConfigurator interface {
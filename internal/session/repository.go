@@ -109,3 +109,42 @@ func (r *Repository) DeleteLoginSession(ctx context.Context, id string) (*LoginS
 
 	return session, nil
 }
+
+func (r *Repository) ListLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(r.table).
+		Where(squirrel.Eq{"subject": subject}).
+		OrderBy("authenticated_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, postgres.ToObject[LoginSession])
+}
+
+func (r *Repository) DeleteLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(r.table).
+		Where(squirrel.Eq{"subject": subject}).
+		Suffix("RETURNING *").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, postgres.ToObject[LoginSession])
+}
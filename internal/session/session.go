@@ -14,11 +14,14 @@ import (
 // Session is used for methods that handle business logic related to sessions.
 type Session struct {
 	*oidc.IDTokenSession `json:"id_token"`
-	Extra                map[string]any `json:"extra"`
-	KeyID                string         `json:"kid"`
-	ClientID             string         `json:"client_id"`
-	Challenge            string         `json:"challenge"`
-	Flow                 *flow.Flow     `json:"-"`
+	Extra                map[string]any   `json:"extra"`
+	KeyID                string           `json:"kid"`
+	ClientID             string           `json:"client_id"`
+	Challenge            string           `json:"challenge"`
+	Actor                *jwt.ActorClaims `json:"act,omitempty"`
+	Flow                 *flow.Flow       `json:"-"`
+	// Confirmation binds the tokens of the session to a key of the client, see RFC 9449.
+	Confirmation *core.Confirmation `json:"cnf,omitempty"`
 
 	//ExcludeNotBeforeClaim bool `json:"exclude_not_before_claim"`
 	//AllowedTopLevelClaims []string `json:"allowed_top_level_claims"`
@@ -46,12 +49,32 @@ func (s *Session) Clone() core.Session {
 	return deepcopy.Copy(s).(core.Session)
 }
 
+func (s *Session) GetActor() *jwt.ActorClaims {
+	return s.Actor
+}
+
+func (s *Session) SetActor(actor *jwt.ActorClaims) {
+	s.Actor = actor
+}
+
+func (s *Session) GetExtraClaims() map[string]any {
+	return s.Extra
+}
+
+func (s *Session) GetConfirmation() *core.Confirmation {
+	return s.Confirmation
+}
+
+func (s *Session) SetConfirmation(cnf *core.Confirmation) {
+	s.Confirmation = cnf
+}
+
 type LoginSession struct {
-	ID                        string            `db:"id"`
-	Subject                   string            `db:"subject"`
-	Remember                  bool              `db:"remember"`
-	AuthenticatedAt           dbtype.NullTime   `db:"authenticated_at"`
-	IdentityProviderSessionID dbtype.NullString `db:"identity_provider_session_id"`
+	ID                        string            `db:"id" json:"id"`
+	Subject                   string            `db:"subject" json:"subject"`
+	Remember                  bool              `db:"remember" json:"remember"`
+	AuthenticatedAt           dbtype.NullTime   `db:"authenticated_at" json:"authenticated_at"`
+	IdentityProviderSessionID dbtype.NullString `db:"identity_provider_session_id" json:"identity_provider_session_id"`
 }
 
 func NewLoginSession() *LoginSession {
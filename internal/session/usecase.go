@@ -14,17 +14,35 @@ import (
 type UseCase interface {
 	GetRememberedLoginSession(ctx context.Context, loginSessionFromCookie *LoginSession, id string) (*LoginSession, error)
 	DeleteLoginSession(ctx context.Context, id string) (deletedSession *LoginSession, err error)
-	RevokeSubjectLoginSession(ctx context.Context, user string) error
+	ListSubjectLoginSessions(ctx context.Context, user string) ([]*LoginSession, error)
+	RevokeLoginSession(ctx context.Context, id string, revokeTokens bool) error
+	RevokeSubjectLoginSession(ctx context.Context, user string, revokeTokens bool) error
 	ConfirmLoginSession(ctx context.Context, loginSession *LoginSession) error
 }
 
+// TokenRevoker revokes the tokens issued under a login session.
+type TokenRevoker interface {
+	RevokeLoginSessionTokens(ctx context.Context, loginSessionID string) error
+}
+
+// LoginSessionStorage persists the login sessions, it is implemented by Repository.
+type LoginSessionStorage interface {
+	GetRememberedLoginSession(ctx context.Context, id string) (*LoginSession, error)
+	UpsertLoginSession(ctx context.Context, session *LoginSession) error
+	DeleteLoginSession(ctx context.Context, id string) (*LoginSession, error)
+	ListLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error)
+	DeleteLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error)
+}
+
 type useCase struct {
-	sessionRepo *Repository
+	sessionRepo  LoginSessionStorage
+	tokenRevoker TokenRevoker
 }
 
-func NewUseCase(sessionRepo *Repository) UseCase {
+func NewUseCase(sessionRepo LoginSessionStorage, tokenRevoker TokenRevoker) UseCase {
 	return &useCase{
-		sessionRepo: sessionRepo,
+		sessionRepo:  sessionRepo,
+		tokenRevoker: tokenRevoker,
 	}
 }
 
@@ -54,9 +72,44 @@ func (u *useCase) DeleteLoginSession(ctx context.Context, id string) (*LoginSess
 	return session, nil
 }
 
-func (u *useCase) RevokeSubjectLoginSession(ctx context.Context, user string) error {
-	//TODO implement me
-	panic("implement me")
+func (u *useCase) ListSubjectLoginSessions(ctx context.Context, user string) ([]*LoginSession, error) {
+	return u.sessionRepo.ListLoginSessionsBySubject(ctx, user)
+}
+
+// RevokeLoginSession ends a login session, the end-user has to log in again with the next authorization request. The
+// tokens are revoked before the session is deleted, as the deletion unlinks the session from its flows.
+func (u *useCase) RevokeLoginSession(ctx context.Context, id string, revokeTokens bool) error {
+	if revokeTokens {
+		if err := u.tokenRevoker.RevokeLoginSessionTokens(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	_, err := u.DeleteLoginSession(ctx, id)
+	return err
+}
+
+// RevokeSubjectLoginSession ends every login session of the subject.
+func (u *useCase) RevokeSubjectLoginSession(ctx context.Context, user string, revokeTokens bool) error {
+	sessions, err := u.sessionRepo.ListLoginSessionsBySubject(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		return errors.ErrNotFound.WithHint("The subject has no login session.")
+	}
+
+	if revokeTokens {
+		for _, s := range sessions {
+			if err = u.tokenRevoker.RevokeLoginSessionTokens(ctx, s.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = u.sessionRepo.DeleteLoginSessionsBySubject(ctx, user)
+	return err
 }
 
 func (u *useCase) ConfirmLoginSession(ctx context.Context, session *LoginSession) error {
@@ -0,0 +1,263 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	herrors "github.com/tuanta7/hydros/internal/errors"
+)
+
+var errStorage = errors.New("storage unavailable")
+
+// memoryLoginSessionStorage keeps the login sessions in memory, every call is appended to the shared calls so the test
+// can check that the tokens are revoked before the sessions are deleted.
+type memoryLoginSessionStorage struct {
+	sessions  []*LoginSession
+	calls     *[]string
+	listErr   error
+	deleteErr error
+}
+
+func (s *memoryLoginSessionStorage) GetRememberedLoginSession(ctx context.Context, id string) (*LoginSession, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *memoryLoginSessionStorage) UpsertLoginSession(ctx context.Context, session *LoginSession) error {
+	return errors.New("not implemented")
+}
+
+func (s *memoryLoginSessionStorage) DeleteLoginSession(ctx context.Context, id string) (*LoginSession, error) {
+	if s.deleteErr != nil {
+		return nil, s.deleteErr
+	}
+
+	for i, session := range s.sessions {
+		if session.ID == id {
+			*s.calls = append(*s.calls, "delete "+id)
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			return session, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (s *memoryLoginSessionStorage) ListLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+
+	var sessions []*LoginSession
+	for _, session := range s.sessions {
+		if session.Subject == subject {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (s *memoryLoginSessionStorage) DeleteLoginSessionsBySubject(ctx context.Context, subject string) ([]*LoginSession, error) {
+	if s.deleteErr != nil {
+		return nil, s.deleteErr
+	}
+
+	var deleted, kept []*LoginSession
+	for _, session := range s.sessions {
+		if session.Subject == subject {
+			deleted = append(deleted, session)
+		} else {
+			kept = append(kept, session)
+		}
+	}
+
+	*s.calls = append(*s.calls, "delete "+subject)
+	s.sessions = kept
+	return deleted, nil
+}
+
+type recordingRevoker struct {
+	calls *[]string
+	err   error
+}
+
+func (r *recordingRevoker) RevokeLoginSessionTokens(ctx context.Context, loginSessionID string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	*r.calls = append(*r.calls, "revoke "+loginSessionID)
+	return nil
+}
+
+func newTestLoginSessions() []*LoginSession {
+	return []*LoginSession{
+		{ID: "session-1", Subject: "alice"},
+		{ID: "session-2", Subject: "alice"},
+		{ID: "session-3", Subject: "bob"},
+	}
+}
+
+func TestUseCase_RevokeLoginSession(t *testing.T) {
+	cases := []struct {
+		name         string
+		id           string
+		revokeTokens bool
+		revokeErr    error
+		deleteErr    error
+		wantErr      error
+		wantCalls    []string
+		wantKept     []string
+	}{
+		{
+			name:         "with tokens",
+			id:           "session-1",
+			revokeTokens: true,
+			wantCalls:    []string{"revoke session-1", "delete session-1"},
+			wantKept:     []string{"session-2", "session-3"},
+		},
+		{
+			name:      "without tokens",
+			id:        "session-1",
+			wantCalls: []string{"delete session-1"},
+			wantKept:  []string{"session-2", "session-3"},
+		},
+		{
+			name:         "unknown session",
+			id:           "session-4",
+			revokeTokens: true,
+			wantErr:      herrors.ErrNotFound,
+			wantCalls:    []string{"revoke session-4"},
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+		{
+			name:         "tokens can not be revoked",
+			id:           "session-1",
+			revokeTokens: true,
+			revokeErr:    errStorage,
+			wantErr:      errStorage,
+			wantKept:     []string{"session-1", "session-2", "session-3"}, // kept so the revocation can be retried
+		},
+		{
+			name:         "session can not be deleted",
+			id:           "session-1",
+			revokeTokens: true,
+			deleteErr:    errStorage,
+			wantErr:      errStorage,
+			wantCalls:    []string{"revoke session-1"},
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			storage := &memoryLoginSessionStorage{sessions: newTestLoginSessions(), calls: &calls, deleteErr: tc.deleteErr}
+
+			uc := NewUseCase(storage, &recordingRevoker{calls: &calls, err: tc.revokeErr})
+			err := uc.RevokeLoginSession(context.Background(), tc.id, tc.revokeTokens)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantCalls, calls)
+
+			var kept []string
+			for _, session := range storage.sessions {
+				kept = append(kept, session.ID)
+			}
+			assert.Equal(t, tc.wantKept, kept)
+		})
+	}
+}
+
+func TestUseCase_RevokeSubjectLoginSession(t *testing.T) {
+	cases := []struct {
+		name         string
+		subject      string
+		revokeTokens bool
+		listErr      error
+		revokeErr    error
+		deleteErr    error
+		wantErr      error
+		wantCalls    []string
+		wantKept     []string
+	}{
+		{
+			name:         "with tokens",
+			subject:      "alice",
+			revokeTokens: true,
+			wantCalls:    []string{"revoke session-1", "revoke session-2", "delete alice"},
+			wantKept:     []string{"session-3"},
+		},
+		{
+			name:      "without tokens",
+			subject:   "alice",
+			wantCalls: []string{"delete alice"},
+			wantKept:  []string{"session-3"},
+		},
+		{
+			name:         "subject without login session",
+			subject:      "carol",
+			revokeTokens: true,
+			wantErr:      herrors.ErrNotFound,
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+		{
+			name:         "sessions can not be listed",
+			subject:      "alice",
+			revokeTokens: true,
+			listErr:      errStorage,
+			wantErr:      errStorage,
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+		{
+			name:         "tokens can not be revoked",
+			subject:      "alice",
+			revokeTokens: true,
+			revokeErr:    errStorage,
+			wantErr:      errStorage,
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+		{
+			name:         "sessions can not be deleted",
+			subject:      "alice",
+			revokeTokens: true,
+			deleteErr:    errStorage,
+			wantErr:      errStorage,
+			wantCalls:    []string{"revoke session-1", "revoke session-2"},
+			wantKept:     []string{"session-1", "session-2", "session-3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			storage := &memoryLoginSessionStorage{
+				sessions:  newTestLoginSessions(),
+				calls:     &calls,
+				listErr:   tc.listErr,
+				deleteErr: tc.deleteErr,
+			}
+
+			uc := NewUseCase(storage, &recordingRevoker{calls: &calls, err: tc.revokeErr})
+			err := uc.RevokeSubjectLoginSession(context.Background(), tc.subject, tc.revokeTokens)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantCalls, calls)
+
+			var kept []string
+			for _, session := range storage.sessions {
+				kept = append(kept, session.ID)
+			}
+			assert.Equal(t, tc.wantKept, kept)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	stderr "errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/pkg/postgres"
+)
+
+// DeviceRequestData extends RequestSessionData with the user code and the polling state of a device authorization
+// request.
+type DeviceRequestData struct {
+	RequestSessionData
+	UserCodeSignature string       `db:"user_code_signature"`
+	UserCodeState     int16        `db:"user_code_state"`
+	LastPolledAt      sql.NullTime `db:"last_polled_at"`
+	ExpiresAt         time.Time    `db:"expires_at"`
+}
+
+func (s *DeviceRequestData) ColumnMap() map[string]any {
+	data := s.RequestSessionData.ColumnMap()
+	data["user_code_signature"] = s.UserCodeSignature
+	data["user_code_state"] = s.UserCodeState
+	data["last_polled_at"] = s.LastPolledAt
+	data["expires_at"] = s.ExpiresAt
+	return data
+}
+
+func (r *RequestSessionRepo) CreateDeviceCode(ctx context.Context, session *DeviceRequestData) error {
+	data := session.ColumnMap()
+	var columns []string
+	var values []any
+
+	for k, v := range data {
+		columns = append(columns, k)
+		values = append(values, v)
+	}
+
+	query, args, err := r.pgClient.SQLBuilder().
+		Insert(tableName[core.DeviceCode]).
+		Columns(columns...).
+		Values(values...).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RequestSessionRepo) GetDeviceCode(ctx context.Context, column, signature string) (*DeviceRequestData, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(tableName[core.DeviceCode]).
+		Where(squirrel.Eq{column: signature}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	session, err := pgx.CollectOneRow(rows, postgres.ToObject[DeviceRequestData])
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// UpdateUserCode records the decision of the end-user. Only unused user codes can be updated, so a code can not be
+// approved twice.
+func (r *RequestSessionRepo) UpdateUserCode(ctx context.Context, userCodeSignature string, data map[string]any) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[core.DeviceCode]).
+		SetMap(data).
+		Where(squirrel.Eq{
+			"user_code_signature": userCodeSignature,
+			"user_code_state":     int16(core.UserCodeUnused),
+			"active":              true,
+		}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return core.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *RequestSessionRepo) UpdateDeviceCodePolledAt(ctx context.Context, signature string, polledAt time.Time) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[core.DeviceCode]).
+		Set("last_polled_at", polledAt).
+		Where(squirrel.Eq{"signature": signature}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RequestSessionStorage) CreateDeviceCodeSession(
+	ctx context.Context,
+	deviceCodeSignature, userCodeSignature string,
+	req *core.Request,
+) error {
+	s, err := r.sessionFromRequest(ctx, deviceCodeSignature, req, core.DeviceCode)
+	if err != nil {
+		return err
+	}
+
+	return r.pg.CreateDeviceCode(ctx, &DeviceRequestData{
+		RequestSessionData: *s,
+		UserCodeSignature:  userCodeSignature,
+		UserCodeState:      int16(core.UserCodeUnused),
+		ExpiresAt:          s.InternalExpiresAt.Time,
+	})
+}
+
+func (r *RequestSessionStorage) GetDeviceCodeSession(ctx context.Context, deviceCodeSignature string, session core.Session) (*core.DeviceRequest, error) {
+	s, err := r.pg.GetDeviceCode(ctx, "signature", deviceCodeSignature)
+	if stderr.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !s.Active {
+		return nil, core.ErrInactiveToken
+	}
+
+	return s.toDeviceRequest(ctx, session, r)
+}
+
+func (r *RequestSessionStorage) GetUserCodeSession(ctx context.Context, userCodeSignature string, session core.Session) (*core.DeviceRequest, error) {
+	s, err := r.pg.GetDeviceCode(ctx, "user_code_signature", userCodeSignature)
+	if stderr.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !s.Active {
+		return nil, core.ErrInactiveToken
+	}
+
+	return s.toDeviceRequest(ctx, session, r)
+}
+
+func (r *RequestSessionStorage) HandleUserCodeSession(
+	ctx context.Context,
+	userCodeSignature string,
+	state core.UserCodeState,
+	req *core.Request,
+) error {
+	data := map[string]any{
+		"user_code_state": int16(state),
+	}
+
+	if state == core.UserCodeAccepted {
+		s, err := r.sessionFromRequest(ctx, "", req, core.DeviceCode)
+		if err != nil {
+			return err
+		}
+
+		data["granted_scope"] = s.GrantedScope
+		data["granted_audience"] = s.GrantedAudience
+		data["session_data"] = s.Session
+		data["subject"] = s.Subject
+	}
+
+	return r.pg.UpdateUserCode(ctx, userCodeSignature, data)
+}
+
+func (r *RequestSessionStorage) UpdateDeviceCodePolledAt(ctx context.Context, deviceCodeSignature string, polledAt time.Time) error {
+	return r.pg.UpdateDeviceCodePolledAt(ctx, deviceCodeSignature, polledAt)
+}
+
+func (r *RequestSessionStorage) InvalidateDeviceCodeSession(ctx context.Context, deviceCodeSignature string) error {
+	return r.pg.DeactivateActiveBySignature(ctx, core.DeviceCode, deviceCodeSignature)
+}
+
+func (s *DeviceRequestData) toDeviceRequest(ctx context.Context, session core.Session, r *RequestSessionStorage) (*core.DeviceRequest, error) {
+	req, err := s.ToRequest(ctx, s.Signature, session, core.DeviceCode, r.aead)
+	if err != nil {
+		return nil, err
+	}
+
+	// an empty granted scope is stored as an empty string, which must not turn into a single empty scope
+	if s.GrantedScope == "" {
+		req.GrantedScope = nil
+	}
+	if s.GrantedAudience == "" {
+		req.GrantedAudience = nil
+	}
+
+	dr := &core.DeviceRequest{
+		Request:       *req,
+		UserCodeState: core.UserCodeState(s.UserCodeState),
+	}
+	if s.LastPolledAt.Valid {
+		dr.LastPolledAt = s.LastPolledAt.Time
+	}
+
+	return dr, nil
+}
@@ -0,0 +1,166 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	stderr "errors"
+	"net/url"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/client"
+	"github.com/tuanta7/hydros/pkg/postgres"
+)
+
+const parTableName = "par"
+
+// PARData is a pushed authorization request. Only the parameters are kept, the request is validated again when it
+// reaches the authorization endpoint.
+type PARData struct {
+	RequestURI  string    `db:"request_uri"`
+	ClientID    string    `db:"client_id"`
+	Form        string    `db:"form_data"`
+	RequestedAt time.Time `db:"requested_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func (p *PARData) ColumnMap() map[string]any {
+	return map[string]any{
+		"request_uri":  p.RequestURI,
+		"client_id":    p.ClientID,
+		"form_data":    p.Form,
+		"requested_at": p.RequestedAt,
+		"expires_at":   p.ExpiresAt,
+	}
+}
+
+func (r *RequestSessionRepo) CreatePAR(ctx context.Context, par *PARData) error {
+	data := par.ColumnMap()
+	var columns []string
+	var values []any
+
+	for k, v := range data {
+		columns = append(columns, k)
+		values = append(values, v)
+	}
+
+	query, args, err := r.pgClient.SQLBuilder().
+		Insert(parTableName).
+		Columns(columns...).
+		Values(values...).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RequestSessionRepo) GetPAR(ctx context.Context, requestURI string, now time.Time) (*PARData, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("*").
+		From(parTableName).
+		Where(squirrel.Eq{"request_uri": requestURI}).
+		Where(squirrel.Gt{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	par, err := pgx.CollectOneRow(rows, postgres.ToObject[PARData])
+	if err != nil {
+		return nil, err
+	}
+
+	return par, nil
+}
+
+// DeletePAR removes a pushed authorization request together with the expired ones. It returns sql.ErrNoRows when
+// requestURI was already removed, so a handle can only be consumed once.
+func (r *RequestSessionRepo) DeletePAR(ctx context.Context, requestURI string, now time.Time) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(parTableName).
+		Where(squirrel.Or{
+			squirrel.Eq{"request_uri": requestURI},
+			squirrel.LtOrEq{"expires_at": now},
+		}).
+		Suffix("RETURNING request_uri").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	deleted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deleted {
+		if d == requestURI {
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+func (r *RequestSessionStorage) CreatePARSession(ctx context.Context, requestURI string, req *core.AuthorizeRequest, expiresAt time.Time) error {
+	return r.pg.CreatePAR(ctx, &PARData{
+		RequestURI:  requestURI,
+		ClientID:    req.Client.GetID(),
+		Form:        req.Form.Encode(),
+		RequestedAt: req.RequestedAt,
+		ExpiresAt:   expiresAt.UTC(),
+	})
+}
+
+func (r *RequestSessionStorage) GetPARSession(ctx context.Context, requestURI string) (*core.Request, error) {
+	p, err := r.pg.GetPAR(ctx, requestURI, x.NowUTC())
+	if stderr.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	form, err := url.ParseQuery(p.Form)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.Request{
+		RequestedAt: p.RequestedAt,
+		Form:        form,
+		Client: &client.Client{
+			ID: p.ClientID,
+		},
+	}, nil
+}
+
+func (r *RequestSessionStorage) DeletePARSession(ctx context.Context, requestURI string) error {
+	err := r.pg.DeletePAR(ctx, requestURI, x.NowUTC())
+	if stderr.Is(err, sql.ErrNoRows) {
+		return core.ErrNotFound
+	}
+
+	return err
+}
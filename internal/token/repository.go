@@ -2,9 +2,12 @@ package token
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/tuanta7/hydros/core"
 	"github.com/tuanta7/hydros/pkg/postgres"
 )
@@ -14,6 +17,7 @@ var tableName = map[core.TokenType]string{
 	core.RefreshToken:      "refresh_token",
 	core.IDToken:           "id_token",
 	core.AuthorizationCode: "code",
+	core.DeviceCode:        "device_code",
 	PKCE:                   "pkce",
 	OIDC:                   "oidc",
 }
@@ -95,3 +99,141 @@ func (r *RequestSessionRepo) DeleteBySignature(ctx context.Context, tokenType co
 
 	return nil
 }
+
+func (r *RequestSessionRepo) DeleteByRequestID(ctx context.Context, tokenType core.TokenType, requestID string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Delete(tableName[tokenType]).
+		Where(squirrel.Eq{"request_id": requestID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RequestSessionRepo) DeactivateBySignature(ctx context.Context, tokenType core.TokenType, signature string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[tokenType]).
+		Set("active", false).
+		Where(squirrel.Eq{"signature": signature}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeactivateActiveBySignature deactivates the token only while it is still active, so that only one of the
+// concurrent requests using the same token succeeds. The others get core.ErrInactiveToken.
+func (r *RequestSessionRepo) DeactivateActiveBySignature(ctx context.Context, tokenType core.TokenType, signature string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[tokenType]).
+		Set("active", false).
+		Where(squirrel.Eq{
+			"signature": signature,
+			"active":    true,
+		}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if isSerializationFailure(err) {
+		// a concurrent transaction deactivated the token after this one started
+		return core.ErrInactiveToken
+	} else if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return core.ErrInactiveToken
+	}
+
+	return nil
+}
+
+func (r *RequestSessionRepo) DeactivateByRequestID(ctx context.Context, tokenType core.TokenType, requestID string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[tokenType]).
+		Set("active", false).
+		Where(squirrel.Eq{"request_id": requestID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountBySecretID counts the active tokens requested after the given time, grouped by the secret that signed them.
+func (r *RequestSessionRepo) CountBySecretID(ctx context.Context, tokenType core.TokenType, requestedAfter time.Time) (map[string]int, error) {
+	query, args, err := r.pgClient.SQLBuilder().
+		Select("secret_id", "COUNT(*)").
+		From(tableName[tokenType]).
+		Where(squirrel.Eq{"active": true}).
+		Where(squirrel.Gt{"requested_at": requestedAfter}).
+		GroupBy("secret_id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pgClient.QueryProvider(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var secretID string
+		var count int
+		if err = rows.Scan(&secretID, &count); err != nil {
+			return nil, err
+		}
+		counts[secretID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *RequestSessionRepo) DeactivateBySubject(ctx context.Context, tokenType core.TokenType, clientID, subject string) error {
+	query, args, err := r.pgClient.SQLBuilder().
+		Update(tableName[tokenType]).
+		Set("active", false).
+		Where(squirrel.Eq{"client_id": clientID, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pgClient.QueryProvider(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
@@ -30,6 +30,8 @@ type RequestSessionData struct {
 	Subject         string         `db:"subject"`
 	Active          bool           `db:"active"`
 	Challenge       sql.NullString `db:"challenge"`
+	// SecretID identifies the global secret that signed the token, see hmac.SecretID.
+	SecretID string `db:"secret_id"`
 
 	// InternalExpiresAt denormalizes the expiry from the session to additionally store it as a row.
 	InternalExpiresAt sql.NullTime `db:"-" json:"-"`
@@ -50,6 +52,7 @@ func (s *RequestSessionData) ColumnMap() map[string]any {
 		"subject":          s.Subject,
 		"active":           s.Active,
 		"challenge":        s.Challenge,
+		"secret_id":        s.SecretID,
 		// "expires_at":       s.InternalExpiresAt,
 	}
 }
@@ -7,8 +7,10 @@ import (
 	stderr "errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/hmac"
 	"github.com/tuanta7/hydros/internal/config"
 	"github.com/tuanta7/hydros/internal/session"
 	"github.com/tuanta7/hydros/pkg/aead"
@@ -78,27 +80,57 @@ func (r *RequestSessionStorage) DeleteAccessTokenSession(ctx context.Context, si
 }
 
 func (r *RequestSessionStorage) RevokeAccessToken(ctx context.Context, requestID string) error {
-	return nil
+	return r.pg.DeleteByRequestID(ctx, core.AccessToken, requestID)
 }
 
 func (r *RequestSessionStorage) CreateRefreshTokenSession(ctx context.Context, signature string, accessSignature string, req *core.Request) (err error) {
-	return nil
+	s, err := r.sessionFromRequest(ctx, signature, req, core.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return r.pg.Create(ctx, core.RefreshToken, s)
 }
 
 func (r *RequestSessionStorage) GetRefreshTokenSession(ctx context.Context, signature string, session core.Session) (*core.Request, error) {
-	return nil, nil
+	s, err := r.pg.GetBySignature(ctx, core.RefreshToken, signature)
+	if stderr.Is(err, sql.ErrNoRows) {
+		return nil, core.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rr, err := s.ToRequest(ctx, signature, session, core.RefreshToken, r.aead)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.Active {
+		// the refresh token has been rotated or revoked, the request is returned so the caller can
+		// revoke the whole token family
+		return rr, core.ErrInactiveToken
+	}
+
+	return rr, nil
 }
 
 func (r *RequestSessionStorage) DeleteRefreshTokenSession(ctx context.Context, signature string) error {
-	return nil
+	return r.pg.DeleteBySignature(ctx, core.RefreshToken, signature)
 }
 
+// RotateRefreshToken deactivates the used refresh token, keeping it around for replay detection, and removes the
+// access tokens that were issued alongside it. core.ErrInactiveToken is returned when the refresh token has already
+// been rotated, including by a concurrent request.
 func (r *RequestSessionStorage) RotateRefreshToken(ctx context.Context, requestID string, signature string) (err error) {
-	return nil
+	if err = r.pg.DeactivateActiveBySignature(ctx, core.RefreshToken, signature); err != nil {
+		return err
+	}
+
+	return r.pg.DeleteByRequestID(ctx, core.AccessToken, requestID)
 }
 
 func (r *RequestSessionStorage) RevokeRefreshToken(ctx context.Context, requestID string) error {
-	return nil
+	return r.pg.DeactivateByRequestID(ctx, core.RefreshToken, requestID)
 }
 
 func (r *RequestSessionStorage) CreateAuthorizeCodeSession(ctx context.Context, code string, req *core.Request) (err error) {
@@ -131,8 +163,11 @@ func (r *RequestSessionStorage) GetAuthorizationCodeSession(ctx context.Context,
 	return ar, nil
 }
 
+// InvalidateAuthorizeCodeSession deactivates the authorization code, keeping it around so that a replayed code is
+// detected. core.ErrInactiveToken is returned when the code has already been exchanged, including by a concurrent
+// request.
 func (r *RequestSessionStorage) InvalidateAuthorizeCodeSession(ctx context.Context, code string) (err error) {
-	return r.pg.DeleteBySignature(ctx, core.AuthorizationCode, code)
+	return r.pg.DeactivateActiveBySignature(ctx, core.AuthorizationCode, code)
 }
 
 func (r *RequestSessionStorage) GetPKCERequestSession(ctx context.Context, authorizeCode string, session core.Session) (*core.Request, error) {
@@ -181,6 +216,48 @@ func (r *RequestSessionStorage) DeleteOpenIDConnectSession(ctx context.Context,
 	return r.pg.DeleteBySignature(ctx, OIDC, authorizeCode)
 }
 
+// RevokeSubjectTokens deactivates every token and code issued to the client for the subject. JWT access tokens stay
+// valid for resource servers that do not introspect them, until they expire.
+func (r *RequestSessionStorage) RevokeSubjectTokens(ctx context.Context, clientID, subject string) error {
+	for _, tokenType := range []core.TokenType{
+		core.AccessToken, core.RefreshToken, core.AuthorizationCode, core.DeviceCode, PKCE, OIDC,
+	} {
+		if err := r.pg.DeactivateBySubject(ctx, tokenType, clientID, subject); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CountLiveTokensBySecret counts the tokens which may still be presented, grouped by the ID of the secret that signed
+// them. A token counts as live while it is active and younger than the lifetime of its type.
+func (r *RequestSessionStorage) CountLiveTokensBySecret(ctx context.Context) (map[string]int, error) {
+	lifetimes := map[core.TokenType]time.Duration{
+		core.AccessToken:       r.cfg.GetAccessTokenLifetime(),
+		core.RefreshToken:      r.cfg.GetRefreshTokenLifetime(),
+		core.AuthorizationCode: r.cfg.GetAuthorizationCodeLifetime(),
+		core.DeviceCode:        r.cfg.GetDeviceCodeLifetime(),
+		PKCE:                   r.cfg.GetAuthorizationCodeLifetime(),
+		OIDC:                   r.cfg.GetAuthorizationCodeLifetime(),
+	}
+
+	now := time.Now().UTC()
+	total := make(map[string]int)
+	for tokenType, lifetime := range lifetimes {
+		counts, err := r.pg.CountBySecretID(ctx, tokenType, now.Add(-lifetime))
+		if err != nil {
+			return nil, err
+		}
+
+		for secretID, count := range counts {
+			total[secretID] += count
+		}
+	}
+
+	return total, nil
+}
+
 func (r *RequestSessionStorage) sessionFromRequest(
 	ctx context.Context,
 	signature string,
@@ -224,6 +301,7 @@ func (r *RequestSessionStorage) sessionFromRequest(
 		Subject:           req.Session.GetSubject(),
 		Active:            true,
 		Challenge:         challenge,
+		SecretID:          hmac.SecretID(r.cfg.GetGlobalSecret()),
 		InternalExpiresAt: sql.NullTime{Valid: true, Time: req.Session.GetExpiresAt(tokenType).UTC()},
 	}, nil
 }
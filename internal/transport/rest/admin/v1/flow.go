@@ -131,3 +131,219 @@ func (h *FlowHandler) RejectLogin(c *gin.Context) {
 		return
 	}
 }
+
+func (h *FlowHandler) GetConsentFlow(c *gin.Context) {
+	ctx := c.Request.Context()
+	challenge := c.Query("consent_challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'consent_challenge' is not defined but should have been."))
+		return
+	}
+
+	f, err := h.flowUC.GetConsentRequest(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if f.ConsentWasHandled {
+		c.JSON(http.StatusGone, gin.H{
+			"redirect_to": f.RequestURL, // authorize request
+		})
+		return
+	}
+
+	if f.RequestedScope == nil {
+		f.RequestedScope = []string{}
+	}
+
+	if f.RequestedAudience == nil {
+		f.RequestedAudience = []string{}
+	}
+
+	f.Client = client.SanitizeClient(f.Client)
+	c.JSON(http.StatusOK, gin.H{
+		"skip":               f.ConsentSkip,
+		"subject":            f.Subject,
+		"client":             f.Client,
+		"request_url":        f.RequestURL,
+		"requested_scope":    f.RequestedScope,
+		"requested_audience": f.RequestedAudience,
+		"session_id":         f.LoginSessionID,
+		"acr":                f.ACR,
+		"amr":                f.AMR,
+		"context":            f.Context,
+	})
+}
+
+func (h *FlowHandler) AcceptConsent(c *gin.Context) {
+	var handledConsentRequest flow.HandledConsentRequest
+	d := json.NewDecoder(c.Request.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&handledConsentRequest); err != nil {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Unable to decode body: %s", err).WithWrap(err))
+		return
+	}
+
+	h.handleConsent(c, &handledConsentRequest)
+}
+
+func (h *FlowHandler) RejectConsent(c *gin.Context) {
+	var deniedErr flow.RequestDeniedError
+	d := json.NewDecoder(c.Request.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&deniedErr); err != nil {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Unable to decode body: %s", err).WithWrap(err))
+		return
+	}
+
+	deniedErr.Valid = true
+	deniedErr.SetDefaults(flow.ConsentRequestDeniedErrorName)
+	h.handleConsent(c, &flow.HandledConsentRequest{
+		Error: &deniedErr,
+	})
+}
+
+// handleConsent completes the consent request and returns the authorization request to continue with, which carries
+// the consent verifier just like the built-in consent page redirects to.
+func (h *FlowHandler) handleConsent(c *gin.Context, handledConsentRequest *flow.HandledConsentRequest) {
+	ctx := c.Request.Context()
+	challenge := c.Query("consent_challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'consent_challenge' is not defined but should have been."))
+		return
+	}
+
+	f, err := h.flowUC.GetConsentRequest(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	err = f.HandleConsentRequest(handledConsentRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	verifier, err := h.flowUC.EncodeFlow(ctx, f, flow.AsConsentVerifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	redirectTo, err := urlx.AppendQueryString(f.RequestURL, url.Values{"consent_verifier": []string{verifier}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+// ListConsentSessions lists the latest consent the subject granted to each client, e.g. for a "connected apps" page.
+func (h *FlowHandler) ListConsentSessions(c *gin.Context) {
+	subject := c.Query("subject")
+	if subject == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'subject' is not defined but should have been."))
+		return
+	}
+
+	consents, err := h.flowUC.ListGrantedConsents(c.Request.Context(), subject, c.Query("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
+
+// RevokeConsentSessions withdraws the consent of the subject for one client, or for every client with all=true, and
+// revokes the tokens issued under it.
+func (h *FlowHandler) RevokeConsentSessions(c *gin.Context) {
+	subject := c.Query("subject")
+	if subject == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'subject' is not defined but should have been."))
+		return
+	}
+
+	clientID := c.Query("client_id")
+	if clientID == "" && c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'client_id' or 'all=true' must be set."))
+		return
+	}
+
+	err := h.flowUC.RevokeConsents(c.Request.Context(), subject, clientID)
+	if err != nil {
+		rfcErr := core.ErrorToRFC6749Error(err)
+		c.JSON(rfcErr.CodeField, rfcErr)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *FlowHandler) GetLogoutFlow(c *gin.Context) {
+	ctx := c.Request.Context()
+	challenge := c.Query("logout_challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'logout_challenge' is not defined but should have been."))
+		return
+	}
+
+	lr, err := h.flowUC.GetLogoutRequest(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subject":      lr.Subject,
+		"session_id":   lr.LoginSessionID,
+		"client_id":    lr.ClientID,
+		"rp_initiated": lr.RPInitiated,
+		"request_url":  lr.RequestURL,
+	})
+}
+
+func (h *FlowHandler) AcceptLogout(c *gin.Context) {
+	h.handleLogout(c, true)
+}
+
+func (h *FlowHandler) RejectLogout(c *gin.Context) {
+	h.handleLogout(c, false)
+}
+
+func (h *FlowHandler) handleLogout(c *gin.Context, accepted bool) {
+	ctx := c.Request.Context()
+	challenge := c.Query("logout_challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'logout_challenge' is not defined but should have been."))
+		return
+	}
+
+	lr, err := h.flowUC.GetLogoutRequest(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if err = lr.HandleLogoutRequest(accepted); err != nil {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	verifier, err := h.flowUC.EncodeLogoutRequest(ctx, lr, flow.AsLogoutVerifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	redirectTo, err := urlx.AppendQueryString(lr.RequestURL, url.Values{"logout_verifier": []string{verifier}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
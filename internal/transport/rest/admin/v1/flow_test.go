@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/flow"
+	"github.com/tuanta7/hydros/pkg/aead"
+)
+
+const testRequestURL = "https://auth.example.com/oauth/authorize?client_id=client&state=state-value"
+
+type consentTestSetup struct {
+	cipher aead.Cipher
+	router *gin.Engine
+}
+
+func newConsentTestSetup(t *testing.T) *consentTestSetup {
+	cipher, err := aead.NewAESGCM([]byte(strings.Repeat("k", 32)))
+	require.NoError(t, err)
+
+	h := NewFlowHandler(flow.NewUseCase(&config.Config{}, nil, nil, cipher, nil))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/consent/accept", h.AcceptConsent)
+	router.PUT("/consent/reject", h.RejectConsent)
+
+	return &consentTestSetup{cipher: cipher, router: router}
+}
+
+func newConsentFlow() *flow.Flow {
+	return &flow.Flow{
+		ID:             "flow-id",
+		Subject:        "alice",
+		ClientID:       "client",
+		RequestedAt:    x.NowUTC(),
+		RequestURL:     testRequestURL,
+		RequestedScope: []string{"openid", "profile"},
+		State:          flow.StateConsentInitialized,
+	}
+}
+
+func (s *consentTestSetup) challenge(t *testing.T, f *flow.Flow, as flow.AdditionalData) string {
+	challenge, err := flow.EncodeFlow(context.Background(), s.cipher, f, as)
+	require.NoError(t, err)
+	return challenge
+}
+
+func (s *consentTestSetup) do(path, challenge, body string) *httptest.ResponseRecorder {
+	target := path
+	if challenge != "" {
+		target += "?" + url.Values{"consent_challenge": {challenge}}.Encode()
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, target, strings.NewReader(body)))
+	return rec
+}
+
+// verifiedFlow decodes the consent verifier of the redirect_to URL, which must continue the authorization request.
+func (s *consentTestSetup) verifiedFlow(t *testing.T, rec *httptest.ResponseRecorder) *flow.Flow {
+	var resp struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	redirectTo, err := url.Parse(resp.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com/oauth/authorize", redirectTo.Scheme+"://"+redirectTo.Host+redirectTo.Path)
+	assert.Equal(t, "client", redirectTo.Query().Get("client_id"))
+	assert.Equal(t, "state-value", redirectTo.Query().Get("state"))
+
+	f, err := flow.DecodeFlow(context.Background(), s.cipher, redirectTo.Query().Get("consent_verifier"), flow.AsConsentVerifier)
+	require.NoError(t, err)
+	return f
+}
+
+func TestFlowHandler_AcceptConsent(t *testing.T) {
+	s := newConsentTestSetup(t)
+
+	rec := s.do("/consent/accept", s.challenge(t, newConsentFlow(), flow.AsConsentChallenge),
+		`{"scope":["openid"],"remember":true,"remember_for":3600,"session":{"access_token":{"tenant":"acme"}}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	f := s.verifiedFlow(t, rec)
+	assert.Equal(t, flow.StateConsentGranted, f.State)
+	assert.Equal(t, []string{"openid"}, []string(f.GrantedScope))
+	assert.True(t, f.ConsentRemember)
+	assert.Equal(t, 3600, *f.ConsentRememberFor)
+	assert.Equal(t, "acme", f.SessionAccessToken["tenant"])
+	assert.False(t, f.ConsentError.IsError())
+}
+
+func TestFlowHandler_RejectConsent(t *testing.T) {
+	s := newConsentTestSetup(t)
+
+	rec := s.do("/consent/reject", s.challenge(t, newConsentFlow(), flow.AsConsentChallenge),
+		`{"error_description":"The user denied the request."}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	f := s.verifiedFlow(t, rec)
+	assert.Equal(t, flow.StateConsentError, f.State)
+	assert.Empty(t, f.GrantedScope)
+	require.True(t, f.ConsentError.IsError())
+	assert.Equal(t, flow.ConsentRequestDeniedErrorName, f.ConsentError.Error)
+	assert.Equal(t, "The user denied the request.", f.ConsentError.ErrorDescription)
+	assert.Equal(t, http.StatusBadRequest, f.ConsentError.Code)
+}
+
+func TestFlowHandler_HandleConsentErrors(t *testing.T) {
+	s := newConsentTestSetup(t)
+
+	expired := newConsentFlow()
+	expired.RequestedAt = x.NowUTC().Add(-time.Hour)
+
+	loginFlow := newConsentFlow()
+	loginFlow.State = flow.StateLoginInitialized
+
+	handled := newConsentFlow()
+	handled.State = flow.StateConsentHandled
+
+	cases := []struct {
+		name      string
+		path      string
+		challenge string
+		body      string
+		wantCode  int
+	}{
+		{
+			name:     "accept without challenge",
+			path:     "/consent/accept",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "reject without challenge",
+			path:     "/consent/reject",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "malformed challenge",
+			path:      "/consent/accept",
+			challenge: "not-a-challenge",
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "login challenge",
+			path:      "/consent/accept",
+			challenge: s.challenge(t, newConsentFlow(), flow.AsLoginChallenge),
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "consent verifier",
+			path:      "/consent/reject",
+			challenge: s.challenge(t, newConsentFlow(), flow.AsConsentVerifier),
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "expired consent request",
+			path:      "/consent/accept",
+			challenge: s.challenge(t, expired, flow.AsConsentChallenge),
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "login not completed",
+			path:      "/consent/accept",
+			challenge: s.challenge(t, loginFlow, flow.AsConsentChallenge),
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "consent already handled",
+			path:      "/consent/reject",
+			challenge: s.challenge(t, handled, flow.AsConsentChallenge),
+			body:      `{}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "accept with unknown field",
+			path:      "/consent/accept",
+			challenge: s.challenge(t, newConsentFlow(), flow.AsConsentChallenge),
+			body:      `{"scope":["openid"],"redirect_to":"https://attacker.example.com"}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "reject with unknown field",
+			path:      "/consent/reject",
+			challenge: s.challenge(t, newConsentFlow(), flow.AsConsentChallenge),
+			body:      `{"redirect_to":"https://attacker.example.com"}`,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "malformed body",
+			path:      "/consent/accept",
+			challenge: s.challenge(t, newConsentFlow(), flow.AsConsentChallenge),
+			body:      `{"scope":`,
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := s.do(tc.path, tc.challenge, tc.body)
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			resp := map[string]any{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.NotEmpty(t, resp["error"])
+			assert.NotContains(t, resp, "redirect_to")
+		})
+	}
+}
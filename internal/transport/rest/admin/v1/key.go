@@ -1 +1,130 @@
 package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/errors"
+	"github.com/tuanta7/hydros/internal/jwk"
+)
+
+type KeyHandler struct {
+	jwkUC *jwk.UseCase
+}
+
+func NewKeyHandler(jwkUC *jwk.UseCase) *KeyHandler {
+	return &KeyHandler{
+		jwkUC: jwkUC,
+	}
+}
+
+type keySet struct {
+	Set  jwk.Set        `json:"set"`
+	Keys []*jwk.KeyInfo `json:"keys"`
+}
+
+type generateKeyRequest struct {
+	Algorithm string `json:"alg"`
+}
+
+func (h *KeyHandler) ListSets(c *gin.Context) {
+	sets := make([]keySet, 0, len(jwk.Sets))
+	for _, set := range jwk.Sets {
+		keys, err := h.jwkUC.ListKeys(c.Request.Context(), set)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+			return
+		}
+
+		sets = append(sets, keySet{Set: set, Keys: keys})
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+func (h *KeyHandler) List(c *gin.Context) {
+	set, ok := h.getSet(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.jwkUC.ListKeys(c.Request.Context(), set)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, keySet{Set: set, Keys: keys})
+}
+
+// Generate creates a next key for the set, which is published right away and only signs tokens once it is activated.
+func (h *KeyHandler) Generate(c *gin.Context) {
+	set, ok := h.getSet(c)
+	if !ok {
+		return
+	}
+
+	var req generateKeyRequest
+	if c.Request.ContentLength != 0 {
+		d := json.NewDecoder(c.Request.Body)
+		d.DisallowUnknownFields()
+		if err := d.Decode(&req); err != nil {
+			c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Unable to decode body: %s", err).WithWrap(err))
+			return
+		}
+	}
+
+	key, err := h.jwkUC.GenerateKey(c.Request.Context(), set, jose.SignatureAlgorithm(req.Algorithm))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *KeyHandler) Activate(c *gin.Context) {
+	h.handleKey(c, h.jwkUC.ActivateKey)
+}
+
+func (h *KeyHandler) Retire(c *gin.Context) {
+	h.handleKey(c, h.jwkUC.RetireKey)
+}
+
+func (h *KeyHandler) Revoke(c *gin.Context) {
+	h.handleKey(c, h.jwkUC.RevokeKey)
+}
+
+func (h *KeyHandler) handleKey(c *gin.Context, fn func(ctx context.Context, set jwk.Set, kid string) error) {
+	set, ok := h.getSet(c)
+	if !ok {
+		return
+	}
+
+	err := fn(c.Request.Context(), set, c.Param("kid"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *KeyHandler) getSet(c *gin.Context) (jwk.Set, bool) {
+	set, ok := jwk.ParseSet(c.Param("set"))
+	if !ok {
+		c.JSON(http.StatusNotFound, errors.ErrNotFound.WithHint("Key set '%s' does not exist.", c.Param("set")))
+		return "", false
+	}
+
+	return set, true
+}
+
+func (h *KeyHandler) writeError(c *gin.Context, err error) {
+	rfcErr := core.ErrorToRFC6749Error(err)
+	c.JSON(rfcErr.CodeField, rfcErr)
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/session"
+)
+
+type SessionHandler struct {
+	sessionUC session.UseCase
+}
+
+func NewSessionHandler(sessionUC session.UseCase) *SessionHandler {
+	return &SessionHandler{
+		sessionUC: sessionUC,
+	}
+}
+
+func (h *SessionHandler) ListLoginSessions(c *gin.Context) {
+	subject := c.Query("subject")
+	if subject == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'subject' is not defined but should have been."))
+		return
+	}
+
+	sessions, err := h.sessionUC.ListSubjectLoginSessions(c.Request.Context(), subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	if sessions == nil {
+		sessions = []*session.LoginSession{}
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeLoginSession ends one login session, with revoke_tokens=true the tokens issued under it are revoked too.
+func (h *SessionHandler) RevokeLoginSession(c *gin.Context) {
+	err := h.sessionUC.RevokeLoginSession(c.Request.Context(), c.Param("id"), c.Query("revoke_tokens") == "true")
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeSubjectLoginSessions ends every login session of the subject, e.g. when its account is compromised.
+func (h *SessionHandler) RevokeSubjectLoginSessions(c *gin.Context) {
+	subject := c.Query("subject")
+	if subject == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("Query parameter 'subject' is not defined but should have been."))
+		return
+	}
+
+	err := h.sessionUC.RevokeSubjectLoginSession(c.Request.Context(), subject, c.Query("revoke_tokens") == "true")
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SessionHandler) writeError(c *gin.Context, err error) {
+	rfcErr := core.ErrorToRFC6749Error(err)
+	c.JSON(rfcErr.CodeField, rfcErr)
+}
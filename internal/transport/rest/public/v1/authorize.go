@@ -12,12 +12,16 @@ import (
 	"github.com/tuanta7/hydros/core"
 	"github.com/tuanta7/hydros/core/handler/oidc"
 	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/internal/client"
 	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/device"
 	"github.com/tuanta7/hydros/internal/errors"
 	"github.com/tuanta7/hydros/internal/flow"
 	"github.com/tuanta7/hydros/internal/jwk"
 	"github.com/tuanta7/hydros/internal/session"
 	"github.com/tuanta7/hydros/pkg/zapx"
+	"go.uber.org/zap"
 )
 
 const (
@@ -25,32 +29,41 @@ const (
 )
 
 type OAuthHandler struct {
-	cfg       *config.Config
-	store     *sessions.CookieStore
-	oauth2    core.OAuth2Provider
-	jwkUC     *jwk.UseCase
-	sessionUC session.UseCase
-	flowUC    *flow.UseCase
-	logger    *zapx.ZapLogger
+	cfg           *config.Config
+	store         *sessions.CookieStore
+	oauth2        core.OAuth2Provider
+	idTokenSigner strategy.JWTSigner
+	jwkUC         *jwk.UseCase
+	clientUC      *client.UseCase
+	sessionUC     session.UseCase
+	flowUC        *flow.UseCase
+	deviceUC      *device.UseCase
+	logger        *zapx.ZapLogger
 }
 
 func NewOAuthHandler(
 	cfg *config.Config,
 	store *sessions.CookieStore,
 	oauth2 core.OAuth2Provider,
+	idTokenSigner strategy.JWTSigner,
 	jwkUC *jwk.UseCase,
+	clientUC *client.UseCase,
 	sessionUC session.UseCase,
 	flowUC *flow.UseCase,
+	deviceUC *device.UseCase,
 	logger *zapx.ZapLogger,
 ) *OAuthHandler {
 	return &OAuthHandler{
-		cfg:       cfg,
-		store:     store,
-		oauth2:    oauth2,
-		jwkUC:     jwkUC,
-		sessionUC: sessionUC,
-		flowUC:    flowUC,
-		logger:    logger,
+		cfg:           cfg,
+		store:         store,
+		oauth2:        oauth2,
+		idTokenSigner: idTokenSigner,
+		jwkUC:         jwkUC,
+		clientUC:      clientUC,
+		sessionUC:     sessionUC,
+		flowUC:        flowUC,
+		deviceUC:      deviceUC,
+		logger:        logger,
 	}
 }
 
@@ -63,6 +76,13 @@ func (h *OAuthHandler) HandleAuthorizeRequest(c *gin.Context) {
 		return
 	}
 
+	fromObject := ar.PushedRequestURI != "" || c.Query("request") != "" || c.Query("request_uri") != ""
+	if fromObject && strings.Contains(c.Query("prompt"), "login") {
+		// a login flow can send the end-user back with prompt=login to force a new login, which is not part of
+		// the pushed parameters or the request object
+		ar.Prompt = ar.Prompt.Append("login")
+	}
+
 	f, err := h.handleAuthorizeRequest(ctx, c.Writer, c.Request, ar)
 	if stderr.Is(err, errors.ErrAbortOAuth2Request) {
 		return
@@ -78,11 +98,14 @@ func (h *OAuthHandler) HandleAuthorizeRequest(c *gin.Context) {
 		IDTokenSession: &oidc.IDTokenSession{
 			Claims: &jwt.IDTokenClaims{
 				RegisteredClaims: gojwt.RegisteredClaims{
-					Subject: f.Subject, // id of authenticated user
+					Subject: f.SubjectIdentifier(), // id of authenticated user, pairwise for pairwise clients
 				},
+				SessionID: f.LoginSessionID.String(), // used by front-channel and back-channel logout
+				Extra:     f.SessionIDToken,
 			},
 		},
-		Flow: f,
+		Extra: f.SessionAccessToken,
+		Flow:  f,
 	})
 	if err != nil {
 		h.writeAuthorizeError(c, ar, err)
@@ -92,14 +115,41 @@ func (h *OAuthHandler) HandleAuthorizeRequest(c *gin.Context) {
 	h.oauth2.WriteAuthorizeResponse(ctx, c.Writer, ar, authorizeResponse)
 }
 
+// HandlePushedAuthorizeRequest implements the pushed authorization request endpoint of RFC 9126.
+func (h *OAuthHandler) HandlePushedAuthorizeRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	ar, err := h.oauth2.NewPushedAuthorizeRequest(ctx, c.Request)
+	if err != nil {
+		h.logger.Error("error validating pushed authorization request",
+			zap.Error(err),
+			zap.String("method", "oauth2.NewPushedAuthorizeRequest"),
+		)
+		h.oauth2.WritePushedAuthorizeError(ctx, c.Writer, err)
+		return
+	}
+
+	parResponse, err := h.oauth2.NewPushedAuthorizeResponse(ctx, ar, session.NewSession(""))
+	if err != nil {
+		h.logger.Error("error populating pushed authorization response",
+			zap.Error(err),
+			zap.String("method", "oauth2.NewPushedAuthorizeResponse"),
+		)
+		h.oauth2.WritePushedAuthorizeError(ctx, c.Writer, err)
+		return
+	}
+
+	h.oauth2.WritePushedAuthorizeResponse(ctx, c.Writer, parResponse)
+}
+
 func (h *OAuthHandler) handleAuthorizeRequest(
 	ctx context.Context,
 	w http.ResponseWriter,
 	r *http.Request,
 	req *core.AuthorizeRequest,
 ) (*flow.Flow, error) {
-	loginVerifier := strings.TrimSpace(req.Form.Get("login_verifier"))
-	consentVerifier := strings.TrimSpace(req.Form.Get("consent_verifier"))
+	// the verifiers are read from the request itself, the form of a pushed request only holds the pushed parameters
+	loginVerifier := strings.TrimSpace(r.FormValue("login_verifier"))
+	consentVerifier := strings.TrimSpace(r.FormValue("consent_verifier"))
 	if loginVerifier == "" && consentVerifier == "" {
 		return nil, h.requestLogin(ctx, w, r, req)
 	} else if loginVerifier != "" {
@@ -108,6 +158,11 @@ func (h *OAuthHandler) handleAuthorizeRequest(
 			return nil, err
 		}
 
+		// the consent app sees the subject the client will receive
+		if subject := h.clientUC.SubjectIdentifier(req.Client, f.Subject); subject != f.Subject {
+			f.ForcedSubjectIdentifier = subject
+		}
+
 		return nil, h.requestConsent(ctx, w, r, req, f)
 	}
 
@@ -26,8 +26,8 @@ func (h *OAuthHandler) requestConsent(
 		return h.forwardConsentRequest(ctx, w, r, ar, f, nil)
 	}
 
-	if ar.Client.IsPublic() && !x.IsURISecure(ar.RedirectURI) {
-		// insecure redirect uri for public clients always requires consent
+	if ar.Client.IsPublic() && (ar.RedirectURI == nil || !x.IsURISecure(ar.RedirectURI)) {
+		// insecure or missing redirect uri (device flow) for public clients always requires consent
 		return h.forwardConsentRequest(ctx, w, r, ar, f, nil)
 	}
 
@@ -54,7 +54,7 @@ func (h *OAuthHandler) verifyConsent(ctx context.Context, r *http.Request, verif
 
 	err = f.InvalidateConsentRequest()
 	if err != nil {
-		return nil, core.ErrInvalidRequest.WithDebug(err.Error())
+		return nil, core.ErrInvalidRequest.WithDebug("%s", err)
 	}
 
 	// persist login and consent request
@@ -95,6 +95,11 @@ func (h *OAuthHandler) forwardConsentRequest(
 	skip := false
 	if previousConsent != nil {
 		skip = true
+		// a remembered consent grants the same scope, audience and custom claims again
+		f.GrantedScope = previousConsent.GrantedScope
+		f.GrantedAudience = previousConsent.GrantedAudience
+		f.SessionAccessToken = previousConsent.SessionAccessToken
+		f.SessionIDToken = previousConsent.SessionIDToken
 	}
 
 	csrf := x.RandomUUID()
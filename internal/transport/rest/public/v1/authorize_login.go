@@ -159,7 +159,7 @@ func (h *OAuthHandler) verifyLogin(
 	}
 
 	if err = f.InvalidateLoginRequest(); err != nil {
-		return nil, core.ErrInvalidRequest.WithDebug(err.Error())
+		return nil, core.ErrInvalidRequest.WithDebug("%s", err)
 	}
 
 	if f.LoginError.IsError() {
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	stderr "errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/internal/errors"
+	"github.com/tuanta7/hydros/internal/flow"
+	"github.com/tuanta7/hydros/internal/session"
+	"go.uber.org/zap"
+)
+
+// HandleDeviceAuthRequest implements the device authorization endpoint of RFC 8628.
+func (h *OAuthHandler) HandleDeviceAuthRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	deviceRequest, err := h.oauth2.NewDeviceRequest(ctx, c.Request, session.NewSession(""))
+	if err != nil {
+		h.logger.Error("error validating device authorization request",
+			zap.Error(err),
+			zap.String("method", "oauth2.NewDeviceRequest"),
+		)
+		h.oauth2.WriteDeviceError(ctx, c.Writer, err)
+		return
+	}
+
+	deviceResponse, err := h.oauth2.NewDeviceResponse(ctx, deviceRequest)
+	if err != nil {
+		h.logger.Error("error populating device authorization response",
+			zap.Error(err),
+			zap.String("method", "oauth2.NewDeviceResponse"),
+		)
+		h.oauth2.WriteDeviceError(ctx, c.Writer, err)
+		return
+	}
+
+	h.oauth2.WriteDeviceResponse(ctx, c.Writer, deviceResponse)
+}
+
+// HandleDeviceVerifyRequest receives the user code from the device page and runs the same login and consent
+// round-trip as the authorization endpoint. The outcome is recorded on the user code instead of being sent to a
+// redirect uri, the device picks it up with its next token request.
+func (h *OAuthHandler) HandleDeviceVerifyRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	userCode := c.Query("user_code")
+
+	deviceRequest, err := h.deviceUC.GetDeviceRequest(ctx, userCode)
+	if err != nil {
+		h.writeDeviceError(c, userCode, err)
+		return
+	}
+
+	cl, err := h.clientUC.GetClient(ctx, deviceRequest.Client.GetID())
+	if err != nil {
+		h.writeDeviceError(c, userCode, err)
+		return
+	}
+
+	ar := core.NewAuthorizeRequest()
+	ar.Request = deviceRequest.Request
+	ar.Client = cl
+	ar.Form = c.Request.URL.Query()
+	ar.MaxAge = -1
+	// the end-user must always see which device is being authorized, see RFC 8628 section 5.4
+	ar.Prompt = core.Arguments{"consent"}
+	if c.Query("prompt") == "login" {
+		ar.Prompt = append(ar.Prompt, "login")
+	}
+
+	f, err := h.handleAuthorizeRequest(ctx, c.Writer, c.Request, ar)
+	if stderr.Is(err, errors.ErrAbortOAuth2Request) {
+		return
+	} else if err != nil {
+		if isRequestDenied(err) {
+			if rejectErr := h.deviceUC.RejectUserCode(ctx, userCode); rejectErr != nil {
+				h.logger.Error("error rejecting user code",
+					zap.Error(rejectErr),
+					zap.String("method", "deviceUC.RejectUserCode"),
+				)
+			}
+			err = core.ErrAccessDenied
+		}
+
+		h.writeDeviceError(c, "", err)
+		return
+	}
+
+	if err = h.deviceUC.AcceptUserCode(ctx, userCode, deviceRequest, f); err != nil {
+		h.logger.Error("error accepting user code",
+			zap.Error(err),
+			zap.String("method", "deviceUC.AcceptUserCode"),
+		)
+		h.writeDeviceError(c, "", err)
+		return
+	}
+
+	redirectTo := h.cfg.GetDevicePageURL()
+	redirectTo.RawQuery = url.Values{"success": []string{"true"}}.Encode()
+	c.Redirect(http.StatusFound, redirectTo.String())
+}
+
+// writeDeviceError sends the end-user back to the device page, keeping the user code so it can be corrected.
+func (h *OAuthHandler) writeDeviceError(c *gin.Context, userCode string, err error) {
+	rfcErr := core.ErrorToRFC6749Error(err)
+
+	params := rfcErr.ToValues()
+	if rfcErr.HintField != "" {
+		params.Set("error_description", rfcErr.HintField)
+	}
+	if userCode != "" {
+		params.Set("user_code", userCode)
+	}
+
+	redirectTo := h.cfg.GetDevicePageURL()
+	redirectTo.RawQuery = params.Encode()
+	c.Redirect(http.StatusFound, redirectTo.String())
+}
+
+func isRequestDenied(err error) bool {
+	switch core.ErrorToRFC6749Error(err).ErrorField {
+	case core.ErrAccessDenied.ErrorField, flow.LoginRequestDeniedErrorName, flow.ConsentRequestDeniedErrorName:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/handler/oidc"
+	"github.com/tuanta7/hydros/internal/client"
+	"github.com/tuanta7/hydros/internal/jwk"
+	"go.uber.org/zap"
+)
+
+// DiscoveryDocument is the OpenID Provider Metadata as described in OpenID Connect Discovery 1.0 section 3 and
+// RFC 8414 section 2.
+type DiscoveryDocument struct {
+	Issuer                             string   `json:"issuer"`
+	AuthorizationEndpoint              string   `json:"authorization_endpoint"`
+	TokenEndpoint                      string   `json:"token_endpoint"`
+	IntrospectionEndpoint              string   `json:"introspection_endpoint"`
+	RevocationEndpoint                 string   `json:"revocation_endpoint"`
+	UserinfoEndpoint                   string   `json:"userinfo_endpoint"`
+	EndSessionEndpoint                 string   `json:"end_session_endpoint"`
+	DeviceAuthorizationEndpoint        string   `json:"device_authorization_endpoint"`
+	PushedAuthorizationRequestEndpoint string   `json:"pushed_authorization_request_endpoint"`
+	RequirePushedAuthorizationRequests bool     `json:"require_pushed_authorization_requests"`
+	RegistrationEndpoint               string   `json:"registration_endpoint,omitempty"`
+	JWKsURI                            string   `json:"jwks_uri"`
+	ScopesSupported                    []string `json:"scopes_supported"`
+	ResponseTypesSupported             []string `json:"response_types_supported"`
+	ResponseModesSupported             []string `json:"response_modes_supported"`
+	GrantTypesSupported                []string `json:"grant_types_supported"`
+	SubjectTypesSupported              []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported   []string `json:"id_token_signing_alg_values_supported"`
+	UserinfoSigningAlgValuesSupported  []string `json:"userinfo_signing_alg_values_supported"`
+	ClaimsSupported                    []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported  []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValues  []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	CodeChallengeMethodsSupported      []string `json:"code_challenge_methods_supported"`
+	DPoPSigningAlgValuesSupported      []string `json:"dpop_signing_alg_values_supported"`
+	RequestParameterSupported          bool     `json:"request_parameter_supported"`
+	RequestURIParameterSupported       bool     `json:"request_uri_parameter_supported"`
+	RequireRequestURIRegistration      bool     `json:"require_request_uri_registration"`
+	RequestObjectSigningAlgValues      []string `json:"request_object_signing_alg_values_supported"`
+	RequestObjectEncryptionAlgValues   []string `json:"request_object_encryption_alg_values_supported"`
+	RequestObjectEncryptionEncValues   []string `json:"request_object_encryption_enc_values_supported"`
+	ClaimsParameterSupported           bool     `json:"claims_parameter_supported"`
+	RevocationEndpointAuthMethods      []string `json:"revocation_endpoint_auth_methods_supported"`
+	IntrospectionEndpointAuthMethods   []string `json:"introspection_endpoint_auth_methods_supported"`
+	FrontChannelLogoutSupported        bool     `json:"frontchannel_logout_supported"`
+	FrontChannelLogoutSessionSupported bool     `json:"frontchannel_logout_session_supported"`
+	BackChannelLogoutSupported         bool     `json:"backchannel_logout_supported"`
+	BackChannelLogoutSessionSupported  bool     `json:"backchannel_logout_session_supported"`
+	TLSCertificateBoundAccessTokens    bool     `json:"tls_client_certificate_bound_access_tokens"`
+	AuthorizationSigningAlgValues      []string `json:"authorization_signing_alg_values_supported"`
+	AuthorizationEncryptionAlgValues   []string `json:"authorization_encryption_alg_values_supported"`
+	AuthorizationEncryptionEncValues   []string `json:"authorization_encryption_enc_values_supported"`
+	IDTokenEncryptionAlgValues         []string `json:"id_token_encryption_alg_values_supported"`
+	IDTokenEncryptionEncValues         []string `json:"id_token_encryption_enc_values_supported"`
+	UserinfoEncryptionAlgValues        []string `json:"userinfo_encryption_alg_values_supported"`
+	UserinfoEncryptionEncValues        []string `json:"userinfo_encryption_enc_values_supported"`
+}
+
+// HandleDiscoveryRequest serves the OpenID Connect Discovery document.
+func (h *OAuthHandler) HandleDiscoveryRequest(c *gin.Context) {
+	issuer := h.cfg.GetIDTokenIssuer()
+	endpoint := func(path string) string {
+		u, err := url.JoinPath(issuer, path)
+		if err != nil {
+			return issuer + path
+		}
+		return u
+	}
+
+	grantTypes := make([]string, 0)
+	for _, gt := range h.oauth2.GetSupportedGrantTypes() {
+		grantTypes = append(grantTypes, string(gt))
+	}
+
+	challengeMethods := []string{"S256"}
+	if h.cfg.IsEnablePKCEPlainChallengeMethod() {
+		challengeMethods = append(challengeMethods, "plain")
+	}
+
+	claims := []string{"sub"}
+	for _, scope := range []string{"profile", "email", "address", "phone"} {
+		claims = append(claims, oidc.ScopeClaims[scope]...)
+	}
+
+	authMethods := []string{
+		core.ClientAuthenticationMethodBasic,
+		core.ClientAuthenticationMethodPost,
+		core.ClientAuthenticationMethodJWT,
+		core.ClientAuthenticationMethodSecretJWT,
+		core.ClientAuthenticationMethodNone,
+	}
+	if h.cfg.IsTLSEnabled() {
+		authMethods = append(authMethods, core.ClientAuthenticationMethodTLS, core.ClientAuthenticationMethodSelfSignedTLS)
+	}
+	authSigningAlgs := []string{
+		"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA",
+		"HS256", "HS384", "HS512",
+	}
+
+	subjectTypes := []string{client.SubjectTypePublic}
+	if h.cfg.GetPairwiseSalt() != "" {
+		subjectTypes = append(subjectTypes, client.SubjectTypePairwise)
+	}
+
+	registrationEndpoint := ""
+	if h.cfg.IsClientRegistrationEnabled() {
+		registrationEndpoint = h.cfg.GetClientRegistrationURL()
+	}
+
+	c.JSON(http.StatusOK, &DiscoveryDocument{
+		Issuer:                             issuer,
+		AuthorizationEndpoint:              endpoint("/oauth/authorize"),
+		TokenEndpoint:                      h.cfg.GetTokenURL(),
+		IntrospectionEndpoint:              endpoint("/oauth/introspect"),
+		RevocationEndpoint:                 endpoint("/oauth/revoke"),
+		UserinfoEndpoint:                   endpoint("/userinfo"),
+		EndSessionEndpoint:                 endpoint("/oauth/logout"),
+		DeviceAuthorizationEndpoint:        endpoint("/oauth/device/auth"),
+		PushedAuthorizationRequestEndpoint: endpoint("/oauth/par"),
+		RequirePushedAuthorizationRequests: h.cfg.IsPushedAuthorizationRequired(),
+		RegistrationEndpoint:               registrationEndpoint,
+		JWKsURI:                            endpoint("/.well-known/jwks.json"),
+		ScopesSupported:                    h.cfg.GetSupportedScopes(),
+		ResponseTypesSupported:             []string{"code"},
+		ResponseModesSupported: []string{
+			string(core.ResponseModeQuery),
+			string(core.ResponseModeFragment),
+			string(core.ResponseModeFormPost),
+			string(core.ResponseModeJWT),
+			string(core.ResponseModeQueryJWT),
+			string(core.ResponseModeFragmentJWT),
+			string(core.ResponseModeFormPostJWT),
+		},
+		GrantTypesSupported:                grantTypes,
+		SubjectTypesSupported:              subjectTypes,
+		IDTokenSigningAlgValuesSupported:   []string{h.cfg.GetIDTokenAlgorithm()},
+		UserinfoSigningAlgValuesSupported:  []string{"none", h.cfg.GetIDTokenAlgorithm()},
+		ClaimsSupported:                    claims,
+		TokenEndpointAuthMethodsSupported:  authMethods,
+		TokenEndpointAuthSigningAlgValues:  authSigningAlgs,
+		CodeChallengeMethodsSupported:      challengeMethods,
+		DPoPSigningAlgValuesSupported:      core.DPoPSigningAlgorithms,
+		RequestParameterSupported:          true,
+		RequestURIParameterSupported:       true,
+		RequireRequestURIRegistration:      true,
+		RequestObjectSigningAlgValues:      core.RequestObjectSigningAlgorithms,
+		RequestObjectEncryptionAlgValues:   core.RequestObjectEncryptionAlgorithms,
+		RequestObjectEncryptionEncValues:   core.RequestObjectEncryptionEncodings,
+		RevocationEndpointAuthMethods:      authMethods,
+		IntrospectionEndpointAuthMethods:   authMethods,
+		FrontChannelLogoutSupported:        true,
+		FrontChannelLogoutSessionSupported: true,
+		BackChannelLogoutSupported:         true,
+		BackChannelLogoutSessionSupported:  true,
+		TLSCertificateBoundAccessTokens:    h.cfg.IsTLSEnabled(),
+		AuthorizationSigningAlgValues:      []string{h.cfg.GetIDTokenAlgorithm()},
+		AuthorizationEncryptionAlgValues:   core.ResponseEncryptionAlgorithms,
+		AuthorizationEncryptionEncValues:   core.ResponseEncryptionEncodings,
+		IDTokenEncryptionAlgValues:         core.ResponseEncryptionAlgorithms,
+		IDTokenEncryptionEncValues:         core.ResponseEncryptionEncodings,
+		UserinfoEncryptionAlgValues:        core.ResponseEncryptionAlgorithms,
+		UserinfoEncryptionEncValues:        core.ResponseEncryptionEncodings,
+	})
+}
+
+// HandleJWKsRequest publishes the public keys that can be used to verify ID tokens and JWT access tokens.
+func (h *OAuthHandler) HandleJWKsRequest(c *gin.Context) {
+	jwks, err := h.jwkUC.GetPublicJWKs(c.Request.Context(), jwk.IDTokenSet, jwk.AccessTokenSet)
+	if err != nil {
+		h.logger.Error("error while listing public keys",
+			zap.Error(err),
+			zap.String("method", "jwkUC.GetPublicJWKs"),
+		)
+		c.JSON(http.StatusInternalServerError, core.ErrServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
@@ -214,6 +214,10 @@ func (h *FormHandler) ConsentPage(c *gin.Context) {
 		GrantedScope:    f.GrantedScope,
 		GrantedAudience: f.GrantedAudience,
 		Remember:        f.ConsentRemember,
+		Session: &flow.ConsentSession{
+			AccessToken: f.SessionAccessToken,
+			IDToken:     f.SessionIDToken,
+		},
 	})
 }
 
@@ -357,3 +361,72 @@ func (h *FormHandler) rejectConsent(c *gin.Context, deniedErr *flow.RequestDenie
 
 	c.Redirect(http.StatusSeeOther, redirectTo)
 }
+
+func (h *FormHandler) LogoutPage(c *gin.Context) {
+	challenge := c.Query("logout_challenge")
+	if challenge == "" {
+		// this page is also the default destination after logging out
+		c.HTML(http.StatusOK, "logout.html", gin.H{
+			"LoggedOut": true,
+		})
+		return
+	}
+
+	lr, err := h.flowUC.GetLogoutRequest(c.Request.Context(), challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "logout.html", gin.H{
+		"LogoutChallenge": challenge,
+		"Subject":         lr.Subject,
+	})
+}
+
+// DevicePage is the verification_uri of the device authorization grant, where the end-user types the user code shown
+// on the device.
+func (h *FormHandler) DevicePage(c *gin.Context) {
+	c.HTML(http.StatusOK, "device.html", gin.H{
+		"UserCode":         c.Query("user_code"),
+		"Success":          c.Query("success") == "true",
+		"Denied":           c.Query("error") == core.ErrAccessDenied.ErrorField,
+		"Error":            c.Query("error"),
+		"ErrorDescription": c.Query("error_description"),
+	})
+}
+
+func (h *FormHandler) Logout(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	challenge := c.PostForm("logout_challenge")
+	if challenge == "" {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithHint("'logout_challenge' is not defined but should have been."))
+		return
+	}
+
+	lr, err := h.flowUC.GetLogoutRequest(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err)
+		return
+	}
+
+	if err = lr.HandleLogoutRequest(c.PostForm("action") != "deny"); err != nil {
+		c.JSON(http.StatusBadRequest, core.ErrInvalidRequest.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	verifier, err := h.flowUC.EncodeLogoutRequest(ctx, lr, flow.AsLogoutVerifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	redirectTo, err := urlx.AppendQueryString(lr.RequestURL, url.Values{"logout_verifier": []string{verifier}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, core.ErrServerError.WithWrap(err))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, redirectTo)
+}
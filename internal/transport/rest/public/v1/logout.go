@@ -1,22 +1,423 @@
 package v1
 
 import (
+	"context"
 	stderr "errors"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/client"
 	"github.com/tuanta7/hydros/internal/errors"
+	"github.com/tuanta7/hydros/internal/flow"
+	"github.com/tuanta7/hydros/internal/session"
 	"github.com/tuanta7/hydros/pkg/helper/mapx"
+	"github.com/tuanta7/hydros/pkg/helper/urlx"
+	"go.uber.org/zap"
 )
 
-func (h *OAuthHandler) revokeLoginSession(w http.ResponseWriter, r *http.Request) error {
+const (
+	backChannelLogoutEvent   = "http://schemas.openid.net/event/backchannel-logout"
+	backChannelLogoutTimeout = 10 * time.Second
+)
+
+var backChannelLogoutClient = &http.Client{Timeout: backChannelLogoutTimeout}
+
+// HandleLogoutRequest implements OpenID Connect RP-Initiated Logout 1.0. The first call asks the end-user to confirm
+// the logout through the logout challenge, the second call comes back with the logout verifier, ends the login session
+// and notifies every client that took part in it through front-channel and back-channel logout.
+func (h *OAuthHandler) HandleLogoutRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := c.Request.ParseForm(); err != nil {
+		h.writeLogoutError(c, core.ErrInvalidRequest.WithHint("Unable to parse the logout request.").WithWrap(err))
+		return
+	}
+
+	if verifier := c.Request.Form.Get("logout_verifier"); verifier != "" {
+		h.completeLogout(c, verifier)
+		return
+	}
+
+	lr, err := h.newLogoutRequest(ctx, c.Request)
+	if err != nil {
+		h.writeLogoutError(c, err)
+		return
+	}
+
+	if lr.LoginSessionID == "" && lr.Subject == "" {
+		// there is no session to end, the end-user is sent back right away
+		c.Redirect(http.StatusFound, h.postLogoutRedirectURL(lr))
+		return
+	}
+
+	csrf := x.RandomUUID()
+	lr.CSRF = csrf
+
+	err = session.CreateCSRFSession(c.Writer, c.Request, h.cfg, h.store, session.LogoutCSRFCookieKey, csrf, h.cfg.GetConsentRequestMaxAge())
+	if err != nil {
+		h.writeLogoutError(c, err)
+		return
+	}
+
+	challenge, err := h.flowUC.EncodeLogoutRequest(ctx, lr, flow.AsLogoutChallenge)
+	if err != nil {
+		h.writeLogoutError(c, err)
+		return
+	}
+
+	redirectTo := h.cfg.GetLogoutPageURL()
+	params := url.Values{}
+	params.Set("logout_challenge", challenge)
+	redirectTo.RawQuery = params.Encode()
+
+	c.Redirect(http.StatusFound, redirectTo.String())
+}
+
+func (h *OAuthHandler) newLogoutRequest(ctx context.Context, r *http.Request) (*flow.LogoutRequest, error) {
+	lr := &flow.LogoutRequest{
+		ID:                    x.RandomUUID(),
+		ClientID:              r.Form.Get("client_id"),
+		PostLogoutRedirectURI: r.Form.Get("post_logout_redirect_uri"),
+		State:                 r.Form.Get("state"),
+		RequestURL:            r.URL.String(), // TODO: get proper logout request url when behind a reverse proxy
+		RequestedAt:           x.NowUTC().Truncate(time.Second),
+		FlowState:             flow.StateLogoutInitialized,
+	}
+
+	var hint *jwt.IDTokenClaims
+	var hintClient *client.Client
+	if idTokenHint := r.Form.Get("id_token_hint"); idTokenHint != "" {
+		claims, err := h.decodeIDTokenHint(ctx, idTokenHint, lr.ClientID)
+		if err != nil {
+			return nil, err
+		}
+
+		hint = claims
+		lr.RPInitiated = true
+		if lr.ClientID == "" && len(claims.Audience) > 0 {
+			lr.ClientID = claims.Audience[0]
+		}
+
+		if hintClient, err = h.getClient(ctx, lr.ClientID); err != nil {
+			return nil, err
+		}
+	}
+
+	if lr.PostLogoutRedirectURI != "" {
+		if err := h.validatePostLogoutRedirectURI(ctx, lr); err != nil {
+			return nil, err
+		}
+	}
+
+	loginSession, err := h.checkSession(ctx, r)
+	if stderr.Is(err, errors.ErrNoAuthenticationSessionFound) {
+		// without a session cookie we can only rely on the ID token hint, e.g. when the login was not remembered
+		if hint != nil {
+			lr.LoginSessionID = hint.SessionID
+			// the pairwise subject of the hint can not be mapped back to the end-user, the session ID is enough
+			if hintClient.GetSubjectType() != client.SubjectTypePairwise {
+				lr.Subject = hint.Subject
+			}
+		}
+		return lr, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if hint != nil && hint.Subject != h.clientUC.SubjectIdentifier(hintClient, loginSession.Subject) {
+		return nil, core.ErrInvalidRequest.WithHint("The 'id_token_hint' subject does not match the subject of the current session.")
+	}
+
+	lr.Subject = loginSession.Subject
+	lr.LoginSessionID = loginSession.ID
+	return lr, nil
+}
+
+// decodeIDTokenHint verifies an ID token that was issued by us. Expired tokens are accepted because the relying party
+// usually holds on to the ID token for longer than its lifetime.
+func (h *OAuthHandler) decodeIDTokenHint(ctx context.Context, token, clientID string) (*jwt.IDTokenClaims, error) {
+	claims := &jwt.IDTokenClaims{}
+	err := h.idTokenSigner.Decode(ctx, token, claims, gojwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, core.ErrInvalidRequest.WithHint("The 'id_token_hint' could not be verified.").WithWrap(err).WithDebug("%s", err)
+	}
+
+	if claims.Issuer != h.cfg.GetIDTokenIssuer() {
+		return nil, core.ErrInvalidRequest.WithHint("The 'id_token_hint' was not issued by this server.")
+	}
+
+	if clientID != "" && !slices.Contains(claims.Audience, clientID) {
+		return nil, core.ErrInvalidRequest.WithHint("The 'client_id' does not match the audience of the 'id_token_hint'.")
+	}
+
+	return claims, nil
+}
+
+func (h *OAuthHandler) validatePostLogoutRedirectURI(ctx context.Context, lr *flow.LogoutRequest) error {
+	if lr.ClientID == "" {
+		return core.ErrInvalidRequest.WithHint("Parameter 'post_logout_redirect_uri' requires either 'id_token_hint' or 'client_id'.")
+	}
+
+	cl, err := h.getClient(ctx, lr.ClientID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(cl.GetPostLogoutRedirectURIs(), lr.PostLogoutRedirectURI) {
+		return core.ErrInvalidRequest.WithHint("Parameter 'post_logout_redirect_uri' is not registered for the OAuth 2.0 Client.")
+	}
+
+	return nil
+}
+
+func (h *OAuthHandler) completeLogout(c *gin.Context, verifier string) {
+	ctx := c.Request.Context()
+
+	lr, err := h.flowUC.DecodeLogoutRequest(ctx, verifier, flow.AsLogoutVerifier)
+	if err != nil {
+		h.writeLogoutError(c, core.ErrInvalidRequest.WithHint("The logout verifier is invalid.").WithWrap(err))
+		return
+	}
+
+	if lr.RequestedAt.Add(h.cfg.GetConsentRequestMaxAge()).Before(x.NowUTC()) {
+		h.writeLogoutError(c, core.ErrRequestUnauthorized.WithHint("The logout request has expired, please try again."))
+		return
+	}
+
+	if err = session.ValidateCSRFSession(c.Request, h.store, session.LogoutCSRFCookieKey, lr.CSRF); err != nil {
+		h.writeLogoutError(c, err)
+		return
+	}
+
+	if !lr.IsAccepted() {
+		// the end-user decided to stay logged in
+		c.Redirect(http.StatusFound, h.postLogoutRedirectURL(lr))
+		return
+	}
+
+	// the clients must be collected before the login session is removed because the flows lose the reference to it
+	clients := h.listLoggedInClients(ctx, lr.LoginSessionID)
+
+	if err = h.revokeLoginSession(c.Writer, c.Request, lr.LoginSessionID); err != nil {
+		h.writeLogoutError(c, err)
+		return
+	}
+
+	h.sendBackChannelLogout(lr, clients)
+
+	frontChannelURLs := h.frontChannelLogoutURLs(lr, clients)
+	if len(frontChannelURLs) == 0 {
+		c.Redirect(http.StatusFound, h.postLogoutRedirectURL(lr))
+		return
+	}
+
+	c.HTML(http.StatusOK, "logout_frontchannel.html", gin.H{
+		"FrontChannelLogoutURLs": frontChannelURLs,
+		"RedirectTo":             h.postLogoutRedirectURL(lr),
+	})
+}
+
+func (h *OAuthHandler) listLoggedInClients(ctx context.Context, loginSessionID string) []*client.Client {
+	if loginSessionID == "" {
+		return nil
+	}
+
+	ids, err := h.flowUC.ListLoggedInClientIDs(ctx, loginSessionID)
+	if err != nil {
+		h.logger.Error("unable to list the clients of the login session",
+			zap.Error(err),
+			zap.String("method", "flowUC.ListLoggedInClientIDs"),
+		)
+		return nil
+	}
+
+	clients := make([]*client.Client, 0, len(ids))
+	for _, id := range ids {
+		cl, err := h.getClient(ctx, id)
+		if err != nil {
+			h.logger.Error("unable to get the client of the login session",
+				zap.Error(err),
+				zap.String("client_id", id),
+				zap.String("method", "clientUC.GetClient"),
+			)
+			continue
+		}
+
+		clients = append(clients, cl)
+	}
+
+	return clients
+}
+
+func (h *OAuthHandler) getClient(ctx context.Context, id string) (*client.Client, error) {
+	c, err := h.clientUC.GetClient(ctx, id)
+	if err != nil {
+		return nil, core.ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.").WithWrap(err)
+	}
+
+	cl, ok := c.(*client.Client)
+	if !ok {
+		return nil, core.ErrServerError.WithDebug("Expected the client to be of type *client.Client, but got %T.", c)
+	}
+
+	return cl, nil
+}
+
+// sendBackChannelLogout posts a logout token to every client with a back-channel logout URI, see OpenID Connect
+// Back-Channel Logout 1.0 section 2.5. The requests run in the background so a slow client can not block the end-user.
+func (h *OAuthHandler) sendBackChannelLogout(lr *flow.LogoutRequest, clients []*client.Client) {
+	for _, cl := range clients {
+		if cl.GetBackChannelLogoutURI() == "" {
+			continue
+		}
+
+		if cl.IsBackChannelLogoutSessionRequired() && lr.LoginSessionID == "" {
+			continue
+		}
+
+		go func(cl *client.Client) {
+			ctx, cancel := context.WithTimeout(context.Background(), backChannelLogoutTimeout)
+			defer cancel()
+
+			if err := h.postLogoutToken(ctx, lr, cl); err != nil {
+				h.logger.Error("back-channel logout failed",
+					zap.Error(err),
+					zap.String("client_id", cl.GetID()),
+					zap.String("method", "postLogoutToken"),
+				)
+			}
+		}(cl)
+	}
+}
+
+func (h *OAuthHandler) postLogoutToken(ctx context.Context, lr *flow.LogoutRequest, cl *client.Client) error {
+	now := x.NowUTC()
+	claims := gojwt.MapClaims{
+		"iss":    h.cfg.GetIDTokenIssuer(),
+		"aud":    []string{cl.GetID()},
+		"iat":    now.Unix(),
+		"exp":    now.Add(2 * time.Minute).Unix(),
+		"jti":    x.RandomUUID(),
+		"events": map[string]any{backChannelLogoutEvent: map[string]any{}},
+	}
+
+	if lr.Subject != "" {
+		claims["sub"] = h.clientUC.SubjectIdentifier(cl, lr.Subject)
+	}
+
+	if lr.LoginSessionID != "" {
+		claims["sid"] = lr.LoginSessionID
+	}
+
+	logoutToken, _, err := h.idTokenSigner.Generate(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{"logout_token": []string{logoutToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cl.GetBackChannelLogoutURI(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := backChannelLogoutClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return stderr.New("back-channel logout endpoint responded with status " + res.Status)
+	}
+
+	return nil
+}
+
+// frontChannelLogoutURLs returns the URLs that are rendered as iframes, see OpenID Connect Front-Channel Logout 1.0
+// section 2.
+func (h *OAuthHandler) frontChannelLogoutURLs(lr *flow.LogoutRequest, clients []*client.Client) []string {
+	urls := make([]string, 0)
+	for _, cl := range clients {
+		if cl.GetFrontChannelLogoutURI() == "" {
+			continue
+		}
+
+		if !cl.IsFrontChannelLogoutSessionRequired() {
+			urls = append(urls, cl.GetFrontChannelLogoutURI())
+			continue
+		}
+
+		u, err := urlx.AppendQueryString(cl.GetFrontChannelLogoutURI(), url.Values{
+			"iss": []string{h.cfg.GetIDTokenIssuer()},
+			"sid": []string{lr.LoginSessionID},
+		})
+		if err != nil {
+			h.logger.Error("invalid front-channel logout uri",
+				zap.Error(err),
+				zap.String("client_id", cl.GetID()),
+			)
+			continue
+		}
+
+		urls = append(urls, u)
+	}
+
+	return urls
+}
+
+func (h *OAuthHandler) postLogoutRedirectURL(lr *flow.LogoutRequest) string {
+	if lr.PostLogoutRedirectURI == "" {
+		return h.cfg.GetPostLogoutURL().String()
+	}
+
+	if lr.State == "" {
+		return lr.PostLogoutRedirectURI
+	}
+
+	redirectTo, err := urlx.AppendQueryString(lr.PostLogoutRedirectURI, url.Values{"state": []string{lr.State}})
+	if err != nil {
+		return h.cfg.GetPostLogoutURL().String()
+	}
+
+	return redirectTo
+}
+
+func (h *OAuthHandler) writeLogoutError(c *gin.Context, err error) {
+	h.logger.Error("error while processing logout request", zap.Error(err))
+
+	rfcErr := core.ErrorToRFC6749Error(err)
+	c.HTML(rfcErr.CodeField, "errors.html", gin.H{
+		"Error":       rfcErr.ErrorField,
+		"Description": rfcErr.DescriptionField,
+		"Debug":       rfcErr.DebugField,
+		"Hint":        rfcErr.HintField,
+	})
+}
+
+func (h *OAuthHandler) revokeLoginSession(w http.ResponseWriter, r *http.Request, loginSessionID string) error {
 	sid, err := h.revokeAuthenticationCookie(w, r)
 	if err != nil {
 		return err
-	} else if sid == "" {
+	}
+
+	if loginSessionID == "" {
+		loginSessionID = sid
+	}
+
+	if loginSessionID == "" {
 		return nil
 	}
 
-	_, err = h.sessionUC.DeleteLoginSession(r.Context(), sid)
+	_, err = h.sessionUC.DeleteLoginSession(r.Context(), loginSessionID)
 	if stderr.Is(err, errors.ErrNotFound) {
 		return nil
 	}
@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/client"
+	"go.uber.org/zap"
+)
+
+// HandleRegisterRequest registers a new client as described in RFC 7591 section 3. When initial access tokens are
+// configured, one of them must be sent as a bearer token. Without one, the client can not register the
+// 'client_credentials' or token exchange grant types.
+func (h *OAuthHandler) HandleRegisterRequest(c *gin.Context) {
+	if !h.cfg.IsClientRegistrationEnabled() {
+		c.JSON(http.StatusNotFound, core.ErrNotFound.WithHint("Dynamic client registration is disabled."))
+		return
+	}
+
+	trusted := h.cfg.IsInitialAccessTokenValid(bearerToken(c.Request))
+	if h.cfg.IsInitialAccessTokenRequired() && !trusted {
+		h.writeRegistrationError(c, core.ErrInvalidToken.WithHint("A valid initial access token is required to register clients."))
+		return
+	}
+
+	var md client.Metadata
+	if err := c.ShouldBindJSON(&md); err != nil {
+		h.writeRegistrationError(c, core.ErrInvalidClientMetadata.WithHint("Unable to parse the client metadata.").WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	res, err := h.clientUC.RegisterClient(c.Request.Context(), &md, trusted)
+	if err != nil {
+		h.logger.Error("error while registering client",
+			zap.Error(err),
+			zap.String("method", "clientUC.RegisterClient"),
+		)
+		h.writeRegistrationError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusCreated, res)
+}
+
+// HandleGetRegistrationRequest returns the registered client metadata as described in RFC 7592 section 2.1.
+func (h *OAuthHandler) HandleGetRegistrationRequest(c *gin.Context) {
+	cl, ok := h.authenticateRegistration(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, h.clientUC.GetRegisteredClient(cl))
+}
+
+// HandleUpdateRegistrationRequest replaces the registered client metadata as described in RFC 7592 section 2.2.
+func (h *OAuthHandler) HandleUpdateRegistrationRequest(c *gin.Context) {
+	cl, ok := h.authenticateRegistration(c)
+	if !ok {
+		return
+	}
+
+	var md struct {
+		client.Metadata
+		ClientID string `json:"client_id"`
+	}
+	if err := c.ShouldBindJSON(&md); err != nil {
+		h.writeRegistrationError(c, core.ErrInvalidClientMetadata.WithHint("Unable to parse the client metadata.").WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	if md.ClientID != cl.GetID() {
+		h.writeRegistrationError(c, core.ErrInvalidRequest.WithHint("The 'client_id' in the request body must match the client being updated."))
+		return
+	}
+
+	res, err := h.clientUC.UpdateRegisteredClient(c.Request.Context(), cl, &md.Metadata)
+	if err != nil {
+		h.logger.Error("error while updating registered client",
+			zap.Error(err),
+			zap.String("method", "clientUC.UpdateRegisteredClient"),
+		)
+		h.writeRegistrationError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, res)
+}
+
+// HandleDeleteRegistrationRequest deregisters the client as described in RFC 7592 section 2.3.
+func (h *OAuthHandler) HandleDeleteRegistrationRequest(c *gin.Context) {
+	cl, ok := h.authenticateRegistration(c)
+	if !ok {
+		return
+	}
+
+	if err := h.clientUC.DeleteRegisteredClient(c.Request.Context(), cl); err != nil {
+		h.writeRegistrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authenticateRegistration loads the client of the client configuration endpoint with its registration access token,
+// the error response is written when it fails.
+func (h *OAuthHandler) authenticateRegistration(c *gin.Context) (*client.Client, bool) {
+	if !h.cfg.IsClientRegistrationEnabled() {
+		c.JSON(http.StatusNotFound, core.ErrNotFound.WithHint("Dynamic client registration is disabled."))
+		return nil, false
+	}
+
+	cl, err := h.clientUC.AuthenticateRegistration(c.Request.Context(), c.Param("client_id"), bearerToken(c.Request))
+	if err != nil {
+		h.logger.Error("error while authenticating registration access token",
+			zap.Error(err),
+			zap.String("method", "clientUC.AuthenticateRegistration"),
+		)
+		h.writeRegistrationError(c, err)
+		return nil, false
+	}
+
+	return cl, true
+}
+
+// writeRegistrationError writes the error response of RFC 7591 section 3.2.2, token errors are reported with the
+// WWW-Authenticate header as described in RFC 6750 section 3.
+func (h *OAuthHandler) writeRegistrationError(c *gin.Context, err error) {
+	rfcErr := core.ErrorToRFC6749Error(err)
+	if rfcErr.ErrorField == core.ErrInvalidToken.ErrorField {
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s",error_description="%s"`,
+			rfcErr.ErrorField, x.EscapeJSONString(rfcErr.DescriptionField)))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(rfcErr.CodeField, rfcErr)
+}
+
+// bearerToken only reads the Authorization header, as the request bodies of the registration endpoints are JSON.
+func bearerToken(r *http.Request) string {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+
+	return parts[1]
+}
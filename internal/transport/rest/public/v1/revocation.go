@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (h *OAuthHandler) HandleRevocationRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	err := h.oauth2.NewRevocationRequest(ctx, c.Request)
+	if err != nil {
+		h.logger.Error("error while revoking token",
+			zap.Error(err),
+			zap.String("method", "oauth2.NewRevocationRequest"),
+		)
+	}
+
+	h.oauth2.WriteRevocationResponse(ctx, c.Writer, err)
+}
@@ -50,8 +50,6 @@ func (h *OAuthHandler) HandleTokenRequest(c *gin.Context) {
 	s.IDTokenSession.Claims.Issuer = h.cfg.GetAccessTokenIssuer()
 	s.IDTokenSession.Claims.IssuedAt = jwt.NewNumericDate(x.NowUTC())
 
-	// TODO: Implement rfc8693 token exchange
-
 	tokenResponse, err := h.oauth2.NewTokenResponse(ctx, tokenRequest)
 	if err != nil {
 		h.logger.Error("error populating token response",
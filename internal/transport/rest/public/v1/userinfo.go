@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/handler/oidc"
+	"github.com/tuanta7/hydros/core/strategy"
+	"github.com/tuanta7/hydros/core/x"
+	"github.com/tuanta7/hydros/internal/session"
+	"go.uber.org/zap"
+)
+
+// HandleUserinfoRequest returns the claims about the authenticated end-user as described in OpenID Connect Core 1.0
+// section 5.3. The access token must have been granted the openid scope, the other standard claims are released
+// according to the profile, email, address and phone scopes.
+func (h *OAuthHandler) HandleUserinfoRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+	s := session.NewSession("")
+
+	accessToken := x.AccessTokenFromRequest(c.Request)
+	tr, err := h.oauth2.IntrospectBearerToken(ctx, accessToken, s, "openid")
+	if err != nil {
+		h.logger.Error("error while introspecting bearer token",
+			zap.Error(err),
+			zap.String("method", "oauth2.IntrospectBearerToken"),
+		)
+		h.writeUserinfoError(c, err)
+		return
+	}
+
+	userinfoURL, err := url.JoinPath(h.cfg.GetIDTokenIssuer(), "/userinfo")
+	if err != nil {
+		h.writeUserinfoError(c, core.ErrServerError.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	if err = h.oauth2.VerifyDPoPBinding(ctx, c.Request, userinfoURL, accessToken, tr); err != nil {
+		h.writeUserinfoError(c, err)
+		return
+	}
+
+	if err = h.oauth2.VerifyCertificateBinding(ctx, c.Request, tr); err != nil {
+		h.writeUserinfoError(c, err)
+		return
+	}
+
+	claims := oidc.UserinfoClaims(tr.GrantedScope, s.IDTokenClaims())
+
+	client, err := h.clientUC.GetClient(ctx, tr.Client.GetID())
+	if err != nil {
+		h.writeUserinfoError(c, core.ErrServerError.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var signingAlg, encryptionAlg, encryptionEnc string
+	if oidcClient, ok := client.(core.OpenIDConnectClient); ok {
+		signingAlg = oidcClient.GetUserinfoSignedResponseAlg()
+	}
+
+	if encryptionClient, ok := client.(core.UserinfoEncryptionClient); ok {
+		encryptionAlg = encryptionClient.GetUserinfoEncryptedResponseAlg()
+		encryptionEnc = encryptionClient.GetUserinfoEncryptedResponseEnc()
+	}
+
+	signed := signingAlg != "" && signingAlg != "none"
+	if !signed && encryptionAlg == "" {
+		c.JSON(http.StatusOK, claims)
+		return
+	}
+
+	mapClaims := gojwt.MapClaims(claims)
+	mapClaims["iss"] = h.cfg.GetIDTokenIssuer()
+	mapClaims["aud"] = client.GetID()
+	mapClaims["iat"] = x.NowUTC().Unix()
+
+	var response []byte
+	if signed {
+		token, err := signUserinfo(ctx, h.idTokenSigner, mapClaims, signingAlg)
+		if err != nil {
+			h.writeUserinfoError(c, err)
+			return
+		}
+		response = []byte(token)
+	} else if response, err = json.Marshal(mapClaims); err != nil {
+		h.writeUserinfoError(c, core.ErrServerError.WithWrap(err).WithDebug("%s", err))
+		return
+	}
+
+	// a signed response is signed first and then encrypted, see OpenID Connect Core 1.0 section 5.3.2
+	if encryptionAlg != "" {
+		jwe, err := core.EncryptForClient(ctx, h.cfg.GetJWKSFetcher(), client, response, signed, encryptionAlg, encryptionEnc)
+		if err != nil {
+			h.writeUserinfoError(c, core.ErrServerError.WithWrap(err).WithDebug("%s", err))
+			return
+		}
+		response = []byte(jwe)
+	}
+
+	c.Data(http.StatusOK, "application/jwt", response)
+}
+
+// signUserinfo signs the claims with the ID token key. The algorithm of the client is checked against the ID token
+// algorithm at registration, but the key may have been replaced with one of another algorithm since then, so the
+// response is refused rather than signed with an algorithm the client does not expect.
+func signUserinfo(ctx context.Context, signer strategy.JWTSigner, claims gojwt.MapClaims, alg string) (string, error) {
+	token, _, err := signer.Generate(ctx, claims)
+	if err != nil {
+		return "", core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	parsed, _, err := gojwt.NewParser().ParseUnverified(token, gojwt.MapClaims{})
+	if err != nil {
+		return "", core.ErrServerError.WithWrap(err).WithDebug("%s", err)
+	}
+
+	if signedAlg := parsed.Method.Alg(); signedAlg != alg {
+		return "", core.ErrServerError.
+			WithHint("The UserInfo response can not be signed with '%s' as the ID token key uses '%s'.", alg, signedAlg)
+	}
+
+	return token, nil
+}
+
+// writeUserinfoError reports token errors with the WWW-Authenticate header as described in RFC 6750 section 3.
+func (h *OAuthHandler) writeUserinfoError(c *gin.Context, err error) {
+	rfcErr := core.ErrorToRFC6749Error(err)
+	if rfcErr.CodeField == http.StatusInternalServerError {
+		c.JSON(http.StatusInternalServerError, rfcErr)
+		return
+	}
+
+	scheme, errorName, code := core.BearerToken, "invalid_token", http.StatusUnauthorized
+	switch rfcErr.ErrorField {
+	case core.ErrInvalidScope.ErrorField:
+		errorName, code = "insufficient_scope", http.StatusForbidden
+	case core.ErrInvalidDPoPProof.ErrorField:
+		scheme, errorName = core.DPoPTokenType, rfcErr.ErrorField
+	case core.ErrUseDPoPNonce.ErrorField:
+		scheme, errorName = core.DPoPTokenType, rfcErr.ErrorField
+		c.Header(core.DPoPNonceHeader, h.oauth2.NewDPoPNonce())
+	}
+
+	c.Header("WWW-Authenticate", fmt.Sprintf(`%s error="%s",error_description="%s"`,
+		scheme, errorName, x.EscapeJSONString(rfcErr.DescriptionField)))
+	c.JSON(code, gin.H{
+		"error":             errorName,
+		"error_description": rfcErr.DescriptionField,
+		"hint":              rfcErr.HintField,
+	})
+}
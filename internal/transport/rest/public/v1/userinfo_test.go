@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/go-jose/go-jose/v4"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/signer/jwt"
+)
+
+type testIssuerConfig struct{}
+
+func (testIssuerConfig) GetAccessTokenIssuer() string { return "https://auth.example.com" }
+
+func TestSignUserinfo(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := jwt.NewSigner(testIssuerConfig{}, func(ctx context.Context, kid ...string) (any, error) {
+		return &jose.JSONWebKey{Key: key, KeyID: "id-token", Algorithm: "ES256", Use: "sig"}, nil
+	})
+	require.NoError(t, err)
+
+	claims := gojwt.MapClaims{"sub": "alice", "aud": "client"}
+
+	token, err := signUserinfo(context.Background(), signer, claims, "ES256")
+	require.NoError(t, err)
+	assert.NoError(t, signer.Validate(context.Background(), token))
+
+	// the client registered RS256 before the ID token key was replaced with an ES256 key
+	_, err = signUserinfo(context.Background(), signer, claims, "RS256")
+	assert.ErrorIs(t, err, core.ErrServerError)
+}
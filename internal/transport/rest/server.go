@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 
@@ -17,13 +18,18 @@ type Server struct {
 	server        *http.Server
 	clientHandler *v1admin.ClientHandler
 	flowHandler   *v1admin.FlowHandler
+	keyHandler    *v1admin.KeyHandler
 	oauthHandler  *v1public.OAuthHandler
 	formHandler   *v1public.FormHandler
+
+	sessionHandler *v1admin.SessionHandler
 }
 
 func NewServer(cfg *config.Config,
 	clientHandler *v1admin.ClientHandler,
 	flowHandler *v1admin.FlowHandler,
+	keyHandler *v1admin.KeyHandler,
+	sessionHandler *v1admin.SessionHandler,
 	oauthHandler *v1public.OAuthHandler,
 	formHandler *v1public.FormHandler,
 ) *Server {
@@ -49,11 +55,25 @@ func NewServer(cfg *config.Config,
 		oauthHandler:  oauthHandler,
 		formHandler:   formHandler,
 		flowHandler:   flowHandler,
+		keyHandler:    keyHandler,
+
+		sessionHandler: sessionHandler,
 	}
 }
 
 func (s *Server) Run() error {
 	s.RegisterRoutes()
+
+	if s.cfg.IsTLSEnabled() {
+		// client certificates are only requested, they are verified when the client authenticates with them since
+		// self-signed certificates are accepted for self_signed_tls_client_auth
+		s.server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.RequestClientCert,
+		}
+		return s.server.ListenAndServeTLS(s.cfg.GetTLSCertFile(), s.cfg.GetTLSKeyFile())
+	}
+
 	return s.server.ListenAndServe()
 }
 
@@ -68,30 +88,58 @@ func (s *Server) Shutdown(ctx context.Context) error {
 func (s *Server) RegisterRoutes() {
 	// Authorization Service - OAuth APIs
 	s.router.GET("/oauth/authorize", s.oauthHandler.HandleAuthorizeRequest)
+	s.router.POST("/oauth/par", s.oauthHandler.HandlePushedAuthorizeRequest)
 	s.router.POST("/oauth/token", s.oauthHandler.HandleTokenRequest)
 	s.router.POST("/oauth/introspect", s.oauthHandler.HandleIntrospectionRequest)
-	s.router.POST("/oauth/revoke", nil)
-	s.router.GET("/oauth/logout", nil)
-	s.router.POST("/oauth/logout", nil)
+	s.router.POST("/oauth/revoke", s.oauthHandler.HandleRevocationRequest)
+	s.router.POST("/oauth/register", s.oauthHandler.HandleRegisterRequest)
+	s.router.GET("/oauth/register/:client_id", s.oauthHandler.HandleGetRegistrationRequest)
+	s.router.PUT("/oauth/register/:client_id", s.oauthHandler.HandleUpdateRegistrationRequest)
+	s.router.DELETE("/oauth/register/:client_id", s.oauthHandler.HandleDeleteRegistrationRequest)
+	s.router.POST("/oauth/device/auth", s.oauthHandler.HandleDeviceAuthRequest)
+	s.router.GET("/oauth/device/verify", s.oauthHandler.HandleDeviceVerifyRequest)
+	s.router.GET("/userinfo", s.oauthHandler.HandleUserinfoRequest)
+	s.router.POST("/userinfo", s.oauthHandler.HandleUserinfoRequest)
+	s.router.GET("/oauth/logout", s.oauthHandler.HandleLogoutRequest)
+	s.router.POST("/oauth/logout", s.oauthHandler.HandleLogoutRequest)
+	s.router.GET("/.well-known/openid-configuration", s.oauthHandler.HandleDiscoveryRequest)
+	s.router.GET("/.well-known/jwks.json", s.oauthHandler.HandleJWKsRequest)
 
 	// Default forms and submit endpoints
 	s.router.GET("/self-service/login", s.formHandler.LoginPage)
 	s.router.POST("/self-service/login", s.formHandler.Login)
 	s.router.GET("/self-service/consent", s.formHandler.ConsentPage)
 	s.router.POST("/self-service/consent", s.formHandler.Consent)
+	s.router.GET("/self-service/logout", s.formHandler.LogoutPage)
+	s.router.POST("/self-service/logout", s.formHandler.Logout)
+	s.router.GET("/self-service/device", s.formHandler.DevicePage)
 
 	// Authorization Service - Admin APIs
 	adminRouter := s.router.Group("/admin/api/v1")
 	adminRouter.GET("/clients", s.clientHandler.List)
 	adminRouter.POST("/clients", s.clientHandler.Create)
+	adminRouter.GET("/keys", s.keyHandler.ListSets)
+	adminRouter.GET("/keys/:set", s.keyHandler.List)
+	adminRouter.POST("/keys/:set", s.keyHandler.Generate)
+	adminRouter.PUT("/keys/:set/:kid/activate", s.keyHandler.Activate)
+	adminRouter.PUT("/keys/:set/:kid/retire", s.keyHandler.Retire)
+	adminRouter.PUT("/keys/:set/:kid/revoke", s.keyHandler.Revoke)
+	adminRouter.GET("/login/sessions", s.sessionHandler.ListLoginSessions)
+	adminRouter.DELETE("/login/sessions", s.sessionHandler.RevokeSubjectLoginSessions)
+	adminRouter.DELETE("/login/sessions/:id", s.sessionHandler.RevokeLoginSession)
 
 	// For external identity providers
 	adminRouter.GET("/login/flows", s.flowHandler.GetLoginFlow)
 	adminRouter.PUT("/login/accept", s.flowHandler.AcceptLogin)
 	adminRouter.PUT("/login/reject", s.flowHandler.RejectLogin)
-	adminRouter.GET("/consent/flows", nil)
-	adminRouter.PUT("/consent/accept", nil)
-	adminRouter.PUT("/consent/reject", nil)
+	adminRouter.GET("/consent/flows", s.flowHandler.GetConsentFlow)
+	adminRouter.PUT("/consent/accept", s.flowHandler.AcceptConsent)
+	adminRouter.PUT("/consent/reject", s.flowHandler.RejectConsent)
+	adminRouter.GET("/consent/sessions", s.flowHandler.ListConsentSessions)
+	adminRouter.DELETE("/consent/sessions", s.flowHandler.RevokeConsentSessions)
+	adminRouter.GET("/logout/flows", s.flowHandler.GetLogoutFlow)
+	adminRouter.PUT("/logout/accept", s.flowHandler.AcceptLogout)
+	adminRouter.PUT("/logout/reject", s.flowHandler.RejectLogout)
 
 	s.server.Handler = s.router
 }
@@ -7,14 +7,17 @@ import (
 
 	"github.com/tuanta7/hydros/cmd"
 	"github.com/tuanta7/hydros/core"
+	"github.com/tuanta7/hydros/core/handler/jarm"
 	"github.com/tuanta7/hydros/core/handler/oauth"
 	"github.com/tuanta7/hydros/core/handler/oidc"
+	"github.com/tuanta7/hydros/core/handler/par"
 	"github.com/tuanta7/hydros/core/handler/pkce"
 	"github.com/tuanta7/hydros/core/signer/hmac"
 	"github.com/tuanta7/hydros/core/signer/jwt"
 	"github.com/tuanta7/hydros/core/strategy"
 	"github.com/tuanta7/hydros/internal/client"
 	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/device"
 	"github.com/tuanta7/hydros/internal/flow"
 	"github.com/tuanta7/hydros/internal/jwk"
 	"github.com/tuanta7/hydros/internal/login"
@@ -48,20 +51,27 @@ func main() {
 	jwkUC := jwk.NewUseCase(cfg, aeadAES, jwkRepo, zl)
 
 	clientRepo := client.NewClientRepository(pgClient)
-	clientUC := client.NewUseCase(cfg, clientRepo, zl)
+	clientUC := client.NewUseCase(cfg, clientRepo, aeadAES, zl)
 
 	tokenRepo := token.NewRequestSessionRepo(pgClient)
 	tokenStorage := token.NewRequestSessionStorage(cfg, aeadAES, tokenRepo)
 
 	flowRepo := flow.NewFlowRepository(pgClient)
-	flowUC := flow.NewUseCase(cfg, flowRepo, aeadAES, zl)
+	flowUC := flow.NewUseCase(cfg, flowRepo, tokenStorage, aeadAES, zl)
 
 	loginSessionRepo := session.NewSessionRepository(pgClient)
-	loginSessionUC := session.NewUseCase(loginSessionRepo)
+	loginSessionUC := session.NewUseCase(loginSessionRepo, flowUC)
 
-	tokenStrategy, err := getTokenStrategy(cfg, jwkUC)
+	hmacSigner, err := hmac.NewSigner(cfg)
+	panicErr(err)
+
+	jwtStrategy, err := getJWTStrategy(cfg, hmacSigner, jwkUC)
 	panicErr(err)
 
+	tokenStrategy := getTokenStrategy(cfg, hmacSigner, jwtStrategy)
+	deviceStrategy := oauth.NewDeviceStrategy(cfg, hmacSigner)
+	deviceUC := device.NewUseCase(tokenStorage, deviceStrategy)
+
 	idTokenSigner, err := jwt.NewSigner(cfg, jwkUC.GetOrCreateJWKFn(jwk.IDTokenSet))
 	panicErr(err)
 
@@ -81,27 +91,47 @@ func main() {
 		Commands: []*cli.Command{
 			cmd.NewCreateClientsCommand(clientUC),
 			cmd.NewCleanCommand(),
+			cmd.NewKeysCommand(jwkUC),
+			cmd.NewSecretsCommand(cfg, tokenStorage),
+			cmd.NewConsentsCommand(flowUC),
+			cmd.NewSessionsCommand(loginSessionUC),
 		},
 		Action: func(ctx context.Context, command *cli.Command) error {
+			jwtIntrospectionHandler := oauth.NewJWTIntrospectionHandler(tokenStrategy)
+			tokenIntrospectionHandler := oauth.NewTokenIntrospectionHandler(cfg, tokenStrategy, tokenStorage)
+
 			oauthCore := core.NewOAuth2(cfg, clientUC,
 				oauth.NewAuthorizationCodeGrantHandler(cfg, tokenStrategy, tokenStorage),
+				oauth.NewRefreshTokenGrantHandler(cfg, tokenStrategy, tokenStorage),
 				oidc.NewOpenIDConnectAuthorizationCodeFlowHandler(cfg, idTokenStrategy, tokenStorage),
 				pkce.NewProofKeyForCodeExchangeHandler(cfg, tokenStrategy, tokenStorage),
 				oauth.NewClientCredentialsGrantHandler(cfg, tokenStrategy, tokenStorage),
-				oauth.NewJWTIntrospectionHandler(tokenStrategy),
-				oauth.NewTokenIntrospectionHandler(cfg, tokenStrategy, tokenStorage),
+				oauth.NewDeviceGrantHandler(cfg, tokenStrategy, deviceStrategy, tokenStorage),
+				oauth.NewTokenExchangeGrantHandler(cfg, tokenStrategy, getTokenExchangeJWTStrategy(cfg, jwtStrategy), tokenStorage,
+					jwtIntrospectionHandler,
+					tokenIntrospectionHandler,
+				),
+				jwtIntrospectionHandler,
+				tokenIntrospectionHandler,
+				oauth.NewTokenRevocationHandler(tokenStrategy, tokenStorage),
+				jarm.NewAuthorizeResponseHandler(cfg, idTokenSigner),
+				// registered last so the request_uri is only consumed when every other handler succeeded
+				par.NewPushedAuthorizeHandler(cfg, tokenStorage),
 			)
 
 			cookieStore := session.NewCookieStore(cfg)
 			clientHandler := restadminv1.NewClientHandler(clientUC)
 			flowHandler := restadminv1.NewFlowHandler(flowUC)
+			keyHandler := restadminv1.NewKeyHandler(jwkUC)
+			sessionHandler := restadminv1.NewSessionHandler(loginSessionUC)
 
 			defaultLoginStrategy := login.NewDefaultStrategy()
 			formHandler := restpublicv1.NewFormHandler(cfg, flowUC, defaultLoginStrategy)
-			oauthHandler := restpublicv1.NewOAuthHandler(cfg, cookieStore, oauthCore, jwkUC, loginSessionUC, flowUC, zl)
+			oauthHandler := restpublicv1.NewOAuthHandler(cfg, cookieStore, oauthCore, idTokenSigner, jwkUC, clientUC, loginSessionUC, flowUC, deviceUC, zl)
 
-			restServer := rest.NewServer(cfg, clientHandler, flowHandler, oauthHandler, formHandler)
-			return transport.RunServers(restServer)
+			restServer := rest.NewServer(cfg, clientHandler, flowHandler, keyHandler, sessionHandler, oauthHandler, formHandler)
+			keyRotator := jwk.NewRotator(cfg, jwkUC, zl)
+			return transport.RunServers(restServer, keyRotator)
 		},
 	}
 
@@ -110,23 +140,32 @@ func main() {
 	}
 }
 
-func getTokenStrategy(cfg *config.Config, jwkUC *jwk.UseCase) (strategy.TokenStrategy, error) {
-	hmacSigner, err := hmac.NewSigner(cfg)
+func getTokenStrategy(cfg *config.Config, hmacSigner strategy.OpaqueSigner, jwtStrategy *oauth.JWTStrategy) strategy.TokenStrategy {
+	if cfg.GetAccessTokenFormat() == config.AccessTokenFormatJWT {
+		return jwtStrategy
+	}
+
+	return oauth.NewHMACStrategy(cfg, hmacSigner)
+}
+
+// getJWTStrategy returns the strategy for JWT access tokens.
+func getJWTStrategy(cfg *config.Config, hmacSigner strategy.OpaqueSigner, jwkUC *jwk.UseCase) (*oauth.JWTStrategy, error) {
+	jwtSigner, err := jwt.NewSigner(cfg, jwkUC.GetOrCreateJWKFn(jwk.AccessTokenSet))
 	if err != nil {
 		return nil, err
 	}
 
-	if cfg.GetAccessTokenFormat() == config.AccessTokenFormatJWT {
-		getPrivateKeyFn := jwkUC.GetOrCreateJWKFn(jwk.AccessTokenSet)
-		jwtSigner, err := jwt.NewSigner(cfg, getPrivateKeyFn)
-		if err != nil {
-			return nil, err
-		}
+	return oauth.NewJWTStrategy(cfg, hmacSigner, jwtSigner), nil
+}
 
-		return oauth.NewJWTStrategy(cfg, hmacSigner, jwtSigner), nil
+// getTokenExchangeJWTStrategy returns the strategy for the JWTs requested through token exchange. They are only issued
+// when the access token format is JWT, opaque tokens are introspected and revoked by a signature a JWT does not have.
+func getTokenExchangeJWTStrategy(cfg *config.Config, jwtStrategy *oauth.JWTStrategy) strategy.AccessTokenStrategy {
+	if cfg.GetAccessTokenFormat() == config.AccessTokenFormatJWT {
+		return jwtStrategy
 	}
 
-	return oauth.NewHMACStrategy(cfg, hmacSigner), nil
+	return nil
 }
 
 func panicErr(err error) {
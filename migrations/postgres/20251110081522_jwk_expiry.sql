@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE jwk
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE jwk
+    DROP COLUMN IF EXISTS expires_at;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS userinfo_signed_response_alg VARCHAR(10) DEFAULT '' NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS userinfo_signed_response_alg;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS post_logout_redirect_uris            TEXT    DEFAULT '[]'  NOT NULL,
+    ADD COLUMN IF NOT EXISTS frontchannel_logout_uri              TEXT    DEFAULT ''    NOT NULL,
+    ADD COLUMN IF NOT EXISTS frontchannel_logout_session_required BOOLEAN DEFAULT false NOT NULL,
+    ADD COLUMN IF NOT EXISTS backchannel_logout_uri               TEXT    DEFAULT ''    NOT NULL,
+    ADD COLUMN IF NOT EXISTS backchannel_logout_session_required  BOOLEAN DEFAULT false NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS post_logout_redirect_uris,
+    DROP COLUMN IF EXISTS frontchannel_logout_uri,
+    DROP COLUMN IF EXISTS frontchannel_logout_session_required,
+    DROP COLUMN IF EXISTS backchannel_logout_uri,
+    DROP COLUMN IF EXISTS backchannel_logout_session_required;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS secret_ciphertext TEXT DEFAULT '' NOT NULL;
+
+CREATE TABLE IF NOT EXISTS client_assertion_jti
+(
+    jti        VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP    NOT NULL,
+    PRIMARY KEY (jti)
+);
+
+CREATE INDEX IF NOT EXISTS client_assertion_jti_expires_at_idx ON client_assertion_jti (expires_at);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS client_assertion_jti_expires_at_idx;
+DROP TABLE IF EXISTS client_assertion_jti;
+ALTER TABLE client
+    DROP COLUMN IF EXISTS secret_ciphertext;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS device_code
+(
+    signature           VARCHAR(255)                               NOT NULL,
+    request_id          VARCHAR(40)                                NOT NULL,
+    requested_at        TIMESTAMP    DEFAULT now()                 NOT NULL,
+    client_id           VARCHAR(255)                               NOT NULL,
+    scope               TEXT                                       NOT NULL,
+    granted_scope       TEXT                                       NOT NULL,
+    audience            TEXT         DEFAULT ''::TEXT,
+    granted_audience    TEXT         DEFAULT ''::TEXT,
+    form_data           TEXT                                       NOT NULL,
+    session_data        TEXT                                       NOT NULL,
+    subject             VARCHAR(255) DEFAULT ''::CHARACTER VARYING NOT NULL,
+    active              BOOLEAN      DEFAULT TRUE                  NOT NULL,
+    challenge           VARCHAR(40),
+    user_code_signature VARCHAR(255)                               NOT NULL,
+    user_code_state     SMALLINT     DEFAULT 0                     NOT NULL, -- 0: unused, 1: accepted, 2: rejected
+    last_polled_at      TIMESTAMP,
+    expires_at          TIMESTAMP                                  NOT NULL,
+    PRIMARY KEY (signature),
+    UNIQUE (user_code_signature)
+);
+
+CREATE INDEX IF NOT EXISTS device_code_client_id_idx ON device_code (client_id);
+CREATE INDEX IF NOT EXISTS device_code_request_id_idx ON device_code (request_id);
+CREATE INDEX IF NOT EXISTS device_code_expires_at_idx ON device_code (expires_at);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS device_code_client_id_idx;
+DROP INDEX IF EXISTS device_code_request_id_idx;
+DROP INDEX IF EXISTS device_code_expires_at_idx;
+DROP TABLE IF EXISTS device_code;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS require_pushed_authorization_requests BOOLEAN DEFAULT false NOT NULL;
+
+CREATE TABLE IF NOT EXISTS par
+(
+    request_uri  VARCHAR(255)            NOT NULL,
+    client_id    VARCHAR(255)            NOT NULL,
+    form_data    TEXT                    NOT NULL,
+    requested_at TIMESTAMP DEFAULT now() NOT NULL,
+    expires_at   TIMESTAMP               NOT NULL,
+    PRIMARY KEY (request_uri)
+);
+
+CREATE INDEX IF NOT EXISTS par_expires_at_idx ON par (expires_at);
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS par_expires_at_idx;
+DROP TABLE IF EXISTS par;
+ALTER TABLE client
+    DROP COLUMN IF EXISTS require_pushed_authorization_requests;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS registration_access_token VARCHAR(255) DEFAULT '' NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS registration_access_token;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
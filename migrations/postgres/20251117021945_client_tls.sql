@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS tls_client_auth_subject_dn                 TEXT    DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS tls_client_auth_san_dns                    TEXT    DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS tls_client_auth_san_uri                    TEXT    DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS tls_client_auth_san_ip                     TEXT    DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS tls_client_auth_san_email                  TEXT    DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS tls_client_certificate_bound_access_tokens BOOLEAN DEFAULT false NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS tls_client_auth_subject_dn,
+    DROP COLUMN IF EXISTS tls_client_auth_san_dns,
+    DROP COLUMN IF EXISTS tls_client_auth_san_uri,
+    DROP COLUMN IF EXISTS tls_client_auth_san_ip,
+    DROP COLUMN IF EXISTS tls_client_auth_san_email,
+    DROP COLUMN IF EXISTS tls_client_certificate_bound_access_tokens;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS subject_type          TEXT DEFAULT 'public' NOT NULL,
+    ADD COLUMN IF NOT EXISTS sector_identifier_uri TEXT DEFAULT ''       NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS subject_type,
+    DROP COLUMN IF EXISTS sector_identifier_uri;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
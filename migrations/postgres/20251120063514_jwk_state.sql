@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE jwk
+    ADD COLUMN IF NOT EXISTS state TEXT DEFAULT 'next' NOT NULL;
+
+UPDATE jwk
+SET state = CASE WHEN active THEN 'active' ELSE 'retired' END;
+
+ALTER TABLE jwk
+    DROP COLUMN IF EXISTS active;
+
+CREATE UNIQUE INDEX IF NOT EXISTS jwk_active_sid_idx ON jwk (sid) WHERE state = 'active';
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP INDEX IF EXISTS jwk_active_sid_idx;
+
+ALTER TABLE jwk
+    ADD COLUMN IF NOT EXISTS active BOOLEAN DEFAULT FALSE NOT NULL;
+
+UPDATE jwk
+SET active = state = 'active';
+
+ALTER TABLE jwk
+    DROP COLUMN IF EXISTS state;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
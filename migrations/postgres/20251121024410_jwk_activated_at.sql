@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE jwk
+    ADD COLUMN IF NOT EXISTS activated_at TIMESTAMP NULL;
+
+UPDATE jwk
+SET activated_at = created_at
+WHERE state = 'active';
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE jwk
+    DROP COLUMN IF EXISTS activated_at;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE client
+    ADD COLUMN IF NOT EXISTS id_token_encrypted_response_alg TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS id_token_encrypted_response_enc TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS userinfo_encrypted_response_alg TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS userinfo_encrypted_response_enc TEXT DEFAULT '' NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE client
+    DROP COLUMN IF EXISTS id_token_encrypted_response_alg,
+    DROP COLUMN IF EXISTS id_token_encrypted_response_enc,
+    DROP COLUMN IF EXISTS userinfo_encrypted_response_alg,
+    DROP COLUMN IF EXISTS userinfo_encrypted_response_enc;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
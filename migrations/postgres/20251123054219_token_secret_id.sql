@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE access_token ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE refresh_token ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE code ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE pkce ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE oidc ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+ALTER TABLE device_code ADD COLUMN IF NOT EXISTS secret_id VARCHAR(16) DEFAULT '' NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE access_token DROP COLUMN IF EXISTS secret_id;
+ALTER TABLE refresh_token DROP COLUMN IF EXISTS secret_id;
+ALTER TABLE code DROP COLUMN IF EXISTS secret_id;
+ALTER TABLE pkce DROP COLUMN IF EXISTS secret_id;
+ALTER TABLE oidc DROP COLUMN IF EXISTS secret_id;
+ALTER TABLE device_code DROP COLUMN IF EXISTS secret_id;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
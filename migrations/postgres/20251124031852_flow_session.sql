@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE flow
+    ADD COLUMN IF NOT EXISTS session_access_token JSONB DEFAULT '{}'::JSONB NOT NULL,
+    ADD COLUMN IF NOT EXISTS session_id_token JSONB DEFAULT '{}'::JSONB NOT NULL;
+
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE flow
+    DROP COLUMN IF EXISTS session_access_token,
+    DROP COLUMN IF EXISTS session_id_token;
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
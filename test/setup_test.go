@@ -11,8 +11,10 @@ import (
 	"github.com/tuanta7/hydros/core/signer/jwt"
 	"github.com/tuanta7/hydros/internal/client"
 	"github.com/tuanta7/hydros/internal/config"
+	"github.com/tuanta7/hydros/internal/device"
 	"github.com/tuanta7/hydros/internal/flow"
 	"github.com/tuanta7/hydros/internal/jwk"
+	"github.com/tuanta7/hydros/internal/login"
 	"github.com/tuanta7/hydros/internal/session"
 	"github.com/tuanta7/hydros/internal/token"
 	restadminv1 "github.com/tuanta7/hydros/internal/transport/rest/admin/v1"
@@ -54,7 +56,7 @@ func SetupTestApp(t *testing.T) *App {
 		},
 	}
 
-	zl, err := logger.NewLogger(cfg.LogLevel)
+	zl, err := zapx.NewLogger(cfg.LogLevel)
 	if err != nil {
 		t.Fatalf("Failed to create zl: %v", err)
 	}
@@ -74,16 +76,16 @@ func SetupTestApp(t *testing.T) *App {
 	jwkUC := jwk.NewUseCase(cfg, aeadAES, jwkRepo, zl)
 
 	clientRepo := client.NewClientRepository(pgClient)
-	clientUC := client.NewUseCase(cfg, clientRepo, zl)
+	clientUC := client.NewUseCase(cfg, clientRepo, aeadAES, zl)
 
 	tokenRepo := token.NewRequestSessionRepo(pgClient)
 	tokenStorage := token.NewRequestSessionStorage(cfg, aeadAES, tokenRepo)
 
 	flowRepo := flow.NewFlowRepository(pgClient)
-	flowUC := flow.NewUseCase(cfg, flowRepo, aeadAES, zl)
+	flowUC := flow.NewUseCase(cfg, flowRepo, tokenStorage, aeadAES, zl)
 
 	loginSessionRepo := session.NewSessionRepository(pgClient)
-	loginSessionUC := session.NewUseCase(loginSessionRepo)
+	loginSessionUC := session.NewUseCase(loginSessionRepo, flowUC)
 
 	hmacSigner, err := hmac.NewSigner(cfg)
 	if err != nil {
@@ -96,6 +98,8 @@ func SetupTestApp(t *testing.T) *App {
 	}
 
 	tokenStrategy := oauth.NewJWTStrategy(cfg, hmacSigner, jwtSigner)
+	deviceStrategy := oauth.NewDeviceStrategy(cfg, hmacSigner)
+	deviceUC := device.NewUseCase(tokenStorage, deviceStrategy)
 
 	idTokenSigner, err := jwt.NewSigner(cfg, jwkUC.GetOrCreateJWKFn(jwk.IDTokenSet))
 	if err != nil {
@@ -109,6 +113,7 @@ func SetupTestApp(t *testing.T) *App {
 		oidc.NewOpenIDConnectAuthorizationCodeFlowHandler(cfg, idTokenStrategy, tokenStorage),
 		pkce.NewProofKeyForCodeExchangeHandler(cfg, tokenStrategy, tokenStorage),
 		oauth.NewClientCredentialsGrantHandler(cfg, tokenStrategy, tokenStorage),
+		oauth.NewDeviceGrantHandler(cfg, tokenStrategy, deviceStrategy, tokenStorage),
 		oauth.NewJWTIntrospectionHandler(tokenStrategy),
 		oauth.NewTokenIntrospectionHandler(cfg, tokenStrategy, tokenStorage),
 	)
@@ -117,8 +122,8 @@ func SetupTestApp(t *testing.T) *App {
 	cookieStore := session.NewCookieStore(cfg)
 	clientHandler := restadminv1.NewClientHandler(clientUC)
 	flowHandler := restadminv1.NewFlowHandler(flowUC)
-	formHandler := restpublicv1.NewFormHandler(cfg, flowUC)
-	oauthHandler := restpublicv1.NewOAuthHandler(cfg, cookieStore, oauthCore, jwkUC, loginSessionUC, flowUC, zl)
+	formHandler := restpublicv1.NewFormHandler(cfg, flowUC, login.NewDefaultStrategy())
+	oauthHandler := restpublicv1.NewOAuthHandler(cfg, cookieStore, oauthCore, idTokenSigner, jwkUC, clientUC, loginSessionUC, flowUC, deviceUC, zl)
 
 	cleanup := func() {
 		_ = zl.Sync()